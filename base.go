@@ -1,27 +1,55 @@
 package pg
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"io"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/go-pg/pg/v9/internal"
 	"github.com/go-pg/pg/v9/internal/pool"
 	"github.com/go-pg/pg/v9/orm"
+	"github.com/go-pg/pg/v9/pgerrcode"
 )
 
+var errConnRejected = errors.New("pg: connection is rejected by BeforeAcquire")
+
+var errRowsOpen = errors.New("pg: Rows must be closed before running other queries")
+
 type baseDB struct {
-	db   orm.DB
-	opt  *Options
-	pool pool.Pooler
+	db       orm.DB
+	opt      *Options
+	pool     pool.Pooler
+	replicas *replicaSet
 
 	fmter      *orm.Formatter
 	queryHooks []QueryHook
+
+	// openRows counts Rows that hold the connection of Tx or Conn.
+	openRows *int32
 }
 
 // PoolStats contains the stats of a connection pool
 type PoolStats pool.Stats
 
+// PoolConnInfo describes a pool connection passed to the Options
+// connection callbacks.
+type PoolConnInfo = pool.ConnInfo
+
+// ConnCloseReason is the reason why the pool closed a connection.
+type ConnCloseReason = pool.CloseReason
+
+const (
+	CloseReasonClosed   = pool.CloseReasonClosed
+	CloseReasonIdle     = pool.CloseReasonIdle
+	CloseReasonMaxAge   = pool.CloseReasonMaxAge
+	CloseReasonBadConn  = pool.CloseReasonBadConn
+	CloseReasonPoolFull = pool.CloseReasonPoolFull
+)
+
 // PoolStats returns connection pool stats.
 func (db *baseDB) PoolStats() *PoolStats {
 	stats := db.pool.Stats()
@@ -30,18 +58,25 @@ func (db *baseDB) PoolStats() *PoolStats {
 
 func (db *baseDB) clone() *baseDB {
 	return &baseDB{
-		db:   db.db,
-		opt:  db.opt,
-		pool: db.pool,
+		db:       db.db,
+		opt:      db.opt,
+		pool:     db.pool,
+		replicas: db.replicas,
 
 		fmter:      db.fmter,
 		queryHooks: copyQueryHooks(db.queryHooks),
+
+		openRows: db.openRows,
 	}
 }
 
 func (db *baseDB) withPool(p pool.Pooler) *baseDB {
 	cp := db.clone()
 	cp.pool = p
+	cp.replicas = nil
+	if _, ok := p.(*pool.SingleConnPool); ok {
+		cp.openRows = new(int32)
+	}
 	return cp
 }
 
@@ -71,21 +106,127 @@ func (db *baseDB) retryBackoff(retry int) time.Duration {
 }
 
 func (db *baseDB) getConn(c context.Context) (*pool.Conn, error) {
-	cn, err := db.pool.Get(c)
-	if err != nil {
-		return nil, err
+	// The only connection of Tx or Conn is held by the rows, so waiting
+	// for it would block forever.
+	if db.openRows != nil && atomic.LoadInt32(db.openRows) > 0 {
+		return nil, errRowsOpen
 	}
 
-	err = db.initConn(c, cn)
-	if err != nil {
+	for attempt := 0; ; attempt++ {
+		cn, err := db.pool.Get(c)
+		if err != nil {
+			return nil, err
+		}
+
+		reused := cn.Inited
+		idle := time.Since(cn.UsedAt())
+		err = db.initConn(c, cn)
+		if err != nil {
+			db.pool.Remove(cn, err)
+			if err := internal.Unwrap(err); err != nil {
+				return nil, err
+			}
+			return nil, err
+		}
+
+		err = db.validateConn(c, cn, reused, idle)
+		if err == nil {
+			return cn, nil
+		}
 		db.pool.Remove(cn, err)
-		if err := internal.Unwrap(err); err != nil {
+		if attempt >= db.opt.PoolSize {
 			return nil, err
 		}
-		return nil, err
 	}
+}
 
-	return cn, nil
+// validateConn checks connections taken from the connection pool with
+// a round trip when they were idle for longer than HealthCheckIdleTime
+// and with the BeforeAcquire hook.
+func (db *baseDB) validateConn(
+	c context.Context, cn *pool.Conn, reused bool, idle time.Duration,
+) error {
+	if _, ok := db.pool.(*pool.ConnPool); !ok {
+		return nil
+	}
+
+	if reused && db.opt.HealthCheckIdleTime > 0 && idle >= db.opt.HealthCheckIdleTime {
+		if err := pingConn(c, cn, db.opt.pingTimeout()); err != nil {
+			return err
+		}
+	}
+
+	if db.opt.BeforeAcquire != nil {
+		parent := &acquirePool{Pooler: db.pool}
+		p := pool.NewSingleConnPool(parent)
+		p.SetConn(cn)
+		ok := db.opt.BeforeAcquire(c, newConn(c, db.withPool(p)))
+		_ = p.Close()
+		if parent.badConnErr != nil {
+			return parent.badConnErr
+		}
+		if !ok {
+			return errConnRejected
+		}
+	}
+
+	return nil
+}
+
+// acquirePool is the parent of the pool of the Conn passed to
+// BeforeAcquire. The connection is kept when the Conn is closed and
+// the error is recorded when queries of the hook find the connection bad.
+type acquirePool struct {
+	pool.Pooler
+	badConnErr error
+}
+
+func (p *acquirePool) Put(cn *pool.Conn) {}
+
+func (p *acquirePool) Remove(cn *pool.Conn, reason error) {
+	if reason == nil {
+		reason = errConnRejected
+	}
+	p.badConnErr = reason
+}
+
+// validatingPool takes connections for transactions and Conn using
+// getConn so they are validated like connections used by queries.
+type validatingPool struct {
+	pool.Pooler
+	db *baseDB
+}
+
+func (p validatingPool) Get(c context.Context) (*pool.Conn, error) {
+	return p.db.getConn(c)
+}
+
+// singleConnParent returns the pool used by SingleConnPool to take
+// a connection.
+func (db *baseDB) singleConnParent() pool.Pooler {
+	if _, ok := db.pool.(*pool.ConnPool); !ok {
+		return db.pool
+	}
+	if db.opt.HealthCheckIdleTime == 0 && db.opt.BeforeAcquire == nil {
+		return db.pool
+	}
+	return validatingPool{Pooler: db.pool, db: db}
+}
+
+// pingConn checks that the server responds to a Sync message.
+func pingConn(c context.Context, cn *pool.Conn, timeout time.Duration) error {
+	err := cn.WithWriter(c, timeout, func(wb *pool.WriteBuffer) error {
+		writeSyncMsg(wb)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return cn.WithReader(c, timeout, func(rd *internal.BufReader) error {
+		_, err := readReadyForQuery(rd)
+		return err
+	})
 }
 
 func (db *baseDB) initConn(c context.Context, cn *pool.Conn) error {
@@ -93,15 +234,14 @@ func (db *baseDB) initConn(c context.Context, cn *pool.Conn) error {
 		return nil
 	}
 	cn.Inited = true
+	db.setNoticeHandler(cn)
 
-	if db.opt.TLSConfig != nil {
-		err := db.enableSSL(c, cn, db.opt.TLSConfig)
-		if err != nil {
-			return err
-		}
+	var err error
+	if db.opt.TargetSessionAttrs.needsCheck() {
+		err = db.startupTarget(c, cn)
+	} else {
+		err = db.startupConn(c, cn, db.opt.RuntimeParams)
 	}
-
-	err := db.startup(c, cn, db.opt.User, db.opt.Password, db.opt.Database, db.opt.ApplicationName)
 	if err != nil {
 		return err
 	}
@@ -115,6 +255,79 @@ func (db *baseDB) initConn(c context.Context, cn *pool.Conn) error {
 	return nil
 }
 
+// startupConn negotiates SSL according to Options.SSLMode and starts up
+// the session with the run-time params.
+func (db *baseDB) startupConn(c context.Context, cn *pool.Conn, params map[string]string) error {
+	mode := db.opt.SSLMode
+	if db.opt.TLSConfig == nil || mode == SSLModeDisable {
+		return db.startup(c, cn, db.opt.User, db.opt.Database, db.opt.ApplicationName, params)
+	}
+
+	var plainErr error
+	if mode == SSLModeAllow {
+		plainErr = db.startup(c, cn, db.opt.User, db.opt.Database, db.opt.ApplicationName, params)
+		if _, ok := plainErr.(Error); !ok {
+			return plainErr
+		}
+
+		// The server rejected the plaintext connection, so try again with SSL.
+		hc, ok := cn.NetConn().(*hostConn)
+		if !ok {
+			return plainErr
+		}
+		_ = cn.Close()
+		netConn, err := hc.dialHost(c, hc.idx)
+		if err != nil {
+			return err
+		}
+		cn.SetNetConn(netConn)
+	}
+
+	err := db.enableSSL(c, cn, db.opt.TLSConfig)
+	if err == errSSLNotSupported {
+		switch mode {
+		case SSLModePrefer:
+			err = nil
+		case SSLModeAllow:
+			return plainErr
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	return db.startup(c, cn, db.opt.User, db.opt.Database, db.opt.ApplicationName, params)
+}
+
+func (db *baseDB) setNoticeHandler(cn *pool.Conn) {
+	if db.opt.OnNotice == nil && !db.opt.LogNotices {
+		return
+	}
+	opt := db.opt
+	cn.SetNoticeHandler(func(fields map[byte]string) {
+		notice := Notice{fields: fields}
+		if opt.LogNotices {
+			logNotice(opt.logger(), notice)
+		}
+		if opt.OnNotice != nil {
+			opt.OnNotice(notice)
+		}
+	})
+}
+
+func logNotice(logger internal.Logging, notice Notice) {
+	severity := notice.Field('V')
+	if severity == "" {
+		severity = notice.Field('S')
+	}
+	level := LogInfo
+	if severity == "WARNING" {
+		level = LogWarn
+	}
+	logger.Log(context.TODO(), level, notice.Field('M'),
+		"severity", severity, "code", notice.Field('C'))
+}
+
 func (db *baseDB) releaseConn(cn *pool.Conn, err error) {
 	if isBadConn(err, false) {
 		db.pool.Remove(cn, err)
@@ -140,7 +353,7 @@ func (db *baseDB) withConn(
 			case <-c.Done():
 				err := db.cancelRequest(cn.ProcessID, cn.SecretKey)
 				if err != nil {
-					internal.Logger.Printf("cancelRequest failed: %s", err)
+					db.opt.logger().Log(c, LogError, "cancelRequest failed", "error", err)
 				}
 				// Signal end of conn use.
 				fnDone <- struct{}{}
@@ -167,13 +380,13 @@ func (db *baseDB) shouldRetry(err error) bool {
 	case nil, context.Canceled, context.DeadlineExceeded:
 		return false
 	}
-	if pgerr, ok := err.(Error); ok {
-		switch pgerr.Field('C') {
-		case "40001", // serialization_failure
-			"53300", // too_many_connections
-			"55000": // attempted to delete invisible tuple
+	if pgerr, ok := internal.AsPGError(err); ok {
+		switch pgerr.Code() {
+		case pgerrcode.SerializationFailure,
+			pgerrcode.TooManyConnections,
+			pgerrcode.ObjectNotInPrerequisiteState: // attempted to delete invisible tuple
 			return true
-		case "57014": // statement_timeout
+		case pgerrcode.QueryCanceled: // statement_timeout
 			return db.opt.RetryStatementTimeout
 		default:
 			return false
@@ -182,11 +395,33 @@ func (db *baseDB) shouldRetry(err error) bool {
 	return isNetworkError(err)
 }
 
+// ServerParams returns run-time parameters reported by the server,
+// e.g. server_version, TimeZone or integer_datetimes. The parameters
+// are taken from a pooled connection that is established if necessary.
+func (db *baseDB) ServerParams() (map[string]string, error) {
+	var params map[string]string
+	err := db.withConn(context.TODO(), func(c context.Context, cn *pool.Conn) error {
+		src := cn.ServerParams()
+		params = make(map[string]string, len(src))
+		for k, v := range src {
+			params[k] = v
+		}
+		return nil
+	})
+	return params, err
+}
+
 // Close closes the database client, releasing any open resources.
 //
 // It is rare to Close a DB, as the DB handle is meant to be
 // long-lived and shared between many goroutines.
 func (db *baseDB) Close() error {
+	if db.replicas != nil {
+		if err := db.replicas.Close(); err != nil {
+			_ = db.pool.Close()
+			return err
+		}
+	}
 	return db.pool.Close()
 }
 
@@ -206,6 +441,7 @@ func (db *baseDB) exec(c context.Context, query interface{}, params ...interface
 		return nil, err
 	}
 
+	readOnly := isReadOnlyQuery(query, false)
 	var res Result
 	var lastErr error
 	for attempt := 0; attempt <= db.opt.MaxRetries; attempt++ {
@@ -216,8 +452,8 @@ func (db *baseDB) exec(c context.Context, query interface{}, params ...interface
 			}
 		}
 
-		lastErr = db.withConn(c, func(c context.Context, cn *pool.Conn) error {
-			res, err = db.simpleQuery(c, cn, query, params...)
+		lastErr = db.withQueryConn(c, readOnly, func(c context.Context, cn *pool.Conn) error {
+			res, err = db.runQuery(c, cn, query, params...)
 			return err
 		})
 		if !db.shouldRetry(lastErr) {
@@ -256,6 +492,10 @@ func (db *baseDB) execOne(c context.Context, query interface{}, params ...interf
 
 // Query executes a query that returns rows, typically a SELECT.
 // The params are for any placeholders in the query.
+//
+// When Options.Replicas are set, the query runs on a replica unless
+// it is an ORM query that modifies data. Use DB.OnPrimary to run
+// raw queries that modify data, e.g. INSERT ... RETURNING.
 func (db *baseDB) Query(model, query interface{}, params ...interface{}) (res Result, err error) {
 	return db.query(context.Background(), model, query, params...)
 }
@@ -270,6 +510,7 @@ func (db *baseDB) query(c context.Context, model, query interface{}, params ...i
 		return nil, err
 	}
 
+	readOnly := isReadOnlyQuery(query, true)
 	var res Result
 	var lastErr error
 	for attempt := 0; attempt <= db.opt.MaxRetries; attempt++ {
@@ -280,8 +521,8 @@ func (db *baseDB) query(c context.Context, model, query interface{}, params ...i
 			}
 		}
 
-		lastErr = db.withConn(c, func(c context.Context, cn *pool.Conn) error {
-			res, err = db.simpleQueryData(c, cn, model, query, params...)
+		lastErr = db.withQueryConn(c, readOnly, func(c context.Context, cn *pool.Conn) error {
+			res, err = db.runQueryData(c, cn, model, query, params...)
 			return err
 		})
 		if !db.shouldRetry(lastErr) {
@@ -451,10 +692,19 @@ func (db *baseDB) ForceDelete(model interface{}) error {
 //   - notnull - sets NOT NULL constraint.
 //   - unique - sets UNIQUE constraint.
 //   - default:value - sets default value.
+//   - index, index:name - creates index, see orm.Index.
+//   - check:'expr' - sets CHECK constraint.
+//   - generated:'expr' - creates stored generated column.
+//   - identity - creates identity column.
 func (db *baseDB) CreateTable(model interface{}, opt *orm.CreateTableOptions) error {
 	return orm.CreateTable(db.db, model, opt)
 }
 
+// CreateIndexes creates indexes of the model defined with the struct tags.
+func (db *baseDB) CreateIndexes(model interface{}, opt *orm.CreateIndexOptions) error {
+	return orm.CreateIndexes(db.db, model, opt)
+}
+
 // DropTable drops table for the model.
 func (db *baseDB) DropTable(model interface{}, opt *orm.DropTableOptions) error {
 	return orm.DropTable(db.db, model, opt)
@@ -542,47 +792,250 @@ func (db *baseDB) simpleQueryData(
 	return res, nil
 }
 
-// Prepare creates a prepared statement for later queries or
-// executions. Multiple queries or executions may be run concurrently
-// from the returned statement.
-func (db *baseDB) Prepare(q string) (*Stmt, error) {
-	return prepareStmt(db.withPool(pool.NewSingleConnPool(db.pool)), q)
+func (db *baseDB) runQuery(
+	c context.Context, cn *pool.Conn, query interface{}, params ...interface{},
+) (*result, error) {
+	if db.opt.StatementCacheSize > 0 {
+		q, args, err := db.formatServerQuery(query, params...)
+		if err != nil {
+			return nil, err
+		}
+		if args == nil {
+			return db.simpleQuery(c, cn, formattedQuery(q))
+		}
+		return db.cachedQueryData(c, cn, nil, string(q), args)
+	}
+	return db.simpleQuery(c, cn, query, params...)
 }
 
-func (db *baseDB) prepare(
-	c context.Context, cn *pool.Conn, q string,
-) (string, [][]byte, error) {
-	name := cn.NextID()
+func (db *baseDB) runQueryData(
+	c context.Context, cn *pool.Conn, model, query interface{}, params ...interface{},
+) (*result, error) {
+	if db.opt.StatementCacheSize > 0 {
+		q, args, err := db.formatServerQuery(query, params...)
+		if err != nil {
+			return nil, err
+		}
+		if args == nil {
+			return db.simpleQueryData(c, cn, model, formattedQuery(q))
+		}
+		return db.cachedQueryData(c, cn, model, string(q), args)
+	}
+	return db.simpleQueryData(c, cn, model, query, params...)
+}
+
+// formatServerQuery formats the query replacing params with $1, $2, ...
+// placeholders and returns the param values. Queries without such params,
+// string queries without params, e.g. SQL scripts, and statements that
+// don't accept params, e.g. SET or CREATE TABLE, are formatted on the client
+// and returned with nil args, so they are sent using the simple query
+// protocol and can contain multiple statements.
+func (db *baseDB) formatServerQuery(
+	query interface{}, params ...interface{},
+) ([]byte, []interface{}, error) {
+	if _, ok := query.(string); ok && len(params) == 0 {
+		b, err := appendQuery(db.fmter, nil, query)
+		return b, nil, err
+	}
+
+	var args []interface{}
+	b, err := appendQuery(db.fmter.WithServerParams(&args), nil, query, params...)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(args) == 0 {
+		return b, nil, nil
+	}
+	if len(args) <= maxServerParams && acceptsServerParams(b) {
+		return b, args, nil
+	}
+
+	b, err = appendQuery(db.fmter, nil, query, params...)
+	return b, nil, err
+}
+
+// maxServerParams is the maximum number of params of a statement.
+const maxServerParams = 65535
+
+// acceptsServerParams reports whether the statement can have params,
+// i.e. it is SELECT, INSERT, UPDATE, DELETE, WITH or VALUES. Utility
+// statements like SET or CREATE TABLE don't accept params.
+func acceptsServerParams(q []byte) bool {
+	q = skipQueryPrefix(q)
+	end := 0
+	for end < len(q) && (q[end] >= 'a' && q[end] <= 'z' || q[end] >= 'A' && q[end] <= 'Z') {
+		end++
+	}
+	switch strings.ToUpper(string(q[:end])) {
+	case "SELECT", "INSERT", "UPDATE", "DELETE", "WITH", "VALUES":
+		return true
+	default:
+		return false
+	}
+}
+
+// skipQueryPrefix skips whitespace, comments and opening parentheses
+// before the first keyword of the query.
+func skipQueryPrefix(q []byte) []byte {
+	for len(q) > 0 {
+		switch {
+		case q[0] == ' ' || q[0] == '\t' || q[0] == '\n' || q[0] == '\r' || q[0] == '(':
+			q = q[1:]
+		case bytes.HasPrefix(q, []byte("--")):
+			ind := bytes.IndexByte(q, '\n')
+			if ind == -1 {
+				return nil
+			}
+			q = q[ind+1:]
+		case bytes.HasPrefix(q, []byte("/*")):
+			ind := bytes.Index(q, []byte("*/"))
+			if ind == -1 {
+				return nil
+			}
+			q = q[ind+2:]
+		default:
+			return q
+		}
+	}
+	return q
+}
+
+// cachedQueryData sends the query with the server-side params using
+// a statement prepared on the connection. On a cache miss the statement
+// is prepared in the same round trip. Rows are discarded when model is nil.
+func (db *baseDB) cachedQueryData(
+	c context.Context, cn *pool.Conn, model interface{}, q string, args []interface{},
+) (*result, error) {
+	stmts := db.connStmts(cn)
+
+	binaryFormat := db.opt.BinaryFormat
+	stmt := stmts.Get(q)
+	prepare := stmt == nil
+	if prepare {
+		stmt = &pool.CachedStmt{
+			Query: q,
+			Name:  cn.NextID(),
+			Desc:  new(stmtDesc),
+		}
+		stmts.Add(stmt)
+		// Column types are not known until the statement is described.
+		binaryFormat = false
+	}
+	desc := stmt.Desc.(*stmtDesc)
+
 	err := cn.WithWriter(c, db.opt.WriteTimeout, func(wb *pool.WriteBuffer) error {
-		writeParseDescribeSyncMsg(wb, name, q)
-		return nil
+		for _, name := range stmts.Closed() {
+			writeCloseMsg(wb, name)
+		}
+		if prepare {
+			writeParseDescribeMsg(wb, stmt.Name, q)
+		}
+		return writeBindExecuteMsg(wb, stmt.Name, desc, binaryFormat, args...)
 	})
 	if err != nil {
-		return "", nil, err
+		return nil, err
 	}
 
-	var columns [][]byte
+	var res *result
 	err = cn.WithReader(c, db.opt.ReadTimeout, func(rd *internal.BufReader) error {
-		columns, err = readParseDescribeSync(rd)
+		res, err = readCachedQueryData(rd, model, desc, desc.binaryColumnTypes(binaryFormat))
 		return err
 	})
+	if prepare {
+		if desc.paramTypes == nil {
+			// Statement was not prepared.
+			stmts.Remove(q)
+		} else {
+			desc.init()
+		}
+	}
 	if err != nil {
-		return "", nil, err
+		if !prepare && isStaleStmtError(err) {
+			stmts.Remove(q)
+		}
+		return nil, err
+	}
+
+	if res.model != nil && res.returned > 0 {
+		if m, ok := res.model.(orm.AfterScanHook); ok {
+			err = m.AfterScan(c)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return res, nil
+}
+
+// isStaleStmtError reports whether the cached statement must be prepared
+// again, e.g. because the table was altered and the plan changed
+// the result type.
+func isStaleStmtError(err error) bool {
+	switch ErrorCode(err) {
+	case pgerrcode.FeatureNotSupported, pgerrcode.InvalidSQLStatementName:
+		return true
+	default:
+		return false
 	}
+}
 
-	return name, columns, nil
+// Prepare creates a prepared statement for later queries or
+// executions. Multiple queries or executions may be run concurrently
+// from the returned statement. The statement is not bound to a single
+// connection and is prepared on pooled connections as needed.
+func (db *baseDB) Prepare(q string) (*Stmt, error) {
+	return prepareStmt(db, q)
 }
 
-func (db *baseDB) closeStmt(c context.Context, cn *pool.Conn, name string) error {
+// connStmts returns the cache of statements prepared on the connection.
+func (db *baseDB) connStmts(cn *pool.Conn) *pool.StmtCache {
+	if cn.Stmts == nil {
+		size := db.opt.StatementCacheSize
+		if size <= 0 {
+			size = defaultStatementCacheSize
+		}
+		cn.Stmts = pool.NewStmtCache(size)
+	}
+	return cn.Stmts
+}
+
+// prepareCached returns the statement prepared on the connection for
+// the query, preparing it when the connection has not seen the query yet.
+func (db *baseDB) prepareCached(
+	c context.Context, cn *pool.Conn, q string,
+) (*pool.CachedStmt, error) {
+	stmts := db.connStmts(cn)
+	if stmt := stmts.Get(q); stmt != nil {
+		return stmt, nil
+	}
+
+	name := cn.NextID()
 	err := cn.WithWriter(c, db.opt.WriteTimeout, func(wb *pool.WriteBuffer) error {
-		writeCloseMsg(wb, name)
-		writeFlushMsg(wb)
+		for _, name := range stmts.Closed() {
+			writeCloseMsg(wb, name)
+		}
+		writeParseDescribeSyncMsg(wb, name, q)
 		return nil
 	})
 	if err != nil {
+		return nil, err
+	}
+
+	var desc *stmtDesc
+	err = cn.WithReader(c, db.opt.ReadTimeout, func(rd *internal.BufReader) error {
+		desc, err = readParseDescribeSync(rd)
 		return err
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	err = cn.WithReader(c, db.opt.ReadTimeout, readCloseCompleteMsg)
-	return err
+	stmt := &pool.CachedStmt{
+		Query: q,
+		Name:  name,
+		Desc:  desc,
+	}
+	stmts.Add(stmt)
+	return stmt, nil
 }
@@ -0,0 +1,319 @@
+package pg
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"github.com/go-pg/pg/v9/internal"
+	"github.com/go-pg/pg/v9/internal/pool"
+	"github.com/go-pg/pg/v9/orm"
+)
+
+var errBatchCopy = errors.New("pg: CopyFrom and CopyTo are not supported in a batch")
+
+// Batch queues queries and sends them to the server using a single
+// connection and a single network round trip. Queries are sent as
+// a pipeline of Parse, Bind, Describe and Execute messages followed by
+// one Sync message when the batch is run. Queries are formatted when they
+// are queued, so a model or params can be changed and queued again.
+//
+// Queued queries are executed in an implicit transaction: if a query fails,
+// the following queries are not executed and changes made by the previous
+// queries are rolled back. Each query must contain a single SQL statement.
+//
+// Batch implements orm.DB so queries created with Batch.Model are queued
+// too, e.g. batch.Model(book).Insert(). Results returned by queued queries
+// are populated when the batch is run. Model hooks that depend on the
+// query results (e.g. AfterSelect) and methods that read the results
+// (e.g. Count, Exists or SelectOrInsert) can't be used with a batch.
+//
+// Batch is not safe for concurrent use by multiple goroutines.
+type Batch struct {
+	db       *baseDB
+	ormDB    orm.DB
+	ctx      context.Context
+	withConn func(context.Context, func(context.Context, *pool.Conn) error) error
+	retry    bool
+
+	queries []*batchQuery
+}
+
+var _ orm.DB = (*Batch)(nil)
+
+type batchQuery struct {
+	model  interface{}
+	query  interface{}
+	params []interface{}
+	one    bool
+
+	// q is the query formatted when it was queued and args are
+	// the values of its $1, $2, ... params.
+	q    []byte
+	args []interface{}
+
+	res *result
+}
+
+// NewBatch returns a new batch that runs queries using the database.
+func (db *baseDB) NewBatch() *Batch {
+	return &Batch{
+		db:       db,
+		ormDB:    db.db,
+		ctx:      db.db.Context(),
+		withConn: db.withConn,
+		retry:    true,
+	}
+}
+
+// NewBatch returns a new batch that runs queries in the transaction.
+func (tx *Tx) NewBatch() *Batch {
+	return &Batch{
+		db:       tx.db,
+		ormDB:    tx,
+		ctx:      tx.ctx,
+		withConn: tx.withConn,
+	}
+}
+
+// Context returns the context.Context of the batch.
+func (b *Batch) Context() context.Context {
+	return b.ctx
+}
+
+// Len returns the number of queued queries.
+func (b *Batch) Len() int {
+	return len(b.queries)
+}
+
+// queue formats the query right away so later changes of the model
+// or params don't affect the queued query.
+func (b *Batch) queue(model, query interface{}, params []interface{}, one bool) (Result, error) {
+	q, args, err := b.db.formatBatchQuery(query, params...)
+	if err != nil {
+		return nil, err
+	}
+	bq := &batchQuery{
+		model:  model,
+		query:  query,
+		params: params,
+		one:    one,
+		q:      q,
+		args:   args,
+		res:    new(result),
+	}
+	b.queries = append(b.queries, bq)
+	return bq.res, nil
+}
+
+// Exec queues a query ignoring returned rows.
+func (b *Batch) Exec(query interface{}, params ...interface{}) (Result, error) {
+	return b.queue(nil, query, params, false)
+}
+
+// ExecContext is an alias for Exec. The context of the batch run is used.
+func (b *Batch) ExecContext(c context.Context, query interface{}, params ...interface{}) (Result, error) {
+	return b.Exec(query, params...)
+}
+
+// ExecOne acts like Exec, but query must affect only one row.
+// The check is done when the batch is run.
+func (b *Batch) ExecOne(query interface{}, params ...interface{}) (Result, error) {
+	return b.queue(nil, query, params, true)
+}
+
+// ExecOneContext is an alias for ExecOne. The context of the batch run is used.
+func (b *Batch) ExecOneContext(c context.Context, query interface{}, params ...interface{}) (Result, error) {
+	return b.ExecOne(query, params...)
+}
+
+// Query queues a query that returns rows into the model.
+func (b *Batch) Query(model, query interface{}, params ...interface{}) (Result, error) {
+	return b.queue(model, query, params, false)
+}
+
+// QueryContext is an alias for Query. The context of the batch run is used.
+func (b *Batch) QueryContext(
+	c context.Context, model, query interface{}, params ...interface{},
+) (Result, error) {
+	return b.Query(model, query, params...)
+}
+
+// QueryOne acts like Query, but query must return only one row.
+// The check is done when the batch is run.
+func (b *Batch) QueryOne(model, query interface{}, params ...interface{}) (Result, error) {
+	mod, err := orm.NewModel(model)
+	if err != nil {
+		return nil, err
+	}
+	return b.queue(mod, query, params, true)
+}
+
+// QueryOneContext is an alias for QueryOne. The context of the batch run is used.
+func (b *Batch) QueryOneContext(
+	c context.Context, model, query interface{}, params ...interface{},
+) (Result, error) {
+	return b.QueryOne(model, query, params...)
+}
+
+// CopyFrom is not supported and always returns an error.
+func (b *Batch) CopyFrom(r io.Reader, query interface{}, params ...interface{}) (Result, error) {
+	return nil, errBatchCopy
+}
+
+// CopyTo is not supported and always returns an error.
+func (b *Batch) CopyTo(w io.Writer, query interface{}, params ...interface{}) (Result, error) {
+	return nil, errBatchCopy
+}
+
+// Model returns new query for the model. Queries are queued in the batch.
+func (b *Batch) Model(model ...interface{}) *orm.Query {
+	return orm.NewQuery(b, model...)
+}
+
+// ModelContext is an alias for Model. The context of the batch run is used.
+func (b *Batch) ModelContext(c context.Context, model ...interface{}) *orm.Query {
+	return orm.NewQueryContext(c, b, model...)
+}
+
+// Select queues a query that selects the model by primary key.
+func (b *Batch) Select(model interface{}) error {
+	return orm.Select(b, model)
+}
+
+// Insert queues a query that inserts the model.
+func (b *Batch) Insert(model ...interface{}) error {
+	return orm.Insert(b, model...)
+}
+
+// Update queues a query that updates the model by primary key.
+func (b *Batch) Update(model interface{}) error {
+	return orm.Update(b, model)
+}
+
+// Delete queues a query that deletes the model by primary key.
+func (b *Batch) Delete(model interface{}) error {
+	return orm.Delete(b, model)
+}
+
+// ForceDelete queues a query that forces delete of the model
+// with deleted_at column.
+func (b *Batch) ForceDelete(model interface{}) error {
+	return orm.ForceDelete(b, model)
+}
+
+func (b *Batch) Formatter() orm.QueryFormatter {
+	return b.db.Formatter()
+}
+
+// Run sends queued queries to the server and returns a result for each query.
+// It returns the first error that occurred. Queued queries are removed
+// from the batch so it can be reused.
+func (b *Batch) Run() ([]Result, error) {
+	return b.run(b.ctx)
+}
+
+// RunContext acts like Run but additionally receives a context.
+func (b *Batch) RunContext(c context.Context) ([]Result, error) {
+	return b.run(c)
+}
+
+func (b *Batch) run(c context.Context) ([]Result, error) {
+	queries := b.queries
+	b.queries = nil
+	if len(queries) == 0 {
+		return nil, nil
+	}
+
+	ctxs := make([]context.Context, len(queries))
+	evts := make([]*QueryEvent, len(queries))
+	for i, q := range queries {
+		qc, evt, err := b.db.beforeQuery(c, b.ormDB, q.model, q.query, q.params)
+		if err != nil {
+			return nil, err
+		}
+		ctxs[i] = qc
+		evts[i] = evt
+	}
+
+	maxRetries := b.db.opt.MaxRetries
+	if !b.retry {
+		maxRetries = 0
+	}
+
+	var lastErr error
+	for attempt := 0; attempt <= maxRetries; attempt++ {
+		if attempt > 0 {
+			lastErr = internal.Sleep(c, b.db.retryBackoff(attempt-1))
+			if lastErr != nil {
+				break
+			}
+		}
+
+		lastErr = b.withConn(c, func(c context.Context, cn *pool.Conn) error {
+			return b.db.batchQuery(c, cn, queries)
+		})
+		if !b.db.shouldRetry(lastErr) {
+			break
+		}
+	}
+
+	results := make([]Result, len(queries))
+	for i, q := range queries {
+		var res Result
+		if lastErr == nil {
+			res = q.res
+		}
+		if err := b.db.afterQuery(ctxs[i], evts[i], res, lastErr); err != nil {
+			return nil, err
+		}
+		results[i] = res
+	}
+	if lastErr != nil {
+		return nil, lastErr
+	}
+
+	for i, q := range queries {
+		if q.res.model != nil && q.res.returned > 0 {
+			if m, ok := q.res.model.(orm.AfterScanHook); ok {
+				if err := m.AfterScan(ctxs[i]); err != nil {
+					return results, err
+				}
+			}
+		}
+		if q.one {
+			if err := internal.AssertOneRow(q.res.RowsAffected()); err != nil {
+				return results, err
+			}
+		}
+	}
+
+	return results, nil
+}
+
+func (db *baseDB) batchQuery(c context.Context, cn *pool.Conn, queries []*batchQuery) error {
+	err := cn.WithWriter(c, db.opt.WriteTimeout, func(wb *pool.WriteBuffer) error {
+		for _, q := range queries {
+			writeParseBindExecuteMsg(wb, q.q, q.args)
+		}
+		writeSyncMsg(wb)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return cn.WithReader(c, db.opt.ReadTimeout, func(rd *internal.BufReader) error {
+		return readBatchData(rd, queries)
+	})
+}
+
+// formatBatchQuery formats a queued query. Like other queries, it uses
+// server params only when the statement cache is enabled.
+func (db *baseDB) formatBatchQuery(query interface{}, params ...interface{}) ([]byte, []interface{}, error) {
+	if db.opt.StatementCacheSize > 0 {
+		return db.formatServerQuery(query, params...)
+	}
+	b, err := appendQuery(db.fmter, nil, query, params...)
+	return b, nil, err
+}
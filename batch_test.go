@@ -0,0 +1,206 @@
+package pg_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/go-pg/pg/v9"
+)
+
+var _ = Describe("Batch", func() {
+	type BatchTest struct {
+		Id   int
+		Name string
+	}
+
+	var db *pg.DB
+
+	BeforeEach(func() {
+		db = pg.Connect(pgOptions())
+
+		err := db.CreateTable((*BatchTest)(nil), nil)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		err := db.DropTable((*BatchTest)(nil), nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		err = db.Close()
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("runs queued queries", func() {
+		batch := db.NewBatch()
+
+		for i := 1; i <= 3; i++ {
+			err := batch.Insert(&BatchTest{Id: i, Name: "test"})
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		res, err := batch.Model((*BatchTest)(nil)).
+			Set("name = ?", "updated").
+			Where("id > ?", 1).
+			Update()
+		Expect(err).NotTo(HaveOccurred())
+
+		var tests []BatchTest
+		_, err = batch.Query(&tests, "SELECT * FROM batch_tests ORDER BY id")
+		Expect(err).NotTo(HaveOccurred())
+
+		var n int
+		_, err = batch.QueryOne(pg.Scan(&n), "SELECT count(*) FROM batch_tests")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(batch.Len()).To(Equal(6))
+
+		results, err := batch.Run()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(results).To(HaveLen(6))
+		Expect(batch.Len()).To(Equal(0))
+
+		Expect(res.RowsAffected()).To(Equal(2))
+		Expect(n).To(Equal(3))
+		Expect(tests).To(Equal([]BatchTest{
+			{Id: 1, Name: "test"},
+			{Id: 2, Name: "updated"},
+			{Id: 3, Name: "updated"},
+		}))
+	})
+
+	It("stops on the first error", func() {
+		batch := db.NewBatch()
+
+		_, err := batch.Exec("INSERT INTO batch_tests (id) VALUES (1)")
+		Expect(err).NotTo(HaveOccurred())
+		_, err = batch.Exec("SELECT * FROM nonexistent")
+		Expect(err).NotTo(HaveOccurred())
+		_, err = batch.Exec("INSERT INTO batch_tests (id) VALUES (2)")
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = batch.Run()
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring(`relation "nonexistent" does not exist`))
+
+		n, err := db.Model((*BatchTest)(nil)).Count()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(n).To(Equal(0))
+	})
+
+	It("runs queued queries in a transaction", func() {
+		tx, err := db.Begin()
+		Expect(err).NotTo(HaveOccurred())
+
+		batch := tx.NewBatch()
+		_, err = batch.Exec("INSERT INTO batch_tests (id) VALUES (?)", 1)
+		Expect(err).NotTo(HaveOccurred())
+		_, err = batch.ExecOne("UPDATE batch_tests SET name = ? WHERE id = ?", "tx", 1)
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = batch.Run()
+		Expect(err).NotTo(HaveOccurred())
+
+		err = tx.Rollback()
+		Expect(err).NotTo(HaveOccurred())
+
+		n, err := db.Model((*BatchTest)(nil)).Count()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(n).To(Equal(0))
+	})
+})
+
+func TestBatchQueueReusedModel(t *testing.T) {
+	srv := newQueryServer(t)
+	defer srv.Close()
+
+	db := pg.Connect(&pg.Options{
+		Addr: srv.Addr(),
+	})
+	defer db.Close()
+
+	type Book struct {
+		Id    int
+		Title string
+	}
+
+	batch := db.NewBatch()
+	book := &Book{Id: 1, Title: "foo"}
+	if err := batch.Insert(book); err != nil {
+		t.Fatal(err)
+	}
+	book.Id, book.Title = 2, "bar"
+	if err := batch.Insert(book); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := batch.Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	wanted := []string{
+		`INSERT INTO "books" ("id", "title") VALUES (1, 'foo')`,
+		`INSERT INTO "books" ("id", "title") VALUES (2, 'bar')`,
+	}
+	if got := srv.Queries(); !equalStrings(got, wanted) {
+		t.Fatalf("got %q, wanted %q", got, wanted)
+	}
+}
+
+func TestBatchQueueServerParams(t *testing.T) {
+	srv := newQueryServer(t)
+	defer srv.Close()
+
+	db := pg.Connect(&pg.Options{
+		Addr:               srv.Addr(),
+		StatementCacheSize: 10,
+	})
+	defer db.Close()
+
+	type Book struct {
+		Id    int
+		Title string
+	}
+
+	batch := db.NewBatch()
+	book := &Book{Id: 1, Title: "foo"}
+	if err := batch.Insert(book); err != nil {
+		t.Fatal(err)
+	}
+	book.Id, book.Title = 2, "bar"
+	if err := batch.Insert(book); err != nil {
+		t.Fatal(err)
+	}
+	title := "baz"
+	if _, err := batch.Exec("SELECT ?", title); err != nil {
+		t.Fatal(err)
+	}
+	title = "qux"
+	if _, err := batch.Exec("SELECT ?", title); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := batch.Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	wanted := []string{
+		`INSERT INTO "books" ("id", "title") VALUES ($1, $2)`,
+		`INSERT INTO "books" ("id", "title") VALUES ($1, $2)`,
+		`SELECT $1`,
+		`SELECT $1`,
+	}
+	if got := srv.Queries(); !equalStrings(got, wanted) {
+		t.Fatalf("got %q, wanted %q", got, wanted)
+	}
+	wanted = []string{
+		"parse ", "bind  [1 foo]",
+		"parse ", "bind  [2 bar]",
+		"parse ", "bind  [baz]",
+		"parse ", "bind  [qux]",
+	}
+	if got := srv.ExtMessages(); !equalStrings(got, wanted) {
+		t.Fatalf("got %q, wanted %q", got, wanted)
+	}
+}
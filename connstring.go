@@ -0,0 +1,654 @@
+package pg
+
+import (
+	"bufio"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// connParams are libpq connection parameters keyed by keyword,
+// e.g. host, port or sslmode.
+type connParams map[string]string
+
+var connKeywords = map[string]struct{}{
+	"host":                 {},
+	"port":                 {},
+	"user":                 {},
+	"password":             {},
+	"passfile":             {},
+	"dbname":               {},
+	"application_name":     {},
+	"connect_timeout":      {},
+	"options":              {},
+	"sslmode":              {},
+	"sslrootcert":          {},
+	"sslcert":              {},
+	"sslkey":               {},
+	"target_session_attrs": {},
+	"load_balance_hosts":   {},
+}
+
+func parseConnString(s string) (connParams, error) {
+	if strings.Contains(s, "://") {
+		return parseConnURL(s)
+	}
+	return parseConnDSN(s)
+}
+
+func parseConnURL(s string) (connParams, error) {
+	// url.Parse does not support multiple hosts so they are cut
+	// from the URL and parsed separately.
+	s, hostList := cutURLHosts(s)
+
+	parsedURL, err := url.Parse(s)
+	if err != nil {
+		return nil, err
+	}
+
+	if parsedURL.Scheme != "postgres" && parsedURL.Scheme != "postgresql" {
+		return nil, errors.New("pg: invalid scheme: " + parsedURL.Scheme)
+	}
+
+	p := make(connParams)
+
+	if hostList != "" {
+		var hosts, ports []string
+		var hasPort bool
+		for _, hostport := range strings.Split(hostList, ",") {
+			host, port := splitHostPort(hostport)
+			if unescaped, err := url.PathUnescape(host); err == nil {
+				host = unescaped
+			}
+			hosts = append(hosts, host)
+			ports = append(ports, port)
+			if port != "" {
+				hasPort = true
+			}
+		}
+		p["host"] = strings.Join(hosts, ",")
+		if hasPort {
+			p["port"] = strings.Join(ports, ",")
+		}
+	}
+
+	if parsedURL.User != nil {
+		p["user"] = parsedURL.User.Username()
+		if password, ok := parsedURL.User.Password(); ok {
+			p["password"] = password
+		}
+	}
+
+	if dbname := strings.TrimPrefix(parsedURL.Path, "/"); dbname != "" {
+		p["dbname"] = dbname
+	}
+
+	query, err := url.ParseQuery(parsedURL.RawQuery)
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range query {
+		if len(values) > 0 {
+			p[key] = values[len(values)-1]
+		}
+	}
+
+	return p, nil
+}
+
+// cutURLHosts returns the URL without the hosts and the hosts.
+func cutURLHosts(s string) (string, string) {
+	ind := strings.Index(s, "://")
+	if ind == -1 {
+		return s, ""
+	}
+	start := ind + len("://")
+
+	end := len(s)
+	if ind := strings.IndexAny(s[start:], "/?#"); ind != -1 {
+		end = start + ind
+	}
+	if ind := strings.LastIndexByte(s[start:end], '@'); ind != -1 {
+		start += ind + 1
+	}
+
+	return s[:start] + s[end:], s[start:end]
+}
+
+func splitHostPort(hostport string) (host, port string) {
+	if strings.HasPrefix(hostport, "[") || strings.Count(hostport, ":") == 1 {
+		if host, port, err := net.SplitHostPort(hostport); err == nil {
+			return host, port
+		}
+	}
+	return hostport, ""
+}
+
+// parseConnDSN parses a keyword/value connection string, e.g.
+// "host=localhost port=5432 dbname='my db'".
+func parseConnDSN(s string) (connParams, error) {
+	p := make(connParams)
+	for {
+		s = strings.TrimLeft(s, " \t\n\r")
+		if s == "" {
+			break
+		}
+
+		ind := strings.IndexByte(s, '=')
+		if ind == -1 {
+			return nil, fmt.Errorf("pg: missing \"=\" after %q in connection string", s)
+		}
+		key := strings.TrimSpace(s[:ind])
+		s = strings.TrimLeft(s[ind+1:], " \t\n\r")
+
+		var value string
+		var err error
+		value, s, err = parseConnDSNValue(s)
+		if err != nil {
+			return nil, err
+		}
+		p[key] = value
+	}
+	return p, nil
+}
+
+func parseConnDSNValue(s string) (value, tail string, _ error) {
+	quoted := strings.HasPrefix(s, "'")
+	if quoted {
+		s = s[1:]
+	}
+
+	b := make([]byte, 0, len(s))
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '\\' && i+1 < len(s):
+			i++
+			b = append(b, s[i])
+		case quoted && c == '\'':
+			return string(b), s[i+1:], nil
+		case !quoted && (c == ' ' || c == '\t' || c == '\n' || c == '\r'):
+			return string(b), s[i:], nil
+		default:
+			b = append(b, c)
+		}
+	}
+
+	if quoted {
+		return "", "", errors.New("pg: unterminated quoted string in connection string")
+	}
+	return string(b), "", nil
+}
+
+// mergeService adds parameters of the service named by the service
+// keyword or PGSERVICE environment variable. Explicit parameters take
+// precedence over the service ones.
+func (p connParams) mergeService() error {
+	name, ok := p["service"]
+	delete(p, "service")
+	if !ok {
+		name = os.Getenv("PGSERVICE")
+	}
+	if name == "" {
+		return nil
+	}
+
+	var files []string
+	if file := os.Getenv("PGSERVICEFILE"); file != "" {
+		files = append(files, file)
+	} else if home := homeDir(); home != "" {
+		files = append(files, filepath.Join(home, ".pg_service.conf"))
+	}
+	if dir := os.Getenv("PGSYSCONFDIR"); dir != "" {
+		files = append(files, filepath.Join(dir, "pg_service.conf"))
+	}
+
+	for _, file := range files {
+		service, err := readServiceFile(file, name)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return err
+		}
+		if service == nil {
+			continue
+		}
+
+		for key, value := range service {
+			if _, ok := p[key]; !ok {
+				p[key] = value
+			}
+		}
+		return nil
+	}
+
+	return fmt.Errorf("pg: definition of service %q not found", name)
+}
+
+// readServiceFile returns parameters of the service from the
+// connection service file or nil if the service is not defined.
+func readServiceFile(file, name string) (connParams, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var service connParams
+	var inService bool
+	scanner := bufio.NewScanner(f)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' {
+			continue
+		}
+
+		if line[0] == '[' {
+			if inService {
+				break
+			}
+			if line[len(line)-1] != ']' {
+				return nil, fmt.Errorf("pg: syntax error in service file %s, line %d", file, lineNum)
+			}
+			inService = line[1:len(line)-1] == name
+			if inService {
+				service = make(connParams)
+			}
+			continue
+		}
+
+		if !inService {
+			continue
+		}
+
+		ind := strings.IndexByte(line, '=')
+		if ind == -1 {
+			return nil, fmt.Errorf("pg: syntax error in service file %s, line %d", file, lineNum)
+		}
+		service[strings.TrimSpace(line[:ind])] = strings.TrimSpace(line[ind+1:])
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return service, nil
+}
+
+func (p connParams) options() (*Options, error) {
+	var unknown []string
+	for key := range p {
+		if _, ok := connKeywords[key]; !ok {
+			unknown = append(unknown, key)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return nil, fmt.Errorf("pg: option %q is not supported", unknown[0])
+	}
+
+	opt := new(Options)
+
+	err := p.addrs(opt)
+	if err != nil {
+		return nil, err
+	}
+
+	opt.User = p["user"]
+	if opt.User == "" {
+		opt.User = "postgres"
+	}
+
+	opt.Database = p["dbname"]
+	if opt.Database == "" {
+		return nil, errors.New("pg: database name not provided")
+	}
+
+	opt.Password = p["password"]
+	if opt.Password == "" {
+		err := p.passfilePasswords(opt)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	opt.ApplicationName = p["application_name"]
+
+	if connTimeout, ok := p["connect_timeout"]; ok {
+		ct, err := strconv.Atoi(connTimeout)
+		if err != nil {
+			return nil, fmt.Errorf("pg: cannot parse connect_timeout option as int")
+		}
+		opt.DialTimeout = time.Second * time.Duration(ct)
+	}
+
+	if options := p["options"]; options != "" {
+		opt.RuntimeParams = map[string]string{
+			"options": options,
+		}
+	}
+
+	switch attrs := TargetSessionAttrs(p["target_session_attrs"]); attrs {
+	case "", TargetSessionAny:
+	case TargetSessionReadWrite, TargetSessionReadOnly,
+		TargetSessionPrimary, TargetSessionStandby, TargetSessionPreferStandby:
+		opt.TargetSessionAttrs = attrs
+	default:
+		return nil, fmt.Errorf("pg: target_session_attrs '%v' is not supported", attrs)
+	}
+
+	switch lb := p["load_balance_hosts"]; lb {
+	case "", "disable":
+	case "random":
+		opt.LoadBalanceHosts = true
+	default:
+		return nil, fmt.Errorf("pg: load_balance_hosts '%v' is not supported", lb)
+	}
+
+	if opt.Network != "unix" {
+		opt.TLSConfig, opt.SSLMode, err = p.tlsConfig()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return opt, nil
+}
+
+// addrs sets Network, Addr and FallbackAddrs of the options.
+func (p connParams) addrs(opt *Options) error {
+	var hosts, ports []string
+	if s := p["host"]; s != "" {
+		hosts = strings.Split(s, ",")
+	}
+	if s := p["port"]; s != "" {
+		ports = strings.Split(s, ",")
+	}
+
+	if len(hosts) == 0 {
+		if len(ports) == 0 {
+			// Let Options.init use the defaults.
+			return nil
+		}
+		hosts = []string{"localhost"}
+	}
+	if len(ports) > 1 && len(ports) != len(hosts) {
+		return fmt.Errorf("pg: could not match %d port numbers to %d hosts",
+			len(ports), len(hosts))
+	}
+
+	for i, host := range hosts {
+		port := "5432"
+		if len(ports) == 1 {
+			port = ports[0]
+		} else if len(ports) > 1 {
+			port = ports[i]
+		}
+		if port == "" {
+			port = "5432"
+		}
+
+		network := "tcp"
+		addr := net.JoinHostPort(host, port)
+		if strings.HasPrefix(host, "/") {
+			network = "unix"
+			addr = filepath.Join(host, ".s.PGSQL."+port)
+		}
+
+		if i == 0 {
+			opt.Network = network
+			opt.Addr = addr
+			continue
+		}
+		if network != opt.Network {
+			return errors.New("pg: mixing Unix socket and TCP hosts is not supported")
+		}
+		opt.FallbackAddrs = append(opt.FallbackAddrs, addr)
+	}
+
+	return nil
+}
+
+// passfilePasswords looks up passwords in the password file. Like libpq
+// the password is looked up for every host, because the connection uses
+// the password of the host that is dialed.
+func (p connParams) passfilePasswords(opt *Options) error {
+	file, ok := p["passfile"]
+	if !ok {
+		file = os.Getenv("PGPASSFILE")
+	}
+	if file == "" {
+		home := homeDir()
+		if home == "" {
+			return nil
+		}
+		file = filepath.Join(home, ".pgpass")
+	}
+
+	addrs := append([]string{opt.Addr}, opt.FallbackAddrs...)
+	for i, addr := range addrs {
+		host, port := passfileHostPort(opt.Network, addr)
+		password, err := readPassfile(file, host, port, opt.Database, opt.User)
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+
+		if i == 0 {
+			opt.Password = password
+			continue
+		}
+		if password != opt.Password {
+			if opt.hostPasswords == nil {
+				opt.hostPasswords = make(map[string]string)
+			}
+			opt.hostPasswords[addr] = password
+		}
+	}
+	return nil
+}
+
+// passfileHostPort returns the host and port the password file entry
+// must match for the address.
+func passfileHostPort(network, addr string) (string, string) {
+	host, port := "localhost", "5432"
+	if network == "tcp" {
+		if h, p, err := net.SplitHostPort(addr); err == nil {
+			host, port = h, p
+		}
+	} else if network == "unix" {
+		port = strings.TrimPrefix(filepath.Ext(addr), ".")
+	}
+	return host, port
+}
+
+// readPassfile returns the password of the first matching line
+// of the password file.
+func readPassfile(file, host, port, database, user string) (string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	if runtime.GOOS != "windows" {
+		fi, err := f.Stat()
+		if err != nil {
+			return "", err
+		}
+		// Like libpq ignore the file when it is accessible by others.
+		if fi.Mode().Perm()&0077 != 0 {
+			return "", nil
+		}
+	}
+
+	wanted := []string{host, port, database, user}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" || line[0] == '#' {
+			continue
+		}
+
+		fields := splitPassfileLine(line)
+		if len(fields) != 5 {
+			continue
+		}
+
+		matched := true
+		for i, field := range fields[:4] {
+			if field != "*" && field != wanted[i] {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return fields[4], nil
+		}
+	}
+	return "", scanner.Err()
+}
+
+func splitPassfileLine(line string) []string {
+	var fields []string
+	b := make([]byte, 0, len(line))
+	for i := 0; i < len(line); i++ {
+		switch c := line[i]; {
+		case c == '\\' && i+1 < len(line):
+			i++
+			b = append(b, line[i])
+		case c == ':' && len(fields) < 4:
+			fields = append(fields, string(b))
+			b = b[:0]
+		default:
+			b = append(b, c)
+		}
+	}
+	return append(fields, string(b))
+}
+
+func (p connParams) tlsConfig() (*tls.Config, SSLMode, error) {
+	sslMode := SSLMode(p["sslmode"])
+	if sslMode == "" {
+		sslMode = SSLModePrefer
+	}
+
+	rootCert := p["sslrootcert"]
+	switch sslMode {
+	case SSLModeDisable:
+		return nil, sslMode, nil
+	case SSLModeAllow, SSLModePrefer:
+	case SSLModeRequire:
+		// Like libpq verify the server certificate when
+		// the root certificate is provided.
+		if rootCert != "" {
+			sslMode = SSLModeVerifyCA
+		}
+	case SSLModeVerifyCA, SSLModeVerifyFull:
+		if rootCert == "" {
+			if home := homeDir(); home != "" {
+				file := filepath.Join(home, ".postgresql", "root.crt")
+				if _, err := os.Stat(file); err == nil {
+					rootCert = file
+				}
+			}
+		}
+	default:
+		return nil, "", fmt.Errorf("pg: sslmode '%v' is not supported", sslMode)
+	}
+
+	var roots *x509.CertPool
+	if sslMode == SSLModeVerifyCA || sslMode == SSLModeVerifyFull {
+		if rootCert != "" && rootCert != "system" {
+			pem, err := ioutil.ReadFile(rootCert)
+			if err != nil {
+				return nil, "", err
+			}
+			roots = x509.NewCertPool()
+			if !roots.AppendCertsFromPEM(pem) {
+				return nil, "", fmt.Errorf("pg: can't parse sslrootcert %s", rootCert)
+			}
+		}
+	}
+
+	var tlsConf *tls.Config
+	switch sslMode {
+	case SSLModeVerifyFull:
+		// ServerName is set to the host that is dialed, so with multiple
+		// hosts the certificate is verified against every host separately.
+		tlsConf = &tls.Config{
+			RootCAs: roots,
+		}
+	case SSLModeVerifyCA:
+		// verify-ca does not check the host name, so the certificate
+		// chain is verified by VerifyPeerCertificate.
+		tlsConf = &tls.Config{
+			InsecureSkipVerify:    true, //nolint
+			VerifyPeerCertificate: verifyPeerCertificate(roots),
+		}
+	default:
+		tlsConf = &tls.Config{
+			InsecureSkipVerify: true, //nolint
+		}
+	}
+
+	certFile, keyFile := p["sslcert"], p["sslkey"]
+	if certFile != "" || keyFile != "" {
+		if certFile == "" || keyFile == "" {
+			return nil, "", errors.New("pg: sslcert and sslkey must be provided together")
+		}
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, "", err
+		}
+		tlsConf.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConf, sslMode, nil
+}
+
+// verifyPeerCertificate verifies the server certificate chain using
+// the roots or the system roots when roots is nil.
+func verifyPeerCertificate(roots *x509.CertPool) func([][]byte, [][]*x509.Certificate) error {
+	return func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+		if len(rawCerts) == 0 {
+			return errors.New("pg: server did not provide a certificate")
+		}
+
+		certs := make([]*x509.Certificate, len(rawCerts))
+		for i, raw := range rawCerts {
+			cert, err := x509.ParseCertificate(raw)
+			if err != nil {
+				return err
+			}
+			certs[i] = cert
+		}
+
+		opts := x509.VerifyOptions{
+			Roots:         roots,
+			Intermediates: x509.NewCertPool(),
+		}
+		for _, cert := range certs[1:] {
+			opts.Intermediates.AddCert(cert)
+		}
+		_, err := certs[0].Verify(opts)
+		return err
+	}
+}
+
+func homeDir() string {
+	return os.Getenv("HOME")
+}
@@ -15,14 +15,23 @@ import (
 // and maintains its own connection pool.
 func Connect(opt *Options) *DB {
 	opt.init()
-	return newDB(
-		context.Background(),
-		&baseDB{
-			opt:   opt,
-			pool:  newConnPool(opt),
-			fmter: orm.NewFormatter(),
-		},
-	)
+
+	baseDB := &baseDB{
+		opt:   opt,
+		pool:  newConnPool(opt),
+		fmter: orm.NewFormatter(),
+	}
+	if opt.SlowQueryThreshold > 0 {
+		baseDB.queryHooks = []QueryHook{slowQueryLogger{opt: opt}}
+	}
+	if len(opt.Replicas) > 0 {
+		baseDB.replicas = newReplicaSet(opt)
+		if opt.ReplicaCheckFrequency > 0 {
+			go baseDB.replicas.checkHealth(baseDB, opt.ReplicaCheckFrequency)
+		}
+	}
+
+	return newDB(context.Background(), baseDB)
 }
 
 func newDB(ctx context.Context, baseDB *baseDB) *DB {
@@ -74,6 +83,15 @@ func (db *DB) WithParam(param string, value interface{}) *DB {
 	return newDB(db.ctx, db.baseDB.WithParam(param, value))
 }
 
+// OnPrimary returns a copy of the DB that runs all queries on the primary
+// ignoring Options.Replicas. It is useful for raw queries that modify data
+// and for reads that must see the latest writes.
+func (db *DB) OnPrimary() *DB {
+	cp := db.baseDB.clone()
+	cp.replicas = nil
+	return newDB(db.ctx, cp)
+}
+
 // Listen listens for notifications sent with NOTIFY command.
 func (db *DB) Listen(channels ...string) *Listener {
 	ln := &Listener{
@@ -107,7 +125,7 @@ var _ orm.DB = (*Conn)(nil)
 // Every Conn must be returned to the database pool after use by
 // calling Conn.Close.
 func (db *DB) Conn() *Conn {
-	return newConn(db.ctx, db.baseDB.withPool(pool.NewSingleConnPool(db.pool)))
+	return newConn(db.ctx, db.baseDB.withPool(pool.NewSingleConnPool(db.singleConnParent())))
 }
 
 func newConn(ctx context.Context, baseDB *baseDB) *Conn {
@@ -211,6 +211,171 @@ func TestBigColumn(t *testing.T) {
 	}
 }
 
+func TestCreateTableIndexes(t *testing.T) {
+	type IndexedModel struct {
+		_ struct{} `pg:"index:indexed_models_lower_name_idx,unique,on:'lower(name)'"`
+
+		ID   int
+		Name string
+		Tags []string `pg:",array,index,using:gin"`
+	}
+
+	db := pg.Connect(pgOptions())
+	defer db.Close()
+
+	err := db.CreateTable((*IndexedModel)(nil), &orm.CreateTableOptions{
+		Temp: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	_, err = db.Query(&names, `
+		SELECT indexname FROM pg_indexes
+		WHERE tablename = 'indexed_models' ORDER BY indexname`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wanted := []string{"indexed_models_lower_name_idx", "indexed_models_pkey", "indexed_models_tags_idx"}
+	if strings.Join(names, ",") != strings.Join(wanted, ",") {
+		t.Fatalf("got %v, wanted %v", names, wanted)
+	}
+
+	err = db.CreateIndexes((*IndexedModel)(nil), &orm.CreateIndexOptions{
+		IfNotExists: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGeneratedColumns(t *testing.T) {
+	type Item struct {
+		ID       int `pg:",identity"`
+		Price    int `pg:",check:'price > 0'"`
+		Quantity int
+		Total    int `pg:"generated:'price * quantity'"`
+	}
+
+	db := pg.Connect(pgOptions())
+	defer db.Close()
+
+	err := db.CreateTable((*Item)(nil), &orm.CreateTableOptions{
+		Temp: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	item := &Item{Price: 2, Quantity: 3}
+	if err := db.Insert(item); err != nil {
+		t.Fatal(err)
+	}
+	if item.ID != 1 || item.Total != 6 {
+		t.Fatalf("got %+v, wanted ID=1 and Total=6", item)
+	}
+
+	item.Quantity = 5
+	if err := db.Update(item); err != nil {
+		t.Fatal(err)
+	}
+	if item.Total != 10 {
+		t.Fatalf("got Total=%d, wanted 10", item.Total)
+	}
+
+	err = db.Insert(&Item{Price: -1})
+	if err == nil || !strings.Contains(err.Error(), "items_price_check") {
+		t.Fatalf("got %v, wanted check constraint violation", err)
+	}
+}
+
+func TestPartitions(t *testing.T) {
+	type PartitionedEvent struct {
+		tableName struct{} `pg:"partitionBy:RANGE (created_at)"`
+
+		ID        int
+		CreatedAt time.Time
+	}
+
+	db := pg.Connect(pgOptions())
+	defer db.Close()
+
+	model := (*PartitionedEvent)(nil)
+	_ = db.DropTable(model, &orm.DropTableOptions{IfExists: true, Cascade: true})
+	_, _ = db.Exec("DROP TABLE IF EXISTS partitioned_events_old")
+	defer db.DropTable(model, &orm.DropTableOptions{IfExists: true, Cascade: true})
+
+	// Partitioned tables can't have a primary key without the partition key.
+	_, err := db.Exec(`CREATE TABLE partitioned_events (id bigint, created_at timestamptz)
+		PARTITION BY RANGE (created_at)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = orm.CreateTimePartitions(db, model, &orm.TimePartitionOptions{
+		Start: time.Date(2020, time.January, 15, 0, 0, 0, 0, time.UTC),
+		Count: 2,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Existing partitions are skipped.
+	err = orm.CreateTimePartitions(db, model, &orm.TimePartitionOptions{
+		Start: time.Date(2020, time.February, 1, 0, 0, 0, 0, time.UTC),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = orm.CreatePartition(db, model, "partitioned_events_default", orm.DefaultBound(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = db.Insert(&PartitionedEvent{
+		ID:        1,
+		CreatedAt: time.Date(2020, time.February, 10, 0, 0, 0, 0, time.UTC),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var n int
+	_, err = db.QueryOne(pg.Scan(&n), "SELECT count(*) FROM partitioned_events_p202002")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Fatalf("got %d rows in the partition, wanted 1", n)
+	}
+
+	err = orm.DetachPartition(db, model, "partitioned_events_p202001", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = db.Exec("ALTER TABLE partitioned_events_p202001 RENAME TO partitioned_events_old")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Exec("DROP TABLE IF EXISTS partitioned_events_old")
+
+	partitions, err := orm.Partitions(db, model)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(partitions) != 2 ||
+		partitions[0] != (orm.Partition{Name: "partitioned_events_default", Bound: "DEFAULT"}) ||
+		partitions[1].Name != "partitioned_events_p202002" {
+		t.Fatalf("got %v", partitions)
+	}
+
+	err = orm.AttachPartition(db, model, "partitioned_events_old",
+		orm.RangeBound("2020-01-01", "2020-02-01"))
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
 var _ = Describe("DB", func() {
 	var db *pg.DB
 	var tx *pg.Tx
@@ -1,10 +1,12 @@
 package pg
 
 import (
+	"fmt"
 	"io"
 	"net"
 
 	"github.com/go-pg/pg/v9/internal"
+	"github.com/go-pg/pg/v9/pgerrcode"
 )
 
 // ErrNoRows is returned by QueryOne and ExecOne when query returned zero rows
@@ -36,6 +38,71 @@ type Error interface {
 
 var _ Error = (*internal.PGError)(nil)
 
+// PGError is the concrete type of errors returned by PostgreSQL server.
+// It provides named accessors for the error fields and can be used
+// with errors.As:
+//
+//    var pgErr pg.PGError
+//    if errors.As(err, &pgErr) {
+//        fmt.Println(pgErr.Code(), pgErr.Constraint())
+//    }
+//
+// Error codes are available as constants in pgerrcode package.
+type PGError = internal.PGError
+
+// ErrorCode returns SQLSTATE code of the first PGError in the err chain
+// or an empty string.
+func ErrorCode(err error) string {
+	if pgErr, ok := internal.AsPGError(err); ok {
+		return pgErr.Code()
+	}
+	return ""
+}
+
+// IsUniqueViolation reports whether err is a unique_violation error.
+func IsUniqueViolation(err error) bool {
+	return ErrorCode(err) == pgerrcode.UniqueViolation
+}
+
+// IsForeignKeyViolation reports whether err is a foreign_key_violation error.
+func IsForeignKeyViolation(err error) bool {
+	return ErrorCode(err) == pgerrcode.ForeignKeyViolation
+}
+
+// IsSerializationFailure reports whether err is a serialization_failure error.
+func IsSerializationFailure(err error) bool {
+	return ErrorCode(err) == pgerrcode.SerializationFailure
+}
+
+// IsDeadlock reports whether err is a deadlock_detected error.
+func IsDeadlock(err error) bool {
+	return ErrorCode(err) == pgerrcode.DeadlockDetected
+}
+
+// IsQueryCanceled reports whether err is a query_canceled error,
+// e.g. because of statement_timeout or pg_cancel_backend.
+func IsQueryCanceled(err error) bool {
+	return ErrorCode(err) == pgerrcode.QueryCanceled
+}
+
+// Notice represents a notice or warning sent by PostgreSQL server
+// using PostgreSQL NoticeResponse protocol, e.g. RAISE NOTICE output.
+type Notice struct {
+	fields map[byte]string
+}
+
+// Field returns a string value associated with a notice field code.
+// Notices have the same fields as errors.
+//
+// https://www.postgresql.org/docs/10/static/protocol-error-fields.html
+func (n Notice) Field(k byte) string {
+	return n.fields[k]
+}
+
+func (n Notice) String() string {
+	return fmt.Sprintf("%s #%s %s", n.Field('S'), n.Field('C'), n.Field('M'))
+}
+
 func isBadConn(err error, allowTimeout bool) bool {
 	if err == nil {
 		return false
@@ -0,0 +1,72 @@
+package pg_test
+
+import (
+	"testing"
+
+	"github.com/go-pg/pg/v9"
+	"github.com/go-pg/pg/v9/pgerrcode"
+)
+
+type wrappedError struct {
+	err error
+}
+
+func (e wrappedError) Error() string {
+	return "wrapped: " + e.err.Error()
+}
+
+func (e wrappedError) Unwrap() error {
+	return e.err
+}
+
+func TestPGError(t *testing.T) {
+	srv := newQueryServer(t)
+	defer srv.Close()
+
+	db := pg.Connect(&pg.Options{
+		Addr: srv.Addr(),
+	})
+	defer db.Close()
+
+	type Dup struct {
+		Id int
+	}
+
+	_, err := db.Model(&Dup{Id: 1}).Insert()
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+
+	pgErr, ok := err.(pg.PGError)
+	if !ok {
+		t.Fatalf("got %T, wanted pg.PGError", err)
+	}
+	if pgErr.Code() != pgerrcode.UniqueViolation ||
+		pgErr.Detail() != "Key (id)=(1) already exists." ||
+		pgErr.Schema() != "public" ||
+		pgErr.Table() != "dups" ||
+		pgErr.Constraint() != "dups_pkey" {
+		t.Fatalf("got %#v", pgErr)
+	}
+	if !pgerrcode.IsIntegrityConstraintViolation(pgErr.Code()) {
+		t.Fatalf("got %q", pgErr.Code())
+	}
+
+	wrapped := wrappedError{err}
+	if !pg.IsUniqueViolation(wrapped) || pg.ErrorCode(wrapped) != "23505" {
+		t.Fatalf("IsUniqueViolation(%v) = false", wrapped)
+	}
+	if pg.IsForeignKeyViolation(wrapped) || pg.IsSerializationFailure(wrapped) ||
+		pg.IsDeadlock(wrapped) || pg.IsQueryCanceled(wrapped) {
+		t.Fatalf("wrong error class for %v", wrapped)
+	}
+	if pg.IsUniqueViolation(pg.ErrNoRows) {
+		t.Fatal("ErrNoRows is not a unique violation")
+	}
+
+	// Connection is not closed on PostgreSQL errors.
+	_, err = db.Exec("SELECT 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+}
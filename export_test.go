@@ -1,6 +1,10 @@
 package pg
 
-import "github.com/go-pg/pg/v9/internal/pool"
+import (
+	"time"
+
+	"github.com/go-pg/pg/v9/internal/pool"
+)
 
 func (db *DB) Pool() pool.Pooler {
 	return db.pool
@@ -9,3 +13,11 @@ func (db *DB) Pool() pool.Pooler {
 func (ln *Listener) CurrentConn() *pool.Conn {
 	return ln.cn
 }
+
+func SetReplicaRetryInterval(d time.Duration) func() {
+	old := replicaRetryInterval
+	replicaRetryInterval = d
+	return func() {
+		replicaRetryInterval = old
+	}
+}
@@ -0,0 +1,167 @@
+package pg
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"github.com/go-pg/pg/v9/internal/pool"
+)
+
+// TargetSessionAttrs is the kind of server a connection must be
+// established with. It has the semantics of libpq target_session_attrs.
+type TargetSessionAttrs string
+
+const (
+	// TargetSessionAny accepts any server.
+	TargetSessionAny TargetSessionAttrs = "any"
+	// TargetSessionReadWrite accepts servers that accept writes by default,
+	// i.e. transaction_read_only is off.
+	TargetSessionReadWrite TargetSessionAttrs = "read-write"
+	// TargetSessionReadOnly accepts servers that don't accept writes
+	// by default, i.e. transaction_read_only is on.
+	TargetSessionReadOnly TargetSessionAttrs = "read-only"
+	// TargetSessionPrimary accepts servers that are not in recovery.
+	TargetSessionPrimary TargetSessionAttrs = "primary"
+	// TargetSessionStandby accepts servers that are in recovery.
+	TargetSessionStandby TargetSessionAttrs = "standby"
+	// TargetSessionPreferStandby accepts a standby server when there is
+	// one and any server otherwise.
+	TargetSessionPreferStandby TargetSessionAttrs = "prefer-standby"
+)
+
+func (attrs TargetSessionAttrs) needsCheck() bool {
+	return attrs != "" && attrs != TargetSessionAny
+}
+
+// hostConn is a connection established by the dialer with the host
+// addrs[idx]. It lets SSL negotiation verify the host that was dialed
+// and the session check fall over to the remaining hosts.
+type hostConn struct {
+	net.Conn
+
+	network string
+	addrs   []string
+	idx     int
+	dial    func(context.Context, string, string) (net.Conn, error)
+}
+
+// dialHost establishes a new connection with the host addrs[idx].
+func (hc *hostConn) dialHost(c context.Context, idx int) (*hostConn, error) {
+	netConn, err := hc.dial(c, hc.network, hc.addrs[idx])
+	if err != nil {
+		return nil, err
+	}
+	return &hostConn{
+		Conn:    netConn,
+		network: hc.network,
+		addrs:   hc.addrs,
+		idx:     idx,
+		dial:    hc.dial,
+	}, nil
+}
+
+// hostname returns the name of the TCP host the connection was
+// established with or an empty string.
+func (hc *hostConn) hostname() string {
+	if hc.network == "unix" {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(hc.addrs[hc.idx])
+	if err != nil {
+		return ""
+	}
+	return host
+}
+
+// startupTarget starts up the connection and checks that the server
+// matches TargetSessionAttrs. When it does not, the remaining hosts are
+// tried in order. With TargetSessionPreferStandby all hosts are tried
+// again accepting any server when there is no standby.
+func (db *baseDB) startupTarget(c context.Context, cn *pool.Conn) error {
+	attrs := db.opt.TargetSessionAttrs
+
+	hc, ok := cn.NetConn().(*hostConn)
+	if !ok {
+		if err := db.startupConn(c, cn, db.opt.RuntimeParams); err != nil {
+			return err
+		}
+		return db.checkSessionAttrs(c, cn, attrs, cn.RemoteAddr().String())
+	}
+
+	modes := []TargetSessionAttrs{attrs}
+	if attrs == TargetSessionPreferStandby {
+		modes = []TargetSessionAttrs{TargetSessionStandby, TargetSessionAny}
+	}
+
+	var lastErr error
+	dialed := true
+	for _, mode := range modes {
+		var start int
+		if dialed {
+			start = hc.idx
+		}
+		for i := start; i < len(hc.addrs); i++ {
+			addr := hc.addrs[i]
+			if !dialed {
+				netConn, err := hc.dialHost(c, i)
+				if err != nil {
+					lastErr = err
+					if c.Err() != nil {
+						return err
+					}
+					continue
+				}
+				cn.SetNetConn(netConn)
+			}
+			dialed = false
+
+			err := db.startupConn(c, cn, db.opt.RuntimeParams)
+			if err == nil {
+				err = db.checkSessionAttrs(c, cn, mode, addr)
+				if err == nil {
+					return nil
+				}
+				_ = terminateConn(cn)
+			}
+			_ = cn.Close()
+
+			lastErr = err
+			if c.Err() != nil {
+				return err
+			}
+		}
+	}
+	return lastErr
+}
+
+// checkSessionAttrs checks that the server matches the attrs.
+func (db *baseDB) checkSessionAttrs(
+	c context.Context, cn *pool.Conn, attrs TargetSessionAttrs, addr string,
+) error {
+	var query, wanted string
+	switch attrs {
+	case "", TargetSessionAny, TargetSessionPreferStandby:
+		return nil
+	case TargetSessionReadWrite:
+		query, wanted = "SHOW transaction_read_only", "off"
+	case TargetSessionReadOnly:
+		query, wanted = "SHOW transaction_read_only", "on"
+	case TargetSessionPrimary:
+		query, wanted = "SELECT pg_catalog.pg_is_in_recovery()", "f"
+	case TargetSessionStandby:
+		query, wanted = "SELECT pg_catalog.pg_is_in_recovery()", "t"
+	default:
+		return fmt.Errorf("pg: target_session_attrs '%v' is not supported", attrs)
+	}
+
+	var got string
+	_, err := db.simpleQueryData(c, cn, Scan(&got), query)
+	if err != nil {
+		return err
+	}
+	if got != wanted {
+		return fmt.Errorf("pg: server %s does not match target_session_attrs=%s", addr, attrs)
+	}
+	return nil
+}
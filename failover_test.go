@@ -0,0 +1,110 @@
+package pg_test
+
+import (
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/go-pg/pg/v9"
+)
+
+func closedAddr(t *testing.T) string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+	return addr
+}
+
+func TestTargetSessionAttrs(t *testing.T) {
+	primary := newQueryServer(t)
+	defer primary.Close()
+
+	standby := newQueryServer(t)
+	defer standby.Close()
+	standby.SetStandby(true)
+
+	down := closedAddr(t)
+
+	tests := []struct {
+		attrs  pg.TargetSessionAttrs
+		addrs  []string
+		wanted *queryServer
+	}{
+		{pg.TargetSessionAny, []string{standby.Addr(), primary.Addr()}, standby},
+		{pg.TargetSessionReadWrite, []string{standby.Addr(), primary.Addr()}, primary},
+		{pg.TargetSessionReadOnly, []string{primary.Addr(), standby.Addr()}, standby},
+		{pg.TargetSessionPrimary, []string{down, standby.Addr(), primary.Addr()}, primary},
+		{pg.TargetSessionStandby, []string{primary.Addr(), down, standby.Addr()}, standby},
+		{pg.TargetSessionPreferStandby, []string{primary.Addr(), standby.Addr()}, standby},
+		{pg.TargetSessionPreferStandby, []string{down, primary.Addr()}, primary},
+	}
+	for _, test := range tests {
+		db := pg.Connect(&pg.Options{
+			Addr:               test.addrs[0],
+			FallbackAddrs:      test.addrs[1:],
+			TargetSessionAttrs: test.attrs,
+			PoolSize:           1,
+		})
+
+		_, err := db.Exec("SELECT 'target'")
+		if err != nil {
+			t.Fatalf("%s: %s", test.attrs, err)
+		}
+
+		if !containsString(test.wanted.Queries(), "SELECT 'target'") {
+			t.Fatalf("%s: query is not sent to %s", test.attrs, test.wanted.Addr())
+		}
+		_ = db.Close()
+		resetQueries(primary, standby)
+	}
+}
+
+func TestTargetSessionAttrsNoServer(t *testing.T) {
+	primary := newQueryServer(t)
+	defer primary.Close()
+
+	db := pg.Connect(&pg.Options{
+		Addr:               primary.Addr(),
+		FallbackAddrs:      []string{closedAddr(t)},
+		TargetSessionAttrs: pg.TargetSessionStandby,
+	})
+	defer db.Close()
+
+	_, err := db.Exec("SELECT 1")
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if strings.Contains(err.Error(), "target_session_attrs") {
+		t.Fatalf("got %q, wanted the dial error of the last host", err)
+	}
+
+	db = pg.Connect(&pg.Options{
+		Addr:               primary.Addr(),
+		TargetSessionAttrs: pg.TargetSessionStandby,
+	})
+	defer db.Close()
+
+	_, err = db.Exec("SELECT 1")
+	wanted := "pg: server " + primary.Addr() + " does not match target_session_attrs=standby"
+	if err == nil || err.Error() != wanted {
+		t.Fatalf("got %v, wanted %q", err, wanted)
+	}
+}
+
+func containsString(ss []string, s string) bool {
+	for _, el := range ss {
+		if el == s {
+			return true
+		}
+	}
+	return false
+}
+
+func resetQueries(srvs ...*queryServer) {
+	for _, srv := range srvs {
+		srv.ResetQueries()
+	}
+}
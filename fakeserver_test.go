@@ -0,0 +1,586 @@
+package pg_test
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/binary"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// queryServer is a fake server that answers every simple or extended
+// query with an empty result and records the startup parameters and queries.
+// DO queries send a notice, SET TimeZone reports the new TimeZone,
+// queries mentioning dups table fail with unique_violation,
+// generate_series(1, n) queries return n rows with a single int column
+// and the server reports whether it is a standby like a real one.
+// SSL requests are refused unless the server has a TLS config.
+type queryServer struct {
+	ln net.Listener
+
+	mu       sync.Mutex
+	conns    []net.Conn
+	startup  map[string]string
+	sessions []bool
+	queries  []string
+	failures map[string]*queryFailure
+	extMsgs  []string
+	standby  bool
+	password string
+	tlsConf  *tls.Config
+	sslOnly  bool
+}
+
+type queryFailure struct {
+	code string
+	n    int
+}
+
+func newQueryServer(t *testing.T) *queryServer {
+	return newQueryServerAt(t, "127.0.0.1:0")
+}
+
+// newQueryServerAt is like newQueryServer, but listens on addr,
+// e.g. to restart a server that was closed.
+func newQueryServerAt(t *testing.T, addr string) *queryServer {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := &queryServer{
+		ln: ln,
+	}
+	go srv.serve()
+	return srv
+}
+
+func (srv *queryServer) Addr() string {
+	return srv.ln.Addr().String()
+}
+
+func (srv *queryServer) Close() error {
+	return srv.ln.Close()
+}
+
+// CloseConns closes the accepted connections, e.g. to simulate a failover.
+func (srv *queryServer) CloseConns() {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	for _, cn := range srv.conns {
+		_ = cn.Close()
+	}
+	srv.conns = nil
+}
+
+// SetStandby makes the server report that it is a read-only standby.
+func (srv *queryServer) SetStandby(standby bool) {
+	srv.mu.Lock()
+	srv.standby = standby
+	srv.mu.Unlock()
+}
+
+// SetPassword makes the server ask for the cleartext password.
+func (srv *queryServer) SetPassword(password string) {
+	srv.mu.Lock()
+	srv.password = password
+	srv.mu.Unlock()
+}
+
+// SetTLS makes the server accept SSL requests. When sslOnly is set,
+// plaintext connections are rejected like with hostssl in pg_hba.conf.
+func (srv *queryServer) SetTLS(tlsConf *tls.Config, sslOnly bool) {
+	srv.mu.Lock()
+	srv.tlsConf = tlsConf
+	srv.sslOnly = sslOnly
+	srv.mu.Unlock()
+}
+
+// Sessions reports for every startup message whether it was sent over SSL.
+func (srv *queryServer) Sessions() []bool {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	return srv.sessions
+}
+
+// Startup returns the parameters of the last startup message.
+func (srv *queryServer) Startup() map[string]string {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	return srv.startup
+}
+
+func (srv *queryServer) Queries() []string {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	return srv.queries
+}
+
+func (srv *queryServer) ResetQueries() {
+	srv.mu.Lock()
+	srv.queries = nil
+	srv.mu.Unlock()
+}
+
+// ExtMessages returns extended query protocol messages, e.g.
+// "parse 1", "bind 1 [foo]" or "close 1".
+func (srv *queryServer) ExtMessages() []string {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	return srv.extMsgs
+}
+
+func (srv *queryServer) addQuery(q string) {
+	srv.mu.Lock()
+	srv.queries = append(srv.queries, q)
+	srv.mu.Unlock()
+}
+
+func (srv *queryServer) addExtMsg(format string, args ...interface{}) {
+	srv.mu.Lock()
+	srv.extMsgs = append(srv.extMsgs, fmt.Sprintf(format, args...))
+	srv.mu.Unlock()
+}
+
+// FailQuery makes the next n executions of the query fail with the code.
+func (srv *queryServer) FailQuery(query, code string, n int) {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	if srv.failures == nil {
+		srv.failures = make(map[string]*queryFailure)
+	}
+	srv.failures[query] = &queryFailure{code: code, n: n}
+}
+
+// failure returns the code the query must fail with or an empty string.
+// Syntax errors and access rule violations are reported when the query
+// is parsed and other errors when it is executed.
+func (srv *queryServer) failure(query string, parse bool) string {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	f := srv.failures[query]
+	if f == nil || f.n == 0 || strings.HasPrefix(f.code, "42") != parse {
+		return ""
+	}
+	f.n--
+	return f.code
+}
+
+func (srv *queryServer) serve() {
+	for {
+		cn, err := srv.ln.Accept()
+		if err != nil {
+			return
+		}
+		srv.mu.Lock()
+		srv.conns = append(srv.conns, cn)
+		srv.mu.Unlock()
+		go srv.serveConn(cn)
+	}
+}
+
+func (srv *queryServer) serveConn(cn net.Conn) {
+	defer cn.Close()
+
+	startup, err := readStartupMsg(cn)
+	if err != nil {
+		return
+	}
+
+	var ssl bool
+	if binary.BigEndian.Uint32(startup) == 80877103 { // SSLRequest
+		srv.mu.Lock()
+		tlsConf := srv.tlsConf
+		srv.mu.Unlock()
+
+		if tlsConf == nil {
+			_, _ = cn.Write([]byte{'N'})
+		} else {
+			_, _ = cn.Write([]byte{'S'})
+			tlsConn := tls.Server(cn, tlsConf)
+			if err := tlsConn.Handshake(); err != nil {
+				return
+			}
+			cn = tlsConn
+			ssl = true
+		}
+
+		startup, err = readStartupMsg(cn)
+		if err != nil {
+			return
+		}
+	}
+
+	// Skip protocol version and parse name/value pairs.
+	params := make(map[string]string)
+	fields := strings.Split(string(startup[4:]), "\x00")
+	for i := 0; i+1 < len(fields); i += 2 {
+		if fields[i] == "" {
+			break
+		}
+		params[fields[i]] = fields[i+1]
+	}
+	srv.mu.Lock()
+	srv.startup = params
+	srv.sessions = append(srv.sessions, ssl)
+	password := srv.password
+	sslOnly := srv.sslOnly
+	srv.mu.Unlock()
+
+	if sslOnly && !ssl {
+		writeServerMsg(cn, 'E', []byte("SFATAL\x00C28000\x00"+
+			"Mno pg_hba.conf entry for host, no encryption\x00\x00"))
+		return
+	}
+
+	if password != "" {
+		writeServerMsg(cn, 'R', []byte{0, 0, 0, 3}) // AuthenticationCleartextPassword
+		typ, msg, err := readClientMsg(cn)
+		if err != nil {
+			return
+		}
+		if typ != 'p' || string(msg) != password+"\x00" {
+			writeServerMsg(cn, 'E', []byte("SFATAL\x00C28P01\x00"+
+				"Mpassword authentication failed\x00\x00"))
+			return
+		}
+	}
+
+	writeServerMsg(cn, 'R', []byte{0, 0, 0, 0})                   // AuthenticationOk
+	writeServerMsg(cn, 'S', []byte("server_version\x0012.1\x00")) // ParameterStatus
+	writeServerMsg(cn, 'S', []byte("TimeZone\x00UTC\x00"))        // ParameterStatus
+	writeServerMsg(cn, 'Z', []byte{'I'})                          // ReadyForQuery
+
+	stmts := make(map[string]string)
+	var portal string
+	var pos portalPos
+	var failed bool
+	for {
+		typ, msg, err := readClientMsg(cn)
+		if err != nil {
+			return
+		}
+
+		switch typ {
+		case 'Q':
+			q := string(msg[:len(msg)-1])
+			srv.addQuery(q)
+			if code := srv.failure(q, true); code != "" {
+				writeServerMsg(cn, 'E', []byte("SERROR\x00C"+code+"\x00Mfailed\x00\x00"))
+			} else {
+				srv.execute(cn, q, nil)
+			}
+			writeServerMsg(cn, 'Z', []byte{'I'}) // ReadyForQuery
+		case 'P':
+			fields := strings.SplitN(string(msg), "\x00", 3)
+			name, q := fields[0], fields[1]
+			srv.addQuery(q)
+			srv.addExtMsg("parse %s", name)
+			if failed {
+				continue
+			}
+			if code := srv.failure(q, true); code != "" {
+				writeServerMsg(cn, 'E', []byte("SERROR\x00C"+code+"\x00Mfailed\x00\x00"))
+				failed = true
+				continue
+			}
+			stmts[name] = q
+			writeServerMsg(cn, '1', nil) // ParseComplete
+		case 'D':
+			if failed {
+				continue
+			}
+			q := stmts[string(msg[1:len(msg)-1])]
+			if msg[0] == 'S' {
+				n := uint16(strings.Count(q, "$"))
+				desc := []byte{byte(n >> 8), byte(n)}
+				for i := uint16(0); i < n; i++ {
+					desc = append(desc, 0, 0, 0, 25) // text
+				}
+				writeServerMsg(cn, 't', desc) // ParameterDescription
+			}
+			if seriesLen(q) >= 0 {
+				writeSeriesRowDescription(cn)
+			} else {
+				writeServerMsg(cn, 'n', nil) // NoData
+			}
+		case 'B':
+			name, args := parseBindMsg(msg)
+			srv.addExtMsg("bind %s %v", name, args)
+			if failed {
+				continue
+			}
+			portal = name
+			pos = portalPos{}
+			writeServerMsg(cn, '2', nil) // BindComplete
+		case 'E':
+			if failed {
+				continue
+			}
+			pos.maxRows = int(binary.BigEndian.Uint32(msg[len(msg)-4:]))
+			failed = !srv.execute(cn, stmts[portal], &pos)
+		case 'C':
+			name := string(msg[1 : len(msg)-1])
+			srv.addExtMsg("close %s", name)
+			delete(stmts, name)
+			if failed {
+				continue
+			}
+			writeServerMsg(cn, '3', nil) // CloseComplete
+		case 'S':
+			failed = false
+			writeServerMsg(cn, 'Z', []byte{'I'}) // ReadyForQuery
+		case 'H': // Flush
+		default:
+			return
+		}
+	}
+}
+
+// newTestCert returns a self-signed server certificate for the hosts
+// and writes it to the file that can be used as sslrootcert.
+func newTestCert(t *testing.T, file string, hosts ...string) tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: hosts[0]},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	for _, host := range hosts {
+		if ip := net.ParseIP(host); ip != nil {
+			tmpl.IPAddresses = append(tmpl.IPAddresses, ip)
+		} else {
+			tmpl.DNSNames = append(tmpl.DNSNames, host)
+		}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	if err := ioutil.WriteFile(file, b, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	return tls.Certificate{
+		Certificate: [][]byte{der},
+		PrivateKey:  key,
+	}
+}
+
+// readStartupMsg reads a startup or SSL request message
+// and returns its contents.
+func readStartupMsg(cn net.Conn) ([]byte, error) {
+	var size uint32
+	if err := binary.Read(cn, binary.BigEndian, &size); err != nil {
+		return nil, err
+	}
+	msg := make([]byte, size-4)
+	if _, err := io.ReadFull(cn, msg); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+// portalPos is the position of a portal executed with a row limit.
+type portalPos struct {
+	offset  int
+	maxRows int
+}
+
+// execute writes the response to the query and reports whether
+// the query succeeded. pos is nil for simple queries.
+func (srv *queryServer) execute(cn net.Conn, q string, pos *portalPos) bool {
+	if code := srv.failure(q, false); code != "" {
+		writeServerMsg(cn, 'E', []byte("SERROR\x00C"+code+"\x00Mfailed\x00\x00"))
+		return false
+	}
+	if strings.Contains(q, "dups") {
+		writeServerMsg(cn, 'E', []byte("SERROR\x00C23505\x00"+
+			"Mduplicate key value violates unique constraint \"dups_pkey\"\x00"+
+			"DKey (id)=(1) already exists.\x00spublic\x00tdups\x00ndups_pkey\x00\x00"))
+		return false
+	}
+
+	if n := seriesLen(q); n >= 0 {
+		return writeSeries(cn, n, pos)
+	}
+
+	switch q {
+	case "SHOW transaction_read_only":
+		writeTextRow(cn, "transaction_read_only", srv.isStandby("on", "off"))
+		return true
+	case "SELECT pg_catalog.pg_is_in_recovery()":
+		writeTextRow(cn, "pg_is_in_recovery", srv.isStandby("t", "f"))
+		return true
+	}
+
+	tag := "SELECT 0"
+	switch {
+	case strings.HasPrefix(q, "INSERT"):
+		tag = "INSERT 0 1"
+	case strings.HasPrefix(q, "DO"):
+		tag = "DO"
+		// NoticeResponse
+		writeServerMsg(cn, 'N', []byte("SNOTICE\x00C00000\x00Mhello\x00\x00"))
+	case strings.HasPrefix(q, "SET TimeZone"):
+		tag = "SET"
+		// ParameterStatus
+		writeServerMsg(cn, 'S', []byte("TimeZone\x00Europe/Moscow\x00"))
+	}
+	writeServerMsg(cn, 'C', append([]byte(tag), 0)) // CommandComplete
+	return true
+}
+
+func (srv *queryServer) isStandby(yes, no string) string {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	if srv.standby {
+		return yes
+	}
+	return no
+}
+
+// writeTextRow writes a result with a single text column and row.
+func writeTextRow(w io.Writer, column, value string) {
+	b := []byte{0, 1}
+	b = append(b, column...)
+	b = append(b, 0)
+	b = append(b, 0, 0, 0, 0, 0, 0) // table OID and column number
+	b = append(b, 0, 0, 0, 25)      // text
+	b = append(b, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0, 0)
+	writeServerMsg(w, 'T', b) // RowDescription
+
+	b = []byte{0, 1, 0, 0, 0, byte(len(value))}
+	writeServerMsg(w, 'D', append(b, value...)) // DataRow
+
+	writeServerMsg(w, 'C', []byte("SELECT 1\x00")) // CommandComplete
+}
+
+var seriesRe = regexp.MustCompile(`generate_series\(1, (\d+)\)`)
+
+// seriesLen returns the number of rows returned by the query or -1.
+func seriesLen(q string) int {
+	m := seriesRe.FindStringSubmatch(q)
+	if m == nil {
+		return -1
+	}
+	n, _ := strconv.Atoi(m[1])
+	return n
+}
+
+func writeSeriesRowDescription(w io.Writer) {
+	b := []byte{0, 1}
+	b = append(b, "n\x00"...)
+	b = append(b, 0, 0, 0, 0, 0, 0) // table OID and column number
+	b = append(b, 0, 0, 0, 23)      // int4
+	b = append(b, 0, 4, 0xff, 0xff, 0xff, 0xff, 0, 0)
+	writeServerMsg(w, 'T', b) // RowDescription
+}
+
+// writeSeries writes rows 1..n. When the portal has a row limit,
+// the rows after the limit are left for the next execution.
+func writeSeries(w io.Writer, n int, pos *portalPos) bool {
+	start, end := 0, n
+	if pos == nil {
+		writeSeriesRowDescription(w)
+	} else {
+		start = pos.offset
+		if pos.maxRows > 0 && start+pos.maxRows < n {
+			end = start + pos.maxRows
+		}
+		pos.offset = end
+	}
+
+	for i := start + 1; i <= end; i++ {
+		v := strconv.Itoa(i)
+		b := []byte{0, 1, 0, 0, 0, byte(len(v))}
+		writeServerMsg(w, 'D', append(b, v...)) // DataRow
+	}
+
+	if end < n {
+		writeServerMsg(w, 's', nil) // PortalSuspended
+		return true
+	}
+	writeServerMsg(w, 'C', []byte("SELECT "+strconv.Itoa(n)+"\x00")) // CommandComplete
+	return true
+}
+
+func parseBindMsg(msg []byte) (string, []string) {
+	fields := bytes.SplitN(msg, []byte{0}, 3)
+	name := string(fields[1])
+	b := fields[2]
+
+	// Skip parameter format codes.
+	n := int(binary.BigEndian.Uint16(b))
+	b = b[2+2*n:]
+
+	n = int(binary.BigEndian.Uint16(b))
+	b = b[2:]
+	args := make([]string, n)
+	for i := range args {
+		size := int32(binary.BigEndian.Uint32(b))
+		b = b[4:]
+		if size == -1 {
+			args[i] = "NULL"
+			continue
+		}
+		args[i] = string(b[:size])
+		b = b[size:]
+	}
+	return name, args
+}
+
+func writeServerMsg(w io.Writer, typ byte, b []byte) {
+	msg := []byte{typ, 0, 0, 0, 0}
+	binary.BigEndian.PutUint32(msg[1:], uint32(len(b)+4))
+	_, _ = w.Write(append(msg, b...))
+}
+
+func readClientMsg(r io.Reader) (byte, []byte, error) {
+	hdr := make([]byte, 5)
+	if _, err := io.ReadFull(r, hdr); err != nil {
+		return 0, nil, err
+	}
+	b := make([]byte, binary.BigEndian.Uint32(hdr[1:])-4)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return 0, nil, err
+	}
+	return hdr[0], b, nil
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,159 @@
+package pg_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-pg/pg/v9"
+)
+
+func TestHealthCheckIdleTime(t *testing.T) {
+	srv := newQueryServer(t)
+	defer srv.Close()
+
+	db := pg.Connect(&pg.Options{
+		Addr:                srv.Addr(),
+		PoolSize:            1,
+		HealthCheckIdleTime: time.Nanosecond,
+	})
+	defer db.Close()
+
+	if _, err := db.Exec("SELECT 1"); err != nil {
+		t.Fatal(err)
+	}
+
+	// The idle connection is alive and is reused.
+	if _, err := db.Exec("SELECT 2"); err != nil {
+		t.Fatal(err)
+	}
+	if st := db.PoolStats(); st.Misses != 1 || st.BadConnClosed != 0 {
+		t.Fatalf("got %d misses and %d bad conns, wanted 1 and 0", st.Misses, st.BadConnClosed)
+	}
+
+	srv.CloseConns()
+
+	// The dead connection fails the check and is replaced.
+	if _, err := db.Exec("SELECT 3"); err != nil {
+		t.Fatal(err)
+	}
+	st := db.PoolStats()
+	if st.BadConnClosed != 1 || st.TotalConns != 1 {
+		t.Fatalf("got %d bad conns and %d conns, wanted 1 and 1",
+			st.BadConnClosed, st.TotalConns)
+	}
+}
+
+func TestBeforeAcquire(t *testing.T) {
+	srv := newQueryServer(t)
+	defer srv.Close()
+
+	var calls int
+	db := pg.Connect(&pg.Options{
+		Addr:     srv.Addr(),
+		PoolSize: 1,
+		BeforeAcquire: func(c context.Context, cn *pg.Conn) bool {
+			calls++
+			if calls == 1 {
+				return false
+			}
+			_, err := cn.ExecContext(c, "SELECT 'acquire'")
+			return err == nil
+		},
+	})
+	defer db.Close()
+
+	if _, err := db.Exec("SELECT 1"); err != nil {
+		t.Fatal(err)
+	}
+
+	if calls != 2 {
+		t.Fatalf("got %d calls, wanted 2", calls)
+	}
+	wanted := []string{"SELECT 'acquire'", "SELECT 1"}
+	if got := srv.Queries(); !equalStrings(got, wanted) {
+		t.Fatalf("got %q, wanted %q", got, wanted)
+	}
+	st := db.PoolStats()
+	if st.BadConnClosed != 1 || st.TotalConns != 1 {
+		t.Fatalf("got %d bad conns and %d conns, wanted 1 and 1",
+			st.BadConnClosed, st.TotalConns)
+	}
+
+	// Transactions validate the connection once when it is acquired.
+	err := db.RunInTransaction(func(tx *pg.Tx) error {
+		_, err := tx.Exec("SELECT 2")
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls != 3 {
+		t.Fatalf("got %d calls, wanted 3", calls)
+	}
+}
+
+func TestBeforeAcquireClose(t *testing.T) {
+	srv := newQueryServer(t)
+	defer srv.Close()
+
+	db := pg.Connect(&pg.Options{
+		Addr:     srv.Addr(),
+		PoolSize: 1,
+		BeforeAcquire: func(c context.Context, cn *pg.Conn) bool {
+			_, err := cn.ExecContext(c, "SELECT 'acquire'")
+			return cn.Close() == nil && err == nil
+		},
+	})
+	defer db.Close()
+
+	for i := 0; i < 2; i++ {
+		if _, err := db.Exec("SELECT 1"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Closing the Conn in the hook keeps the connection in the pool.
+	st := db.PoolStats()
+	if st.Misses != 1 || st.TotalConns != 1 {
+		t.Fatalf("got %d misses and %d conns, wanted 1 and 1", st.Misses, st.TotalConns)
+	}
+}
+
+func TestBeforeAcquireBadConn(t *testing.T) {
+	srv := newQueryServer(t)
+	defer srv.Close()
+
+	var calls int
+	db := pg.Connect(&pg.Options{
+		Addr:     srv.Addr(),
+		PoolSize: 1,
+		BeforeAcquire: func(c context.Context, cn *pg.Conn) bool {
+			calls++
+			if calls == 2 {
+				srv.CloseConns()
+			}
+			// The hook ignores the network error.
+			_, _ = cn.ExecContext(c, "SELECT 'acquire'")
+			return true
+		},
+	})
+	defer db.Close()
+
+	if _, err := db.Exec("SELECT 1"); err != nil {
+		t.Fatal(err)
+	}
+
+	// The connection broken in the hook is replaced.
+	if _, err := db.Exec("SELECT 2"); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 3 {
+		t.Fatalf("got %d calls, wanted 3", calls)
+	}
+	st := db.PoolStats()
+	if st.BadConnClosed != 1 || st.TotalConns != 1 {
+		t.Fatalf("got %d bad conns and %d conns, wanted 1 and 1",
+			st.BadConnClosed, st.TotalConns)
+	}
+}
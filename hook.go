@@ -3,6 +3,7 @@ package pg
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/go-pg/pg/v9/orm"
@@ -62,6 +63,28 @@ func (ev *QueryEvent) FormattedQuery() (string, error) {
 	return string(b), nil
 }
 
+// Operation returns the SQL operation of the query, e.g. orm.SelectOp.
+// For queries that are not built with orm.Query it is the first
+// keyword of the query, e.g. "SELECT" or "WITH".
+func (ev *QueryEvent) Operation() orm.QueryOp {
+	if query, ok := ev.Query.(interface{ Operation() orm.QueryOp }); ok {
+		return query.Operation()
+	}
+	query, err := ev.UnformattedQuery()
+	if err != nil {
+		return ""
+	}
+	return queryOperation(query)
+}
+
+func queryOperation(query string) orm.QueryOp {
+	query = strings.TrimSpace(query)
+	if i := strings.IndexAny(query, " \t\r\n(;"); i >= 0 {
+		query = query[:i]
+	}
+	return orm.QueryOp(strings.ToUpper(query))
+}
+
 // AddQueryHook adds a hook into query processing.
 func (db *baseDB) AddQueryHook(hook QueryHook) {
 	db.queryHooks = append(db.queryHooks, hook)
@@ -15,6 +15,12 @@ const defaultBufSize = 65536
 type BufReader struct {
 	Columns [][]byte
 
+	// ServerParams are run-time parameters reported by the server
+	// using ParameterStatus messages.
+	ServerParams map[string]string
+	// OnNotice is called with the fields of every NoticeResponse.
+	OnNotice func(fields map[byte]string)
+
 	rd io.Reader // reader provided by the client
 
 	buf      []byte
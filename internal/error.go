@@ -2,23 +2,35 @@ package internal
 
 import (
 	"fmt"
+	"strconv"
 )
 
 var ErrNoRows = Errorf("pg: no rows in result set")
 var ErrMultiRows = Errorf("pg: multiple rows in result set")
 
 type Error struct {
-	s string
+	s   string
+	err error
 }
 
 func Errorf(s string, args ...interface{}) Error {
 	return Error{s: fmt.Sprintf(s, args...)}
 }
 
+// WrapError returns an Error that does not mark the connection as bad
+// and keeps err available via Unwrap.
+func WrapError(err error) Error {
+	return Error{s: err.Error(), err: err}
+}
+
 func (err Error) Error() string {
 	return err.s
 }
 
+func (err Error) Unwrap() error {
+	return err.err
+}
+
 type PGError struct {
 	m map[byte]string
 }
@@ -33,6 +45,55 @@ func (err PGError) Field(k byte) string {
 	return err.m[k]
 }
 
+func (err PGError) Severity() string {
+	return err.Field('S')
+}
+
+func (err PGError) Code() string {
+	return err.Field('C')
+}
+
+func (err PGError) Message() string {
+	return err.Field('M')
+}
+
+func (err PGError) Detail() string {
+	return err.Field('D')
+}
+
+func (err PGError) Hint() string {
+	return err.Field('H')
+}
+
+func (err PGError) Position() int {
+	n, _ := strconv.Atoi(err.Field('P'))
+	return n
+}
+
+func (err PGError) Where() string {
+	return err.Field('W')
+}
+
+func (err PGError) Schema() string {
+	return err.Field('s')
+}
+
+func (err PGError) Table() string {
+	return err.Field('t')
+}
+
+func (err PGError) Column() string {
+	return err.Field('c')
+}
+
+func (err PGError) Constraint() string {
+	return err.Field('n')
+}
+
+func (err PGError) Routine() string {
+	return err.Field('R')
+}
+
 func (err PGError) IntegrityViolation() bool {
 	switch err.Field('C') {
 	case "23000", "23001", "23502", "23503", "23505", "23514", "23P01":
@@ -47,6 +108,17 @@ func (err PGError) Error() string {
 		err.Field('S'), err.Field('C'), err.Field('M'))
 }
 
+// AsPGError finds the first PGError in the err chain.
+func AsPGError(err error) (PGError, bool) {
+	for err != nil {
+		if pgErr, ok := err.(PGError); ok {
+			return pgErr, true
+		}
+		err = Unwrap(err)
+	}
+	return PGError{}, false
+}
+
 func AssertOneRow(l int) error {
 	switch {
 	case l == 0:
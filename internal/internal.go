@@ -5,6 +5,10 @@ import (
 	"time"
 )
 
+// PGEpoch is the PostgreSQL epoch used by binary timestamps
+// and the replication protocol.
+var PGEpoch = time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+
 // Retry backoff with jitter sleep to prevent overloaded conditions during intervals
 // https://www.awsarchitectureblog.com/2015/03/backoff.html
 func RetryBackoff(retry int, minBackoff, maxBackoff time.Duration) time.Duration {
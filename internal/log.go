@@ -1,8 +1,82 @@
 package internal
 
 import (
+	"bytes"
+	"context"
+	"fmt"
 	"log"
 	"os"
 )
 
-var Logger = log.New(os.Stderr, "pg: ", log.LstdFlags|log.Lshortfile)
+// LogLevel is the severity of a log message.
+type LogLevel int
+
+const (
+	LogDebug LogLevel = iota
+	LogInfo
+	LogWarn
+	LogError
+)
+
+func (l LogLevel) String() string {
+	switch l {
+	case LogDebug:
+		return "debug"
+	case LogInfo:
+		return "info"
+	case LogWarn:
+		return "warn"
+	case LogError:
+		return "error"
+	default:
+		return fmt.Sprintf("level(%d)", int(l))
+	}
+}
+
+// Logging is a leveled logger that accepts alternating key/value pairs
+// in keyvals, e.g. "error", err, "addr", addr.
+type Logging interface {
+	Log(ctx context.Context, level LogLevel, msg string, keyvals ...interface{})
+}
+
+// Logger is used when no logger is configured for the DB.
+var Logger Logging = NewStdLogger(log.New(os.Stderr, "pg: ", log.LstdFlags))
+
+// LoggerOr returns l or the global Logger when l is nil.
+func LoggerOr(l Logging) Logging {
+	if l != nil {
+		return l
+	}
+	return Logger
+}
+
+// Warn logs the message using the global Logger.
+func Warn(msg string, keyvals ...interface{}) {
+	Logger.Log(context.Background(), LogWarn, msg, keyvals...)
+}
+
+type stdLogger struct {
+	log *log.Logger
+}
+
+// NewStdLogger returns a Logging that formats messages as
+// "level: msg key=value ..." lines using the log.Logger.
+func NewStdLogger(l *log.Logger) Logging {
+	return stdLogger{log: l}
+}
+
+func (l stdLogger) Log(ctx context.Context, level LogLevel, msg string, keyvals ...interface{}) {
+	var buf bytes.Buffer
+	buf.WriteString(level.String())
+	buf.WriteString(": ")
+	buf.WriteString(msg)
+	for i := 0; i < len(keyvals); i += 2 {
+		buf.WriteByte(' ')
+		fmt.Fprint(&buf, keyvals[i])
+		buf.WriteByte('=')
+		if i+1 < len(keyvals) {
+			fmt.Fprintf(&buf, "%v", keyvals[i+1])
+		}
+	}
+	_ = l.log.Output(2, buf.String())
+}
@@ -22,6 +22,10 @@ type Conn struct {
 	SecretKey int32
 	lastID    int64
 
+	// Stmts caches statements prepared by queries that use
+	// server-side parameters. It is nil until first such query.
+	Stmts *StmtCache
+
 	pooled    bool
 	Inited    bool
 	createdAt time.Time
@@ -40,6 +44,24 @@ func NewConn(netConn net.Conn) *Conn {
 	return cn
 }
 
+// ConnInfo describes a connection for the pool callbacks.
+type ConnInfo struct {
+	// Backend process ID. It is 0 until the connection is initialized.
+	ProcessID  int32
+	RemoteAddr net.Addr
+	CreatedAt  time.Time
+	UsedAt     time.Time
+}
+
+func (cn *Conn) Info() ConnInfo {
+	return ConnInfo{
+		ProcessID:  cn.ProcessID,
+		RemoteAddr: cn.RemoteAddr(),
+		CreatedAt:  cn.createdAt,
+		UsedAt:     cn.UsedAt(),
+	}
+}
+
 func (cn *Conn) UsedAt() time.Time {
 	unix := atomic.LoadInt64(&cn.usedAt)
 	return time.Unix(unix, 0)
@@ -62,6 +84,18 @@ func (cn *Conn) NetConn() net.Conn {
 	return cn.netConn
 }
 
+// ServerParams returns run-time parameters reported by the server.
+// The map must not be modified.
+func (cn *Conn) ServerParams() map[string]string {
+	return cn.rd.ServerParams
+}
+
+// SetNoticeHandler sets the function that is called with the fields
+// of every notice received on the connection.
+func (cn *Conn) SetNoticeHandler(fn func(fields map[byte]string)) {
+	cn.rd.OnNotice = fn
+}
+
 func (cn *Conn) NextID() string {
 	cn.lastID++
 	return strconv.FormatInt(cn.lastID, 10)
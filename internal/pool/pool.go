@@ -28,11 +28,37 @@ type Stats struct {
 	Misses   uint32 // number of times free connection was NOT found in the pool
 	Timeouts uint32 // number of times a wait timeout occurred
 
+	WaitCount    uint32        // number of times Get waited for a free connection
+	WaitDuration time.Duration // total time Get spent waiting for a free connection
+
 	TotalConns uint32 // number of total connections in the pool
 	IdleConns  uint32 // number of idle connections in the pool
+	InUseConns uint32 // number of connections that are not idle
 	StaleConns uint32 // number of stale connections removed from the pool
+
+	DialErrors uint32 // number of failed attempts to dial a connection
+
+	IdleClosed    uint32 // number of connections closed because of IdleTimeout
+	MaxAgeClosed  uint32 // number of connections closed because of MaxConnAge
+	BadConnClosed uint32 // number of connections removed because of an error
 }
 
+// CloseReason is the reason why a connection was closed.
+type CloseReason string
+
+const (
+	// The connection was closed explicitly or with the pool.
+	CloseReasonClosed CloseReason = "closed"
+	// The connection was idle for longer than IdleTimeout.
+	CloseReasonIdle CloseReason = "idle"
+	// The connection was older than MaxConnAge.
+	CloseReasonMaxAge CloseReason = "max_age"
+	// The connection was removed because of a network or protocol error.
+	CloseReasonBadConn CloseReason = "bad_conn"
+	// The connection was released when the pool was already full.
+	CloseReasonPoolFull CloseReason = "pool_full"
+)
+
 type Pooler interface {
 	NewConn(context.Context) (*Conn, error)
 	CloseConn(*Conn) error
@@ -51,6 +77,14 @@ type Pooler interface {
 type Options struct {
 	Dialer  func(context.Context) (net.Conn, error)
 	OnClose func(*Conn) error
+	Logger  internal.Logging
+
+	// Optional callbacks that are called synchronously when a connection
+	// is dialed, returned by Get, returned to the pool by Put and closed.
+	OnConnCreated  func(ConnInfo)
+	OnConnAcquired func(ConnInfo)
+	OnConnReleased func(ConnInfo)
+	OnConnClosed   func(ConnInfo, CloseReason)
 
 	PoolSize           int
 	MinIdleConns       int
@@ -58,9 +92,17 @@ type Options struct {
 	PoolTimeout        time.Duration
 	IdleTimeout        time.Duration
 	IdleCheckFrequency time.Duration
+
+	// Idle connections that were not used for KeepAliveInterval are
+	// checked with Ping by the reaper. Connections that fail the check
+	// are removed from the pool.
+	KeepAliveInterval time.Duration
+	Ping              func(context.Context, *Conn) error
 }
 
 type ConnPool struct {
+	waitDuration int64 // atomic; first for 64-bit alignment
+
 	opt *Options
 
 	dialErrorsNum uint32 // atomic
@@ -95,7 +137,7 @@ func NewConnPool(opt *Options) *ConnPool {
 
 	p.checkMinIdleConns()
 
-	if opt.IdleTimeout > 0 && opt.IdleCheckFrequency > 0 {
+	if (opt.IdleTimeout > 0 || p.keepAliveEnabled()) && opt.IdleCheckFrequency > 0 {
 		go p.reaper(opt.IdleCheckFrequency)
 	}
 
@@ -169,6 +211,7 @@ func (p *ConnPool) dialConn(c context.Context, pooled bool) (*Conn, error) {
 
 	netConn, err := p.opt.Dialer(c)
 	if err != nil {
+		atomic.AddUint32(&p.stats.DialErrors, 1)
 		p.setLastDialError(err)
 		if atomic.AddUint32(&p.dialErrorsNum, 1) == uint32(p.opt.PoolSize) {
 			go p.tryDial()
@@ -178,6 +221,9 @@ func (p *ConnPool) dialConn(c context.Context, pooled bool) (*Conn, error) {
 
 	cn := NewConn(netConn)
 	cn.pooled = pooled
+	if p.opt.OnConnCreated != nil {
+		p.opt.OnConnCreated(cn.Info())
+	}
 	return cn, nil
 }
 
@@ -189,6 +235,7 @@ func (p *ConnPool) tryDial() {
 
 		conn, err := p.opt.Dialer(context.TODO())
 		if err != nil {
+			atomic.AddUint32(&p.stats.DialErrors, 1)
 			p.setLastDialError(err)
 			time.Sleep(time.Second)
 			continue
@@ -233,12 +280,14 @@ func (p *ConnPool) Get(c context.Context) (*Conn, error) {
 			break
 		}
 
-		if p.isStaleConn(cn) {
-			_ = p.CloseConn(cn)
+		if reason := p.staleReason(cn); reason != "" {
+			p.removeConnWithLock(cn)
+			_ = p.closeConn(cn, reason)
 			continue
 		}
 
 		atomic.AddUint32(&p.stats.Hits, 1)
+		p.acquired(cn)
 		return cn, nil
 	}
 
@@ -250,9 +299,16 @@ func (p *ConnPool) Get(c context.Context) (*Conn, error) {
 		return nil, err
 	}
 
+	p.acquired(newcn)
 	return newcn, nil
 }
 
+func (p *ConnPool) acquired(cn *Conn) {
+	if p.opt.OnConnAcquired != nil {
+		p.opt.OnConnAcquired(cn.Info())
+	}
+}
+
 func (p *ConnPool) getTurn() {
 	p.queue <- struct{}{}
 }
@@ -270,6 +326,12 @@ func (p *ConnPool) waitTurn(c context.Context) error {
 	default:
 	}
 
+	start := time.Now()
+	defer func() {
+		atomic.AddUint32(&p.stats.WaitCount, 1)
+		atomic.AddInt64(&p.waitDuration, int64(time.Since(start)))
+	}()
+
 	timer := timers.Get().(*time.Timer)
 	timer.Reset(p.opt.PoolTimeout)
 
@@ -312,7 +374,7 @@ func (p *ConnPool) popIdle() *Conn {
 
 func (p *ConnPool) Put(cn *Conn) {
 	if cn.rd.Buffered() > 0 {
-		internal.Logger.Printf("Conn has unread data")
+		p.logger().Log(context.TODO(), internal.LogWarn, "Conn has unread data")
 		p.Remove(cn, BadConnError{})
 		return
 	}
@@ -322,6 +384,10 @@ func (p *ConnPool) Put(cn *Conn) {
 		return
 	}
 
+	if p.opt.OnConnReleased != nil {
+		p.opt.OnConnReleased(cn.Info())
+	}
+
 	p.connsMu.Lock()
 	p.idleConns = append(p.idleConns, cn)
 	p.idleConnsLen++
@@ -332,12 +398,23 @@ func (p *ConnPool) Put(cn *Conn) {
 func (p *ConnPool) Remove(cn *Conn, reason error) {
 	p.removeConnWithLock(cn)
 	p.freeTurn()
-	_ = p.closeConn(cn)
+	_ = p.closeConn(cn, removeReason(reason))
+}
+
+func removeReason(err error) CloseReason {
+	switch err {
+	case nil:
+		return CloseReasonPoolFull
+	case ErrClosed:
+		return CloseReasonClosed
+	default:
+		return CloseReasonBadConn
+	}
 }
 
 func (p *ConnPool) CloseConn(cn *Conn) error {
 	p.removeConnWithLock(cn)
-	return p.closeConn(cn)
+	return p.closeConn(cn, CloseReasonClosed)
 }
 
 func (p *ConnPool) removeConnWithLock(cn *Conn) {
@@ -359,7 +436,18 @@ func (p *ConnPool) removeConn(cn *Conn) {
 	}
 }
 
-func (p *ConnPool) closeConn(cn *Conn) error {
+func (p *ConnPool) closeConn(cn *Conn, reason CloseReason) error {
+	switch reason {
+	case CloseReasonIdle:
+		atomic.AddUint32(&p.stats.IdleClosed, 1)
+	case CloseReasonMaxAge:
+		atomic.AddUint32(&p.stats.MaxAgeClosed, 1)
+	case CloseReasonBadConn:
+		atomic.AddUint32(&p.stats.BadConnClosed, 1)
+	}
+	if p.opt.OnConnClosed != nil {
+		p.opt.OnConnClosed(cn.Info(), reason)
+	}
 	if p.opt.OnClose != nil {
 		_ = p.opt.OnClose(cn)
 	}
@@ -383,15 +471,34 @@ func (p *ConnPool) IdleLen() int {
 }
 
 func (p *ConnPool) Stats() *Stats {
-	idleLen := p.IdleLen()
+	p.connsMu.Lock()
+	totalLen := len(p.conns)
+	idleLen := p.idleConnsLen
+	p.connsMu.Unlock()
+
+	inUse := totalLen - idleLen
+	if inUse < 0 { // idle conns that are still being dialed
+		inUse = 0
+	}
+
 	return &Stats{
 		Hits:     atomic.LoadUint32(&p.stats.Hits),
 		Misses:   atomic.LoadUint32(&p.stats.Misses),
 		Timeouts: atomic.LoadUint32(&p.stats.Timeouts),
 
-		TotalConns: uint32(p.Len()),
+		WaitCount:    atomic.LoadUint32(&p.stats.WaitCount),
+		WaitDuration: time.Duration(atomic.LoadInt64(&p.waitDuration)),
+
+		TotalConns: uint32(totalLen),
 		IdleConns:  uint32(idleLen),
+		InUseConns: uint32(inUse),
 		StaleConns: atomic.LoadUint32(&p.stats.StaleConns),
+
+		DialErrors: atomic.LoadUint32(&p.stats.DialErrors),
+
+		IdleClosed:    atomic.LoadUint32(&p.stats.IdleClosed),
+		MaxAgeClosed:  atomic.LoadUint32(&p.stats.MaxAgeClosed),
+		BadConnClosed: atomic.LoadUint32(&p.stats.BadConnClosed),
 	}
 }
 
@@ -400,16 +507,26 @@ func (p *ConnPool) closed() bool {
 }
 
 func (p *ConnPool) Filter(fn func(*Conn) bool) error {
-	var firstErr error
+	var conns []*Conn
 	p.connsMu.Lock()
 	for _, cn := range p.conns {
 		if fn(cn) {
-			if err := p.closeConn(cn); err != nil && firstErr == nil {
-				firstErr = err
-			}
+			conns = append(conns, cn)
 		}
 	}
 	p.connsMu.Unlock()
+
+	// Conns are closed without the lock, because OnConnClosed can use the pool.
+	return p.closeConns(conns)
+}
+
+func (p *ConnPool) closeConns(conns []*Conn) error {
+	var firstErr error
+	for _, cn := range conns {
+		if err := p.closeConn(cn, CloseReasonClosed); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
 	return firstErr
 }
 
@@ -418,20 +535,19 @@ func (p *ConnPool) Close() error {
 		return ErrClosed
 	}
 
-	var firstErr error
 	p.connsMu.Lock()
-	for _, cn := range p.conns {
-		if err := p.closeConn(cn); err != nil && firstErr == nil {
-			firstErr = err
-		}
-	}
+	conns := p.conns
 	p.conns = nil
 	p.poolSize = 0
 	p.idleConns = nil
 	p.idleConnsLen = 0
 	p.connsMu.Unlock()
 
-	return firstErr
+	return p.closeConns(conns)
+}
+
+func (p *ConnPool) logger() internal.Logging {
+	return internal.LoggerOr(p.opt.Logger)
 }
 
 func (p *ConnPool) reaper(frequency time.Duration) {
@@ -444,11 +560,73 @@ func (p *ConnPool) reaper(frequency time.Duration) {
 		}
 		n, err := p.ReapStaleConns()
 		if err != nil {
-			internal.Logger.Printf("ReapStaleConns failed: %s", err)
+			p.logger().Log(context.TODO(), internal.LogError, "ReapStaleConns failed", "error", err)
 			continue
 		}
 		atomic.AddUint32(&p.stats.StaleConns, uint32(n))
+
+		if p.keepAliveEnabled() {
+			p.PingIdleConns()
+		}
+	}
+}
+
+func (p *ConnPool) keepAliveEnabled() bool {
+	return p.opt.KeepAliveInterval > 0 && p.opt.Ping != nil
+}
+
+// PingIdleConns checks idle connections that were not used for
+// KeepAliveInterval and returns the number of removed connections.
+func (p *ConnPool) PingIdleConns() int {
+	p.connsMu.Lock()
+	n := len(p.idleConns)
+	p.connsMu.Unlock()
+
+	var removed int
+	for i := 0; i < n; i++ {
+		p.getTurn()
+
+		p.connsMu.Lock()
+		cn := p.popKeepAliveConn()
+		p.connsMu.Unlock()
+
+		if cn == nil {
+			p.freeTurn()
+			break
+		}
+
+		if err := p.opt.Ping(context.TODO(), cn); err != nil {
+			p.removeConnWithLock(cn)
+			p.freeTurn()
+			_ = p.closeConn(cn, CloseReasonBadConn)
+			removed++
+			continue
+		}
+
+		p.connsMu.Lock()
+		p.idleConns = append(p.idleConns, cn)
+		p.idleConnsLen++
+		p.connsMu.Unlock()
+		p.freeTurn()
+	}
+	return removed
+}
+
+// popKeepAliveConn removes and returns the least recently used idle
+// connection if it was not used for KeepAliveInterval.
+func (p *ConnPool) popKeepAliveConn() *Conn {
+	if len(p.idleConns) == 0 {
+		return nil
 	}
+
+	cn := p.idleConns[0]
+	if time.Since(cn.UsedAt()) < p.opt.KeepAliveInterval {
+		return nil
+	}
+
+	p.idleConns = append(p.idleConns[:0], p.idleConns[1:]...)
+	p.idleConnsLen--
+	return cn
 }
 
 func (p *ConnPool) ReapStaleConns() (int, error) {
@@ -457,13 +635,13 @@ func (p *ConnPool) ReapStaleConns() (int, error) {
 		p.getTurn()
 
 		p.connsMu.Lock()
-		cn := p.reapStaleConn()
+		cn, reason := p.reapStaleConn()
 		p.connsMu.Unlock()
 
 		p.freeTurn()
 
 		if cn != nil {
-			_ = p.closeConn(cn)
+			_ = p.closeConn(cn, reason)
 			n++
 		} else {
 			break
@@ -472,35 +650,38 @@ func (p *ConnPool) ReapStaleConns() (int, error) {
 	return n, nil
 }
 
-func (p *ConnPool) reapStaleConn() *Conn {
+func (p *ConnPool) reapStaleConn() (*Conn, CloseReason) {
 	if len(p.idleConns) == 0 {
-		return nil
+		return nil, ""
 	}
 
 	cn := p.idleConns[0]
-	if !p.isStaleConn(cn) {
-		return nil
+	reason := p.staleReason(cn)
+	if reason == "" {
+		return nil, ""
 	}
 
 	p.idleConns = append(p.idleConns[:0], p.idleConns[1:]...)
 	p.idleConnsLen--
 	p.removeConn(cn)
 
-	return cn
+	return cn, reason
 }
 
-func (p *ConnPool) isStaleConn(cn *Conn) bool {
+// staleReason returns CloseReasonIdle or CloseReasonMaxAge if the
+// connection is stale and an empty reason otherwise.
+func (p *ConnPool) staleReason(cn *Conn) CloseReason {
 	if p.opt.IdleTimeout == 0 && p.opt.MaxConnAge == 0 {
-		return false
+		return ""
 	}
 
 	now := time.Now()
 	if p.opt.IdleTimeout > 0 && now.Sub(cn.UsedAt()) >= p.opt.IdleTimeout {
-		return true
+		return CloseReasonIdle
 	}
 	if p.opt.MaxConnAge > 0 && now.Sub(cn.createdAt) >= p.opt.MaxConnAge {
-		return true
+		return CloseReasonMaxAge
 	}
 
-	return false
+	return ""
 }
@@ -2,6 +2,8 @@ package pool_test
 
 import (
 	"context"
+	"errors"
+	"net"
 	"sync"
 	"testing"
 	"time"
@@ -373,6 +375,214 @@ var _ = Describe("conns reaper", func() {
 	assert("aged")
 })
 
+var _ = Describe("close reasons", func() {
+	const idleTimeout = time.Minute
+	const maxAge = time.Hour
+
+	c := context.Background()
+	var connPool *pool.ConnPool
+	var reasons []pool.CloseReason
+
+	BeforeEach(func() {
+		reasons = nil
+		connPool = pool.NewConnPool(&pool.Options{
+			Dialer:             dummyDialer,
+			PoolSize:           10,
+			IdleTimeout:        idleTimeout,
+			MaxConnAge:         maxAge,
+			PoolTimeout:        time.Second,
+			IdleCheckFrequency: time.Hour,
+			OnConnClosed: func(_ pool.ConnInfo, reason pool.CloseReason) {
+				reasons = append(reasons, reason)
+			},
+		})
+	})
+
+	AfterEach(func() {
+		_ = connPool.Close()
+	})
+
+	It("counts reaped connections by close reason", func() {
+		var cns []*pool.Conn
+		for i := 0; i < 3; i++ {
+			cn, err := connPool.Get(c)
+			Expect(err).NotTo(HaveOccurred())
+			cns = append(cns, cn)
+		}
+		cns[0].SetUsedAt(time.Now().Add(-2 * idleTimeout))
+		cns[1].SetCreatedAt(time.Now().Add(-2 * maxAge))
+		for _, cn := range cns {
+			connPool.Put(cn)
+		}
+
+		n, err := connPool.ReapStaleConns()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(n).To(Equal(2))
+
+		st := connPool.Stats()
+		Expect(st.IdleClosed).To(Equal(uint32(1)))
+		Expect(st.MaxAgeClosed).To(Equal(uint32(1)))
+		Expect(st.BadConnClosed).To(Equal(uint32(0)))
+		Expect(reasons).To(Equal([]pool.CloseReason{pool.CloseReasonIdle, pool.CloseReasonMaxAge}))
+	})
+
+	It("calls OnConnClosed without holding the pool lock", func() {
+		_ = connPool.Close()
+		connPool = pool.NewConnPool(&pool.Options{
+			Dialer:      dummyDialer,
+			PoolSize:    10,
+			PoolTimeout: time.Second,
+			OnConnClosed: func(pool.ConnInfo, pool.CloseReason) {
+				_ = connPool.Stats()
+			},
+		})
+
+		cn, err := connPool.Get(c)
+		Expect(err).NotTo(HaveOccurred())
+		connPool.Put(cn)
+
+		// Both return without a deadlock.
+		_ = connPool.Filter(func(*pool.Conn) bool { return true })
+		_ = connPool.Close()
+	})
+})
+
+var _ = Describe("stats", func() {
+	c := context.Background()
+	var connPool *pool.ConnPool
+	var events []string
+
+	BeforeEach(func() {
+		events = nil
+		connPool = pool.NewConnPool(&pool.Options{
+			Dialer:      dummyDialer,
+			PoolSize:    1,
+			PoolTimeout: time.Second,
+			IdleTimeout: time.Hour,
+
+			OnConnCreated: func(pool.ConnInfo) {
+				events = append(events, "created")
+			},
+			OnConnAcquired: func(pool.ConnInfo) {
+				events = append(events, "acquired")
+			},
+			OnConnReleased: func(pool.ConnInfo) {
+				events = append(events, "released")
+			},
+			OnConnClosed: func(_ pool.ConnInfo, reason pool.CloseReason) {
+				events = append(events, "closed "+string(reason))
+			},
+		})
+	})
+
+	AfterEach(func() {
+		_ = connPool.Close()
+	})
+
+	It("tracks waits, in-use conns and bad conns", func() {
+		cn, err := connPool.Get(c)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(connPool.Stats().InUseConns).To(Equal(uint32(1)))
+
+		go func() {
+			time.Sleep(10 * time.Millisecond)
+			connPool.Put(cn)
+		}()
+
+		cn, err = connPool.Get(c)
+		Expect(err).NotTo(HaveOccurred())
+
+		st := connPool.Stats()
+		Expect(st.WaitCount).To(Equal(uint32(1)))
+		Expect(st.WaitDuration).To(BeNumerically(">=", 10*time.Millisecond))
+		Expect(st.InUseConns).To(Equal(uint32(1)))
+		Expect(st.IdleConns).To(Equal(uint32(0)))
+
+		connPool.Remove(cn, pool.BadConnError{})
+
+		st = connPool.Stats()
+		Expect(st.InUseConns).To(Equal(uint32(0)))
+		Expect(st.TotalConns).To(Equal(uint32(0)))
+		Expect(st.BadConnClosed).To(Equal(uint32(1)))
+
+		Expect(events).To(Equal([]string{
+			"created", "acquired", "released", "acquired", "closed bad_conn",
+		}))
+	})
+
+	It("counts dial errors", func() {
+		errDial := errors.New("dial failed")
+		connPool = pool.NewConnPool(&pool.Options{
+			Dialer: func(context.Context) (net.Conn, error) {
+				return nil, errDial
+			},
+			PoolSize:    10,
+			PoolTimeout: time.Second,
+		})
+
+		for i := 0; i < 3; i++ {
+			_, err := connPool.Get(c)
+			Expect(err).To(Equal(errDial))
+		}
+		Expect(connPool.Stats().DialErrors).To(Equal(uint32(3)))
+	})
+})
+
+var _ = Describe("keepalive", func() {
+	c := context.Background()
+	var connPool *pool.ConnPool
+	var pinged []*pool.Conn
+	var badConn *pool.Conn
+
+	BeforeEach(func() {
+		pinged = nil
+		connPool = pool.NewConnPool(&pool.Options{
+			Dialer:             dummyDialer,
+			PoolSize:           10,
+			PoolTimeout:        time.Second,
+			IdleCheckFrequency: time.Hour,
+			KeepAliveInterval:  time.Minute,
+			Ping: func(_ context.Context, cn *pool.Conn) error {
+				pinged = append(pinged, cn)
+				if cn == badConn {
+					return errors.New("ping failed")
+				}
+				return nil
+			},
+		})
+
+		var cns []*pool.Conn
+		for i := 0; i < 4; i++ {
+			cn, err := connPool.Get(c)
+			Expect(err).NotTo(HaveOccurred())
+			cns = append(cns, cn)
+		}
+		// The first 3 connections were not used for a while.
+		for _, cn := range cns[:3] {
+			cn.SetUsedAt(time.Now().Add(-2 * time.Minute))
+		}
+		badConn = cns[1]
+		for _, cn := range cns {
+			connPool.Put(cn)
+		}
+	})
+
+	AfterEach(func() {
+		_ = connPool.Close()
+	})
+
+	It("pings idle connections and removes bad ones", func() {
+		n := connPool.PingIdleConns()
+		Expect(n).To(Equal(1))
+		Expect(pinged).To(HaveLen(3))
+		Expect(pinged).To(ContainElement(badConn))
+
+		Expect(connPool.Len()).To(Equal(3))
+		Expect(connPool.IdleLen()).To(Equal(3))
+		Expect(connPool.Stats().BadConnClosed).To(Equal(uint32(1)))
+	})
+})
+
 var _ = Describe("race", func() {
 	c := context.Background()
 	var connPool *pool.ConnPool
@@ -0,0 +1,78 @@
+package pool
+
+import "container/list"
+
+// CachedStmt is a statement prepared on the connection.
+type CachedStmt struct {
+	Query string
+	Name  string
+	// Desc describes statement parameters and result columns.
+	// It is nil until the statement is prepared.
+	Desc interface{}
+}
+
+// StmtCache is an LRU cache of statements prepared on a connection.
+// It is not safe for concurrent use.
+type StmtCache struct {
+	size int
+	ll   *list.List
+	m    map[string]*list.Element
+
+	closed []string
+}
+
+func NewStmtCache(size int) *StmtCache {
+	return &StmtCache{
+		size: size,
+		ll:   list.New(),
+		m:    make(map[string]*list.Element),
+	}
+}
+
+// Get returns the statement prepared for the query or nil.
+func (c *StmtCache) Get(query string) *CachedStmt {
+	el, ok := c.m[query]
+	if !ok {
+		return nil
+	}
+	c.ll.MoveToFront(el)
+	return el.Value.(*CachedStmt)
+}
+
+// Add adds the statement to the cache evicting the least recently
+// used statement when the cache is full.
+func (c *StmtCache) Add(stmt *CachedStmt) {
+	if el, ok := c.m[stmt.Query]; ok {
+		c.remove(el)
+	}
+	c.m[stmt.Query] = c.ll.PushFront(stmt)
+	for c.ll.Len() > c.size {
+		c.remove(c.ll.Back())
+	}
+}
+
+// Remove removes the statement prepared for the query.
+func (c *StmtCache) Remove(query string) {
+	if el, ok := c.m[query]; ok {
+		c.remove(el)
+	}
+}
+
+func (c *StmtCache) remove(el *list.Element) {
+	stmt := c.ll.Remove(el).(*CachedStmt)
+	delete(c.m, stmt.Query)
+	c.closed = append(c.closed, stmt.Name)
+}
+
+// Len returns the number of cached statements.
+func (c *StmtCache) Len() int {
+	return c.ll.Len()
+}
+
+// Closed returns names of the removed statements that must be closed
+// on the server and forgets them.
+func (c *StmtCache) Closed() []string {
+	names := c.closed
+	c.closed = nil
+	return names
+}
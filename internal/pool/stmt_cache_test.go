@@ -0,0 +1,39 @@
+package pool_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/go-pg/pg/v9/internal/pool"
+)
+
+var _ = Describe("StmtCache", func() {
+	var cache *pool.StmtCache
+
+	BeforeEach(func() {
+		cache = pool.NewStmtCache(2)
+		cache.Add(&pool.CachedStmt{Query: "SELECT 1", Name: "1"})
+		cache.Add(&pool.CachedStmt{Query: "SELECT 2", Name: "2"})
+	})
+
+	It("evicts least recently used statement", func() {
+		Expect(cache.Get("SELECT 1").Name).To(Equal("1"))
+
+		cache.Add(&pool.CachedStmt{Query: "SELECT 3", Name: "3"})
+		Expect(cache.Len()).To(Equal(2))
+		Expect(cache.Get("SELECT 2")).To(BeNil())
+		Expect(cache.Get("SELECT 1")).NotTo(BeNil())
+		Expect(cache.Get("SELECT 3")).NotTo(BeNil())
+
+		Expect(cache.Closed()).To(Equal([]string{"2"}))
+		Expect(cache.Closed()).To(BeNil())
+	})
+
+	It("removes statement", func() {
+		cache.Remove("SELECT 1")
+		cache.Remove("SELECT 3")
+		Expect(cache.Len()).To(Equal(1))
+		Expect(cache.Get("SELECT 1")).To(BeNil())
+		Expect(cache.Closed()).To(Equal([]string{"1"}))
+	})
+})
@@ -72,6 +72,11 @@ func (buf *WriteBuffer) WriteInt32(num int32) {
 	binary.BigEndian.PutUint32(buf.Bytes[len(buf.Bytes)-4:], uint32(num))
 }
 
+func (buf *WriteBuffer) WriteInt64(num int64) {
+	buf.Bytes = append(buf.Bytes, 0, 0, 0, 0, 0, 0, 0, 0)
+	binary.BigEndian.PutUint64(buf.Bytes[len(buf.Bytes)-8:], uint64(num))
+}
+
 func (buf *WriteBuffer) WriteString(s string) {
 	buf.Bytes = append(buf.Bytes, s...)
 	buf.Bytes = append(buf.Bytes, 0)
@@ -64,7 +64,7 @@ func (ln *Listener) connWithLock() (*pool.Conn, error) {
 		_ = ln.Close()
 		return nil, errListenerClosed
 	default:
-		internal.Logger.Printf("pg: Listen failed: %s", err)
+		ln.db.opt.logger().Log(context.TODO(), LogError, "Listen failed", "error", err)
 		return nil, err
 	}
 }
@@ -123,7 +123,8 @@ func (ln *Listener) closeTheCn(reason error) error {
 		return nil
 	}
 	if !ln.closed {
-		internal.Logger.Printf("pg: discarding bad listener connection: %s", reason)
+		ln.db.opt.logger().Log(context.TODO(), LogWarn,
+			"discarding bad listener connection", "reason", reason)
 	}
 
 	err := ln.db.pool.CloseConn(ln.cn)
@@ -275,9 +276,9 @@ func (ln *Listener) initChannel(size int) {
 						<-timer.C
 					}
 				case <-timer.C:
-					internal.Logger.Printf(
-						"pg: %s channel is full for %s (notification is dropped)",
-						ln, timeout)
+					ln.db.opt.logger().Log(context.TODO(), LogWarn,
+						"channel is full (notification is dropped)",
+						"listener", ln, "channel", channel, "timeout", timeout)
 				}
 			}
 		}
@@ -0,0 +1,204 @@
+package pg
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/go-pg/pg/v9/internal"
+)
+
+// LogLevel is the severity of a log message.
+type LogLevel = internal.LogLevel
+
+const (
+	LogDebug = internal.LogDebug
+	LogInfo  = internal.LogInfo
+	LogWarn  = internal.LogWarn
+	LogError = internal.LogError
+)
+
+// Logger is a leveled logger with context and key/value fields.
+// Keyvals contain alternating keys and values, e.g. "error", err.
+type Logger interface {
+	Log(ctx context.Context, level LogLevel, msg string, keyvals ...interface{})
+}
+
+// SetLogger sets the logger to the given one
+func SetLogger(logger *log.Logger) {
+	internal.Logger = internal.NewStdLogger(logger)
+}
+
+// SetDefaultLogger sets the logger used by databases that don't
+// have Options.Logger.
+func SetDefaultLogger(logger Logger) {
+	internal.Logger = logger
+}
+
+func (opt *Options) logger() internal.Logging {
+	if opt.Logger != nil {
+		return opt.Logger
+	}
+	return internal.Logger
+}
+
+//------------------------------------------------------------------------------
+
+// SlogLogger is the subset of *slog.Logger methods used by LoggerFromSlog.
+type SlogLogger interface {
+	DebugContext(ctx context.Context, msg string, args ...interface{})
+	InfoContext(ctx context.Context, msg string, args ...interface{})
+	WarnContext(ctx context.Context, msg string, args ...interface{})
+	ErrorContext(ctx context.Context, msg string, args ...interface{})
+}
+
+// LoggerFromSlog returns a Logger that sends messages to a log/slog
+// style logger, e.g. *slog.Logger.
+func LoggerFromSlog(l SlogLogger) Logger {
+	return slogLogger{l: l}
+}
+
+type slogLogger struct {
+	l SlogLogger
+}
+
+func (l slogLogger) Log(ctx context.Context, level LogLevel, msg string, keyvals ...interface{}) {
+	switch level {
+	case LogDebug:
+		l.l.DebugContext(ctx, msg, keyvals...)
+	case LogInfo:
+		l.l.InfoContext(ctx, msg, keyvals...)
+	case LogWarn:
+		l.l.WarnContext(ctx, msg, keyvals...)
+	default:
+		l.l.ErrorContext(ctx, msg, keyvals...)
+	}
+}
+
+// ZapLogger is the subset of *zap.SugaredLogger methods used by LoggerFromZap.
+type ZapLogger interface {
+	Debugw(msg string, keysAndValues ...interface{})
+	Infow(msg string, keysAndValues ...interface{})
+	Warnw(msg string, keysAndValues ...interface{})
+	Errorw(msg string, keysAndValues ...interface{})
+}
+
+// LoggerFromZap returns a Logger that sends messages to a zap style
+// logger, e.g. *zap.SugaredLogger.
+func LoggerFromZap(l ZapLogger) Logger {
+	return zapLogger{l: l}
+}
+
+type zapLogger struct {
+	l ZapLogger
+}
+
+func (l zapLogger) Log(ctx context.Context, level LogLevel, msg string, keyvals ...interface{}) {
+	switch level {
+	case LogDebug:
+		l.l.Debugw(msg, keyvals...)
+	case LogInfo:
+		l.l.Infow(msg, keyvals...)
+	case LogWarn:
+		l.l.Warnw(msg, keyvals...)
+	default:
+		l.l.Errorw(msg, keyvals...)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// NewJSONLogger returns a Logger that writes every message as a JSON
+// object on a separate line, e.g.
+//
+//	{"time":"2019-10-16T10:00:00Z","level":"warn","msg":"replica is down","addr":"db2:5432"}
+//
+// Errors, durations and other fmt.Stringer values are written as strings.
+func NewJSONLogger(w io.Writer) Logger {
+	return &jsonLogger{w: w}
+}
+
+type jsonLogger struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (l *jsonLogger) Log(ctx context.Context, level LogLevel, msg string, keyvals ...interface{}) {
+	b := make([]byte, 0, 128)
+	b = append(b, `{"time":`...)
+	b = appendJSON(b, time.Now().UTC().Format(time.RFC3339Nano))
+	b = append(b, `,"level":`...)
+	b = appendJSON(b, level.String())
+	b = append(b, `,"msg":`...)
+	b = appendJSON(b, msg)
+	for i := 0; i < len(keyvals); i += 2 {
+		b = append(b, ',')
+		b = appendJSON(b, fmt.Sprint(keyvals[i]))
+		b = append(b, ':')
+		if i+1 < len(keyvals) {
+			b = appendJSON(b, jsonValue(keyvals[i+1]))
+		} else {
+			b = append(b, "null"...)
+		}
+	}
+	b = append(b, "}\n"...)
+
+	l.mu.Lock()
+	_, _ = l.w.Write(b)
+	l.mu.Unlock()
+}
+
+func jsonValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case error:
+		return v.Error()
+	case fmt.Stringer:
+		return v.String()
+	default:
+		return v
+	}
+}
+
+func appendJSON(b []byte, v interface{}) []byte {
+	bb, err := json.Marshal(v)
+	if err != nil {
+		bb, _ = json.Marshal(fmt.Sprint(v))
+	}
+	return append(b, bb...)
+}
+
+//------------------------------------------------------------------------------
+
+// slowQueryLogger is a query hook that logs queries that take longer
+// than Options.SlowQueryThreshold.
+type slowQueryLogger struct {
+	opt *Options
+}
+
+var _ QueryHook = slowQueryLogger{}
+
+func (h slowQueryLogger) BeforeQuery(c context.Context, evt *QueryEvent) (context.Context, error) {
+	return c, nil
+}
+
+func (h slowQueryLogger) AfterQuery(c context.Context, evt *QueryEvent) error {
+	d := time.Since(evt.StartTime)
+	if d < h.opt.SlowQueryThreshold {
+		return nil
+	}
+
+	query, err := evt.FormattedQuery()
+	if err != nil {
+		query, _ = evt.UnformattedQuery()
+	}
+	keyvals := []interface{}{"query", query, "duration", d}
+	if evt.Err != nil {
+		keyvals = append(keyvals, "error", evt.Err)
+	}
+	h.opt.logger().Log(c, LogWarn, "slow query", keyvals...)
+	return nil
+}
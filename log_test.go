@@ -0,0 +1,120 @@
+package pg_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-pg/pg/v9"
+)
+
+type logEntry struct {
+	level   pg.LogLevel
+	msg     string
+	keyvals []interface{}
+}
+
+type recordLogger struct {
+	mu      sync.Mutex
+	entries []logEntry
+}
+
+func (l *recordLogger) Log(ctx context.Context, level pg.LogLevel, msg string, keyvals ...interface{}) {
+	l.mu.Lock()
+	l.entries = append(l.entries, logEntry{level: level, msg: msg, keyvals: keyvals})
+	l.mu.Unlock()
+}
+
+func (l *recordLogger) Entries() []logEntry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]logEntry(nil), l.entries...)
+}
+
+func TestLogNoticesAndSlowQueries(t *testing.T) {
+	srv := newQueryServer(t)
+	defer srv.Close()
+
+	logger := new(recordLogger)
+	db := pg.Connect(&pg.Options{
+		Addr:               srv.Addr(),
+		Logger:             logger,
+		LogNotices:         true,
+		SlowQueryThreshold: time.Nanosecond,
+	})
+	defer db.Close()
+
+	if _, err := db.Exec("DO $$ BEGIN RAISE NOTICE 'hello'; END $$"); err != nil {
+		t.Fatal(err)
+	}
+
+	entries := logger.Entries()
+	if len(entries) != 2 {
+		t.Fatalf("got %d log entries, wanted 2", len(entries))
+	}
+
+	notice := entries[0]
+	if notice.level != pg.LogInfo || notice.msg != "hello" {
+		t.Fatalf("got %v %q, wanted info hello", notice.level, notice.msg)
+	}
+	got := fmt.Sprint(notice.keyvals)
+	if wanted := "[severity NOTICE code 00000]"; got != wanted {
+		t.Fatalf("got %s, wanted %s", got, wanted)
+	}
+
+	slow := entries[1]
+	if slow.level != pg.LogWarn || slow.msg != "slow query" {
+		t.Fatalf("got %v %q, wanted warn slow query", slow.level, slow.msg)
+	}
+	if len(slow.keyvals) != 4 || slow.keyvals[0] != "query" ||
+		slow.keyvals[1] != "DO $$ BEGIN RAISE NOTICE 'hello'; END $$" ||
+		slow.keyvals[2] != "duration" {
+		t.Fatalf("got %v", slow.keyvals)
+	}
+}
+
+func TestJSONLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := pg.NewJSONLogger(&buf)
+
+	logger.Log(context.Background(), pg.LogError, "cancelRequest failed",
+		"error", errors.New("boom"), "duration", time.Second, "n", 1)
+	logger.Log(context.Background(), pg.LogDebug, "odd", "key")
+
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, wanted 2", len(lines))
+	}
+
+	var m map[string]interface{}
+	if err := json.Unmarshal(lines[0], &m); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := time.Parse(time.RFC3339Nano, m["time"].(string)); err != nil {
+		t.Fatal(err)
+	}
+	delete(m, "time")
+	wanted := map[string]interface{}{
+		"level":    "error",
+		"msg":      "cancelRequest failed",
+		"error":    "boom",
+		"duration": "1s",
+		"n":        1.0,
+	}
+	if fmt.Sprint(m) != fmt.Sprint(wanted) {
+		t.Fatalf("got %v, wanted %v", m, wanted)
+	}
+
+	m = nil
+	if err := json.Unmarshal(lines[1], &m); err != nil {
+		t.Fatal(err)
+	}
+	if v, ok := m["key"]; !ok || v != nil {
+		t.Fatalf("got %v, wanted key=null", m)
+	}
+}
@@ -57,7 +57,8 @@ const (
 	bindMsg         = 'B'
 	bindCompleteMsg = '2'
 
-	executeMsg = 'E'
+	executeMsg         = 'E'
+	portalSuspendedMsg = 's'
 
 	syncMsg  = 'S'
 	flushMsg = 'H'
@@ -65,19 +66,25 @@ const (
 	closeMsg         = 'C'
 	closeCompleteMsg = '3'
 
-	copyInResponseMsg  = 'G'
-	copyOutResponseMsg = 'H'
-	copyDataMsg        = 'd'
-	copyDoneMsg        = 'c'
+	copyInResponseMsg   = 'G'
+	copyOutResponseMsg  = 'H'
+	copyBothResponseMsg = 'W'
+	copyDataMsg         = 'd'
+	copyDoneMsg         = 'c'
 )
 
 var errEmptyQuery = internal.Errorf("pg: query is empty")
 
+var errSSLNotSupported = errors.New("pg: SSL is not enabled on the server")
+
 func (db *baseDB) startup(
-	c context.Context, cn *pool.Conn, user, password, database, appName string,
+	c context.Context,
+	cn *pool.Conn,
+	user, database, appName string,
+	params map[string]string,
 ) error {
 	err := cn.WithWriter(c, db.opt.WriteTimeout, func(wb *pool.WriteBuffer) error {
-		writeStartupMsg(wb, user, database, appName)
+		writeStartupMsg(wb, user, database, appName, params)
 		return nil
 	})
 	if err != nil {
@@ -104,11 +111,15 @@ func (db *baseDB) startup(
 				cn.ProcessID = processID
 				cn.SecretKey = secretKey
 			case parameterStatusMsg:
-				if err := logParameterStatus(rd, msgLen); err != nil {
+				if err := handleParameterStatus(rd, msgLen); err != nil {
+					return err
+				}
+			case noticeResponseMsg:
+				if err := handleNotice(rd, msgLen); err != nil {
 					return err
 				}
 			case authenticationOKMsg:
-				err := db.auth(c, cn, rd, user, password)
+				err := db.auth(c, cn, rd, user)
 				if err != nil {
 					return err
 				}
@@ -143,7 +154,7 @@ func (db *baseDB) enableSSL(c context.Context, cn *pool.Conn, tlsConf *tls.Confi
 			return err
 		}
 		if c != 'S' {
-			return errors.New("pg: SSL is not enabled on the server")
+			return errSSLNotSupported
 		}
 		return nil
 	})
@@ -151,12 +162,29 @@ func (db *baseDB) enableSSL(c context.Context, cn *pool.Conn, tlsConf *tls.Confi
 		return err
 	}
 
-	cn.SetNetConn(tls.Client(cn.NetConn(), tlsConf))
+	hc, ok := cn.NetConn().(*hostConn)
+	if !ok {
+		cn.SetNetConn(tls.Client(cn.NetConn(), tlsConf))
+		return nil
+	}
+
+	// Send SNI and verify the certificate against the host that was
+	// dialed when the config does not name the server.
+	if tlsConf.ServerName == "" {
+		if host := hc.hostname(); host != "" {
+			tlsConf = tlsConf.Clone()
+			tlsConf.ServerName = host
+		}
+	}
+
+	// hostConn stays on top, so the dialed host is known after SSL is enabled.
+	hc.Conn = tls.Client(hc.Conn, tlsConf)
+	cn.SetNetConn(hc)
 	return nil
 }
 
 func (db *baseDB) auth(
-	c context.Context, cn *pool.Conn, rd *internal.BufReader, user, password string,
+	c context.Context, cn *pool.Conn, rd *internal.BufReader, user string,
 ) error {
 	num, err := readInt32(rd)
 	if err != nil {
@@ -166,14 +194,29 @@ func (db *baseDB) auth(
 	switch num {
 	case authenticationOK:
 		return nil
+	case authenticationCleartextPassword, authenticationMD5Password, authenticationSASL:
+	default:
+		return fmt.Errorf("pg: unknown authentication message response: %q", num)
+	}
+
+	// The password is only obtained when the server asks for it,
+	// so PasswordFunc is not called for trusted connections.
+	var addr string
+	if hc, ok := cn.NetConn().(*hostConn); ok {
+		addr = hc.addrs[hc.idx]
+	}
+	password, err := db.opt.password(c, addr)
+	if err != nil {
+		return err
+	}
+
+	switch num {
 	case authenticationCleartextPassword:
 		return db.authCleartext(c, cn, rd, password)
 	case authenticationMD5Password:
 		return db.authMD5(c, cn, rd, user, password)
-	case authenticationSASL:
-		return db.authSASL(c, cn, rd, user, password)
 	default:
-		return fmt.Errorf("pg: unknown authentication message response: %q", num)
+		return db.authSASL(c, cn, rd, user, password)
 	}
 }
 
@@ -382,7 +425,9 @@ func md5s(s string) string {
 	return hex.EncodeToString(h[:])
 }
 
-func writeStartupMsg(buf *pool.WriteBuffer, user, database, appName string) {
+func writeStartupMsg(
+	buf *pool.WriteBuffer, user, database, appName string, params map[string]string,
+) {
 	buf.StartMessage(0)
 	buf.WriteInt32(196608)
 	buf.WriteString("user")
@@ -393,6 +438,18 @@ func writeStartupMsg(buf *pool.WriteBuffer, user, database, appName string) {
 		buf.WriteString("application_name")
 		buf.WriteString(appName)
 	}
+	for name, value := range params {
+		switch name {
+		case "user", "database":
+			continue
+		case "application_name":
+			if appName != "" {
+				continue
+			}
+		}
+		buf.WriteString(name)
+		buf.WriteString(value)
+	}
 	buf.WriteString("")
 	buf.FinishMessage()
 }
@@ -438,8 +495,13 @@ func writeQueryMsg(buf *pool.WriteBuffer, fmter orm.QueryFormatter, query interf
 	return nil
 }
 
+// formattedQuery is a query that is already formatted and is sent as is.
+type formattedQuery []byte
+
 func appendQuery(fmter orm.QueryFormatter, dst []byte, query interface{}, params ...interface{}) ([]byte, error) {
 	switch query := query.(type) {
+	case formattedQuery:
+		return append(dst, query...), nil
 	case orm.QueryAppender:
 		if v, ok := fmter.(*orm.Formatter); ok {
 			fmter = v.WithModel(query)
@@ -466,7 +528,44 @@ func writeSyncMsg(buf *pool.WriteBuffer) {
 	buf.FinishMessage()
 }
 
+// stmtDesc describes parameters and result columns of a prepared statement.
+type stmtDesc struct {
+	columns     [][]byte
+	columnTypes []uint32
+	paramTypes  []uint32
+
+	// binaryTypes has type OIDs of the columns that support binary format
+	// and 0 for other columns. It is nil when no column supports binary format.
+	binaryTypes []uint32
+}
+
+func (d *stmtDesc) init() {
+	for i, oid := range d.columnTypes {
+		if !types.HasBinaryFormat(oid) {
+			continue
+		}
+		if d.binaryTypes == nil {
+			d.binaryTypes = make([]uint32, len(d.columnTypes))
+		}
+		d.binaryTypes[i] = oid
+	}
+}
+
+// binaryColumnTypes returns column types to be received in binary format
+// or nil if all columns are received in text format.
+func (d *stmtDesc) binaryColumnTypes(binaryFormat bool) []uint32 {
+	if !binaryFormat {
+		return nil
+	}
+	return d.binaryTypes
+}
+
 func writeParseDescribeSyncMsg(buf *pool.WriteBuffer, name, q string) {
+	writeParseDescribeMsg(buf, name, q)
+	writeSyncMsg(buf)
+}
+
+func writeParseDescribeMsg(buf *pool.WriteBuffer, name, q string) {
 	buf.StartMessage(parseMsg)
 	buf.WriteString(name)
 	buf.WriteString(q)
@@ -477,12 +576,10 @@ func writeParseDescribeSyncMsg(buf *pool.WriteBuffer, name, q string) {
 	buf.WriteByte('S') //nolint
 	buf.WriteString(name)
 	buf.FinishMessage()
-
-	writeSyncMsg(buf)
 }
 
-func readParseDescribeSync(rd *internal.BufReader) ([][]byte, error) {
-	var columns [][]byte
+func readParseDescribeSync(rd *internal.BufReader) (*stmtDesc, error) {
+	desc := new(stmtDesc)
 	var firstErr error
 	for {
 		c, msgLen, err := readMessageType(rd)
@@ -490,18 +587,18 @@ func readParseDescribeSync(rd *internal.BufReader) ([][]byte, error) {
 			return nil, err
 		}
 		switch c {
-		case parseCompleteMsg:
+		case parseCompleteMsg, closeCompleteMsg:
 			_, err = rd.ReadN(msgLen)
 			if err != nil {
 				return nil, err
 			}
 		case rowDescriptionMsg: // Response to the DESCRIBE message.
-			columns, err = readRowDescription(rd, nil)
+			desc.columns, desc.columnTypes, err = readRowDescriptionTypes(rd)
 			if err != nil {
 				return nil, err
 			}
 		case parameterDescriptionMsg: // Response to the DESCRIBE message.
-			_, err := rd.ReadN(msgLen)
+			desc.paramTypes, err = readParameterDescription(rd)
 			if err != nil {
 				return nil, err
 			}
@@ -518,7 +615,8 @@ func readParseDescribeSync(rd *internal.BufReader) ([][]byte, error) {
 			if firstErr != nil {
 				return nil, firstErr
 			}
-			return columns, err
+			desc.init()
+			return desc, err
 		case errorResponseMsg:
 			e, err := readError(rd)
 			if err != nil {
@@ -528,11 +626,11 @@ func readParseDescribeSync(rd *internal.BufReader) ([][]byte, error) {
 				firstErr = e
 			}
 		case noticeResponseMsg:
-			if err := logNotice(rd, msgLen); err != nil {
+			if err := handleNotice(rd, msgLen); err != nil {
 				return nil, err
 			}
 		case parameterStatusMsg:
-			if err := logParameterStatus(rd, msgLen); err != nil {
+			if err := handleParameterStatus(rd, msgLen); err != nil {
 				return nil, err
 			}
 		default:
@@ -541,15 +639,124 @@ func readParseDescribeSync(rd *internal.BufReader) ([][]byte, error) {
 	}
 }
 
-// Writes BIND, EXECUTE and SYNC messages.
-func writeBindExecuteMsg(buf *pool.WriteBuffer, name string, params ...interface{}) error {
+// Writes PARSE, BIND, DESCRIBE and EXECUTE messages for the unnamed
+// statement and portal. The query is already formatted and args are sent
+// as values of its $1, $2, ... params in text format.
+func writeParseBindExecuteMsg(buf *pool.WriteBuffer, q []byte, args []interface{}) {
+	buf.StartMessage(parseMsg)
+	buf.WriteString("")
+	buf.Bytes = append(buf.Bytes, q...)
+	_ = buf.WriteByte(0x0)
+	buf.WriteInt16(0)
+	buf.FinishMessage()
+
+	buf.StartMessage(bindMsg)
+	buf.WriteString("")
+	buf.WriteString("")
+	buf.WriteInt16(0)
+	buf.WriteInt16(int16(len(args)))
+	for _, arg := range args {
+		buf.StartParam()
+		bytes := types.Append(buf.Bytes, arg, 0)
+		if bytes != nil {
+			buf.Bytes = bytes
+			buf.FinishParam()
+		} else {
+			buf.FinishNullParam()
+		}
+	}
+	buf.WriteInt16(0)
+	buf.FinishMessage()
+
+	buf.StartMessage(describeMsg)
+	buf.WriteByte('P') //nolint
+	buf.WriteString("")
+	buf.FinishMessage()
+
+	writeExecuteMsg(buf, 0)
+}
+
+// Writes PARSE, BIND and DESCRIBE messages for the unnamed statement
+// and portal. Query params are formatted on the client.
+func writeParseBindDescribeMsg(
+	buf *pool.WriteBuffer, fmter orm.QueryFormatter, query interface{}, params ...interface{},
+) error {
+	buf.StartMessage(parseMsg)
+	buf.WriteString("")
+	bytes, err := appendQuery(fmter, buf.Bytes, query, params...)
+	if err != nil {
+		buf.Reset()
+		return err
+	}
+	buf.Bytes = bytes
+	_ = buf.WriteByte(0x0)
+	buf.WriteInt16(0)
+	buf.FinishMessage()
+
 	buf.StartMessage(bindMsg)
 	buf.WriteString("")
-	buf.WriteString(name)
+	buf.WriteString("")
+	buf.WriteInt16(0)
+	buf.WriteInt16(0)
 	buf.WriteInt16(0)
+	buf.FinishMessage()
+
+	buf.StartMessage(describeMsg)
+	buf.WriteByte('P') //nolint
+	buf.WriteString("")
+	buf.FinishMessage()
+
+	return nil
+}
+
+// Writes EXECUTE message for the unnamed portal. When maxRows is not 0,
+// the portal is suspended after maxRows rows are returned.
+func writeExecuteMsg(buf *pool.WriteBuffer, maxRows int32) {
+	buf.StartMessage(executeMsg)
+	buf.WriteString("")
+	buf.WriteInt32(maxRows)
+	buf.FinishMessage()
+}
+
+// Writes BIND, EXECUTE and SYNC messages. When binaryFormat is true, parameters
+// and result columns that support binary format are sent using it.
+func writeBindExecuteMsg(
+	buf *pool.WriteBuffer,
+	name string,
+	desc *stmtDesc,
+	binaryFormat bool,
+	params ...interface{},
+) error {
+	buf.StartMessage(bindMsg)
+	buf.WriteString("")
+	buf.WriteString(name)
+
+	paramFormats := -1
+	if binaryFormat && len(desc.paramTypes) == len(params) {
+		buf.WriteInt16(int16(len(params)))
+		paramFormats = len(buf.Bytes)
+		for range params {
+			buf.WriteInt16(types.TextFormat)
+		}
+	} else {
+		buf.WriteInt16(0)
+	}
+
 	buf.WriteInt16(int16(len(params)))
-	for _, param := range params {
+	for i, param := range params {
 		buf.StartParam()
+
+		if paramFormats != -1 && param != nil {
+			bytes, ok := types.AppendBinary(buf.Bytes, param, desc.paramTypes[i])
+			if ok {
+				buf.Bytes = bytes
+				buf.FinishParam()
+				binary.BigEndian.PutUint16(
+					buf.Bytes[paramFormats+2*i:], uint16(types.BinaryFormat))
+				continue
+			}
+		}
+
 		bytes := types.Append(buf.Bytes, param, 0)
 		if bytes != nil {
 			buf.Bytes = bytes
@@ -558,7 +765,19 @@ func writeBindExecuteMsg(buf *pool.WriteBuffer, name string, params ...interface
 			buf.FinishNullParam()
 		}
 	}
-	buf.WriteInt16(0)
+
+	if binaryTypes := desc.binaryColumnTypes(binaryFormat); binaryTypes != nil {
+		buf.WriteInt16(int16(len(binaryTypes)))
+		for _, oid := range binaryTypes {
+			if oid != 0 {
+				buf.WriteInt16(types.BinaryFormat)
+			} else {
+				buf.WriteInt16(types.TextFormat)
+			}
+		}
+	} else {
+		buf.WriteInt16(0)
+	}
 	buf.FinishMessage()
 
 	buf.StartMessage(executeMsg)
@@ -578,36 +797,6 @@ func writeCloseMsg(buf *pool.WriteBuffer, name string) {
 	buf.FinishMessage()
 }
 
-func readCloseCompleteMsg(rd *internal.BufReader) error {
-	for {
-		c, msgLen, err := readMessageType(rd)
-		if err != nil {
-			return err
-		}
-		switch c {
-		case closeCompleteMsg:
-			_, err := rd.ReadN(msgLen)
-			return err
-		case errorResponseMsg:
-			e, err := readError(rd)
-			if err != nil {
-				return err
-			}
-			return e
-		case noticeResponseMsg:
-			if err := logNotice(rd, msgLen); err != nil {
-				return err
-			}
-		case parameterStatusMsg:
-			if err := logParameterStatus(rd, msgLen); err != nil {
-				return err
-			}
-		default:
-			return fmt.Errorf("pg: readCloseCompleteMsg: unexpected message %q", c)
-		}
-	}
-}
-
 func readSimpleQuery(rd *internal.BufReader) (*result, error) {
 	var res result
 	var firstErr error
@@ -659,11 +848,11 @@ func readSimpleQuery(rd *internal.BufReader) (*result, error) {
 				firstErr = errEmptyQuery
 			}
 		case noticeResponseMsg:
-			if err := logNotice(rd, msgLen); err != nil {
+			if err := handleNotice(rd, msgLen); err != nil {
 				return nil, err
 			}
 		case parameterStatusMsg:
-			if err := logParameterStatus(rd, msgLen); err != nil {
+			if err := handleParameterStatus(rd, msgLen); err != nil {
 				return nil, err
 			}
 		default:
@@ -723,11 +912,11 @@ func readExtQuery(rd *internal.BufReader) (*result, error) {
 				firstErr = errEmptyQuery
 			}
 		case noticeResponseMsg:
-			if err := logNotice(rd, msgLen); err != nil {
+			if err := handleNotice(rd, msgLen); err != nil {
 				return nil, err
 			}
 		case parameterStatusMsg:
-			if err := logParameterStatus(rd, msgLen); err != nil {
+			if err := handleParameterStatus(rd, msgLen); err != nil {
 				return nil, err
 			}
 		default:
@@ -759,6 +948,63 @@ func readRowDescription(rd *internal.BufReader, columns [][]byte) ([][]byte, err
 	return columns, nil
 }
 
+// readRowDescriptionTypes is like readRowDescription, but also returns
+// type OIDs of the columns.
+func readRowDescriptionTypes(rd *internal.BufReader) ([][]byte, []uint32, error) {
+	colNum, err := readInt16(rd)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	columns := make([][]byte, colNum)
+	colTypes := make([]uint32, colNum)
+	for i := 0; i < int(colNum); i++ {
+		b, err := rd.ReadSlice(0)
+		if err != nil {
+			return nil, nil, err
+		}
+		columns[i] = append([]byte(nil), b[:len(b)-1]...)
+
+		// Skip table oid and column attribute number.
+		_, err = rd.ReadN(6)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		oid, err := readInt32(rd)
+		if err != nil {
+			return nil, nil, err
+		}
+		colTypes[i] = uint32(oid)
+
+		// Skip type size, type modifier and format code.
+		_, err = rd.ReadN(8)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return columns, colTypes, nil
+}
+
+func readParameterDescription(rd *internal.BufReader) ([]uint32, error) {
+	num, err := readInt16(rd)
+	if err != nil {
+		return nil, err
+	}
+
+	paramTypes := make([]uint32, num)
+	for i := range paramTypes {
+		oid, err := readInt32(rd)
+		if err != nil {
+			return nil, err
+		}
+		paramTypes[i] = uint32(oid)
+	}
+
+	return paramTypes, nil
+}
+
 func setByteSliceLen(b [][]byte, n int) [][]byte {
 	if n <= cap(b) {
 		return b[:n]
@@ -768,7 +1014,14 @@ func setByteSliceLen(b [][]byte, n int) [][]byte {
 	return b
 }
 
-func readDataRow(rd *internal.BufReader, scanner orm.ColumnScanner, columns [][]byte) error {
+// readDataRow reads a data row and scans it using the scanner.
+// Columns with non-zero binaryTypes are received in binary format.
+func readDataRow(
+	rd *internal.BufReader,
+	scanner orm.ColumnScanner,
+	columns [][]byte,
+	binaryTypes []uint32,
+) error {
 	colNum, err := readInt16(rd)
 	if err != nil {
 		return err
@@ -795,9 +1048,13 @@ func readDataRow(rd *internal.BufReader, scanner orm.ColumnScanner, columns [][]
 			colRd = rd.BytesReader(0)
 		}
 
-		err = scanner.ScanColumn(int(colIdx), column, colRd, int(n))
+		if binaryTypes != nil && binaryTypes[colIdx] != 0 {
+			err = scanBinaryColumn(scanner, int(colIdx), column, binaryTypes[colIdx], colRd, int(n))
+		} else {
+			err = scanner.ScanColumn(int(colIdx), column, colRd, int(n))
+		}
 		if err != nil && firstErr == nil {
-			firstErr = internal.Errorf(err.Error())
+			firstErr = internal.WrapError(err)
 		}
 
 		if rd == colRd {
@@ -814,6 +1071,29 @@ func readDataRow(rd *internal.BufReader, scanner orm.ColumnScanner, columns [][]
 	return firstErr
 }
 
+func scanBinaryColumn(
+	scanner orm.ColumnScanner, colIdx int, column string, oid uint32, rd types.Reader, n int,
+) error {
+	if scanner, ok := scanner.(orm.BinaryColumnScanner); ok {
+		return scanner.ScanBinaryColumn(colIdx, column, oid, rd, n)
+	}
+
+	if n == -1 {
+		return scanner.ScanColumn(colIdx, column, rd, n)
+	}
+
+	b, err := rd.ReadFullTemp()
+	if err != nil {
+		return err
+	}
+
+	text, err := types.AppendBinaryText(nil, oid, b)
+	if err != nil {
+		return err
+	}
+	return scanner.ScanColumn(colIdx, column, types.NewBytesReader(text), len(text))
+}
+
 func newModel(mod interface{}) (orm.Model, error) {
 	m, err := orm.NewModel(mod)
 	if err != nil {
@@ -850,7 +1130,7 @@ func readSimpleQueryData(rd *internal.BufReader, mod interface{}) (*result, erro
 			}
 		case dataRowMsg:
 			scanner := res.model.NextColumnScanner()
-			if err := readDataRow(rd, scanner, rd.Columns); err != nil {
+			if err := readDataRow(rd, scanner, rd.Columns, nil); err != nil {
 				if firstErr == nil {
 					firstErr = err
 				}
@@ -891,11 +1171,11 @@ func readSimpleQueryData(rd *internal.BufReader, mod interface{}) (*result, erro
 				firstErr = errEmptyQuery
 			}
 		case noticeResponseMsg:
-			if err := logNotice(rd, msgLen); err != nil {
+			if err := handleNotice(rd, msgLen); err != nil {
 				return nil, err
 			}
 		case parameterStatusMsg:
-			if err := logParameterStatus(rd, msgLen); err != nil {
+			if err := handleParameterStatus(rd, msgLen); err != nil {
 				return nil, err
 			}
 		default:
@@ -904,7 +1184,9 @@ func readSimpleQueryData(rd *internal.BufReader, mod interface{}) (*result, erro
 	}
 }
 
-func readExtQueryData(rd *internal.BufReader, mod interface{}, columns [][]byte) (*result, error) {
+func readExtQueryData(
+	rd *internal.BufReader, mod interface{}, columns [][]byte, binaryTypes []uint32,
+) (*result, error) {
 	var res result
 	var firstErr error
 	for {
@@ -932,7 +1214,7 @@ func readExtQueryData(rd *internal.BufReader, mod interface{}, columns [][]byte)
 			}
 
 			scanner := res.model.NextColumnScanner()
-			if err := readDataRow(rd, scanner, columns); err != nil {
+			if err := readDataRow(rd, scanner, columns, binaryTypes); err != nil {
 				if firstErr == nil {
 					firstErr = err
 				}
@@ -969,11 +1251,11 @@ func readExtQueryData(rd *internal.BufReader, mod interface{}, columns [][]byte)
 				firstErr = e
 			}
 		case noticeResponseMsg:
-			if err := logNotice(rd, msgLen); err != nil {
+			if err := handleNotice(rd, msgLen); err != nil {
 				return nil, err
 			}
 		case parameterStatusMsg:
-			if err := logParameterStatus(rd, msgLen); err != nil {
+			if err := handleParameterStatus(rd, msgLen); err != nil {
 				return nil, err
 			}
 		default:
@@ -982,6 +1264,208 @@ func readExtQueryData(rd *internal.BufReader, mod interface{}, columns [][]byte)
 	}
 }
 
+// readCachedQueryData reads responses to the statements closed
+// by the cache and to the Parse and Describe messages when the statement
+// is prepared in the same round trip. Statement description is stored
+// in desc. Rows are discarded when mod is nil.
+func readCachedQueryData(
+	rd *internal.BufReader, mod interface{}, desc *stmtDesc, binaryTypes []uint32,
+) (*result, error) {
+	var res result
+	var firstErr error
+	for {
+		c, msgLen, err := readMessageType(rd)
+		if err != nil {
+			return nil, err
+		}
+
+		switch c {
+		case closeCompleteMsg, parseCompleteMsg, bindCompleteMsg, noDataMsg:
+			_, err := rd.ReadN(msgLen)
+			if err != nil {
+				return nil, err
+			}
+		case parameterDescriptionMsg: // Response to the DESCRIBE message.
+			desc.paramTypes, err = readParameterDescription(rd)
+			if err != nil {
+				return nil, err
+			}
+		case rowDescriptionMsg: // Response to the DESCRIBE message.
+			desc.columns, desc.columnTypes, err = readRowDescriptionTypes(rd)
+			if err != nil {
+				return nil, err
+			}
+		case dataRowMsg:
+			if mod == nil {
+				_, err := rd.ReadN(msgLen)
+				if err != nil {
+					return nil, err
+				}
+				res.returned++
+				continue
+			}
+
+			if res.model == nil {
+				var err error
+				res.model, err = newModel(mod)
+				if err != nil {
+					if firstErr == nil {
+						firstErr = err
+					}
+					res.model = Discard
+				}
+			}
+
+			scanner := res.model.NextColumnScanner()
+			if err := readDataRow(rd, scanner, desc.columns, binaryTypes); err != nil {
+				if firstErr == nil {
+					firstErr = err
+				}
+			} else if err := res.model.AddColumnScanner(scanner); err != nil {
+				if firstErr == nil {
+					firstErr = err
+				}
+			}
+
+			res.returned++
+		case commandCompleteMsg: // Response to the EXECUTE message.
+			b, err := rd.ReadN(msgLen)
+			if err != nil {
+				return nil, err
+			}
+			if err := res.parse(b); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		case readyForQueryMsg: // Response to the SYNC message.
+			_, err := rd.ReadN(msgLen)
+			if err != nil {
+				return nil, err
+			}
+			if firstErr != nil {
+				return nil, firstErr
+			}
+			return &res, nil
+		case errorResponseMsg:
+			e, err := readError(rd)
+			if err != nil {
+				return nil, err
+			}
+			if firstErr == nil {
+				firstErr = e
+			}
+		case emptyQueryResponseMsg:
+			if firstErr == nil {
+				firstErr = errEmptyQuery
+			}
+		case noticeResponseMsg:
+			if err := handleNotice(rd, msgLen); err != nil {
+				return nil, err
+			}
+		case parameterStatusMsg:
+			if err := handleParameterStatus(rd, msgLen); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, fmt.Errorf("pg: readCachedQueryData: unexpected message %q", c)
+		}
+	}
+}
+
+// readBatchData reads responses to the queries sent by the batch.
+// After an error the server skips the rest of the queries until SYNC.
+func readBatchData(rd *internal.BufReader, queries []*batchQuery) error {
+	for _, q := range queries {
+		*q.res = result{}
+	}
+
+	var idx int
+	var firstErr error
+	for {
+		c, msgLen, err := readMessageType(rd)
+		if err != nil {
+			return err
+		}
+
+		switch c {
+		case parseCompleteMsg, bindCompleteMsg, noDataMsg:
+			_, err := rd.ReadN(msgLen)
+			if err != nil {
+				return err
+			}
+		case rowDescriptionMsg: // Response to the DESCRIBE message.
+			rd.Columns, err = readRowDescription(rd, rd.Columns[:0])
+			if err != nil {
+				return err
+			}
+
+			q := queries[idx]
+			if q.model == nil {
+				q.res.model = Discard
+				break
+			}
+			q.res.model, err = newModel(q.model)
+			if err != nil {
+				if firstErr == nil {
+					firstErr = err
+				}
+				q.res.model = Discard
+			}
+		case dataRowMsg:
+			res := queries[idx].res
+			scanner := res.model.NextColumnScanner()
+			if err := readDataRow(rd, scanner, rd.Columns, nil); err != nil {
+				if firstErr == nil {
+					firstErr = err
+				}
+			} else if err := res.model.AddColumnScanner(scanner); err != nil {
+				if firstErr == nil {
+					firstErr = err
+				}
+			}
+
+			res.returned++
+		case commandCompleteMsg: // Response to the EXECUTE message.
+			b, err := rd.ReadN(msgLen)
+			if err != nil {
+				return err
+			}
+			if err := queries[idx].res.parse(b); err != nil && firstErr == nil {
+				firstErr = err
+			}
+			idx++
+		case emptyQueryResponseMsg:
+			if firstErr == nil {
+				firstErr = errEmptyQuery
+			}
+			idx++
+		case readyForQueryMsg: // Response to the SYNC message.
+			_, err := rd.ReadN(msgLen)
+			if err != nil {
+				return err
+			}
+			return firstErr
+		case errorResponseMsg:
+			e, err := readError(rd)
+			if err != nil {
+				return err
+			}
+			if firstErr == nil {
+				firstErr = e
+			}
+		case noticeResponseMsg:
+			if err := handleNotice(rd, msgLen); err != nil {
+				return err
+			}
+		case parameterStatusMsg:
+			if err := handleParameterStatus(rd, msgLen); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("pg: readBatchData: unexpected message %q", c)
+		}
+	}
+}
+
 func readCopyInResponse(rd *internal.BufReader) error {
 	var firstErr error
 	for {
@@ -1009,11 +1493,11 @@ func readCopyInResponse(rd *internal.BufReader) error {
 			}
 			return firstErr
 		case noticeResponseMsg:
-			if err := logNotice(rd, msgLen); err != nil {
+			if err := handleNotice(rd, msgLen); err != nil {
 				return err
 			}
 		case parameterStatusMsg:
-			if err := logParameterStatus(rd, msgLen); err != nil {
+			if err := handleParameterStatus(rd, msgLen); err != nil {
 				return err
 			}
 		default:
@@ -1049,11 +1533,11 @@ func readCopyOutResponse(rd *internal.BufReader) error {
 			}
 			return firstErr
 		case noticeResponseMsg:
-			if err := logNotice(rd, msgLen); err != nil {
+			if err := handleNotice(rd, msgLen); err != nil {
 				return err
 			}
 		case parameterStatusMsg:
-			if err := logParameterStatus(rd, msgLen); err != nil {
+			if err := handleParameterStatus(rd, msgLen); err != nil {
 				return err
 			}
 		default:
@@ -1115,11 +1599,11 @@ func readCopyData(rd *internal.BufReader, w io.Writer) (*result, error) {
 			}
 			return nil, e
 		case noticeResponseMsg:
-			if err := logNotice(rd, msgLen); err != nil {
+			if err := handleNotice(rd, msgLen); err != nil {
 				return nil, err
 			}
 		case parameterStatusMsg:
-			if err := logParameterStatus(rd, msgLen); err != nil {
+			if err := handleParameterStatus(rd, msgLen); err != nil {
 				return nil, err
 			}
 		default:
@@ -1176,11 +1660,11 @@ func readReadyForQuery(rd *internal.BufReader) (*result, error) {
 				firstErr = e
 			}
 		case noticeResponseMsg:
-			if err := logNotice(rd, msgLen); err != nil {
+			if err := handleNotice(rd, msgLen); err != nil {
 				return nil, err
 			}
 		case parameterStatusMsg:
-			if err := logParameterStatus(rd, msgLen); err != nil {
+			if err := handleParameterStatus(rd, msgLen); err != nil {
 				return nil, err
 			}
 		default:
@@ -1214,7 +1698,7 @@ func readNotification(rd *internal.BufReader) (channel, payload string, err erro
 			}
 			return "", "", e
 		case noticeResponseMsg:
-			if err := logNotice(rd, msgLen); err != nil {
+			if err := handleNotice(rd, msgLen); err != nil {
 				return "", "", err
 			}
 		case notificationResponseMsg:
@@ -1247,14 +1731,39 @@ func terminateConn(cn *pool.Conn) error {
 
 //------------------------------------------------------------------------------
 
-func logNotice(rd *internal.BufReader, msgLen int) error {
-	_, err := rd.ReadN(msgLen)
-	return err
+func handleNotice(rd *internal.BufReader, msgLen int) error {
+	if rd.OnNotice == nil {
+		_, err := rd.ReadN(msgLen)
+		return err
+	}
+
+	fields, err := readErrorFields(rd)
+	if err != nil {
+		return err
+	}
+	rd.OnNotice(fields)
+	return nil
 }
 
-func logParameterStatus(rd *internal.BufReader, msgLen int) error {
-	_, err := rd.ReadN(msgLen)
-	return err
+func handleParameterStatus(rd *internal.BufReader, msgLen int) error {
+	name, err := readString(rd)
+	if err != nil {
+		return err
+	}
+	value, err := readString(rd)
+	if err != nil {
+		return err
+	}
+
+	// Copy on write, because the map is shared with ServerParams callers.
+	params := make(map[string]string, len(rd.ServerParams)+1)
+	for k, v := range rd.ServerParams {
+		params[k] = v
+	}
+	params[name] = value
+	rd.ServerParams = params
+
+	return nil
 }
 
 func readInt16(rd *internal.BufReader) (int16, error) {
@@ -1282,6 +1791,14 @@ func readString(rd *internal.BufReader) (string, error) {
 }
 
 func readError(rd *internal.BufReader) (error, error) {
+	m, err := readErrorFields(rd)
+	if err != nil {
+		return nil, err
+	}
+	return internal.NewPGError(m), nil
+}
+
+func readErrorFields(rd *internal.BufReader) (map[byte]string, error) {
 	m := make(map[byte]string)
 	for {
 		c, err := rd.ReadByte()
@@ -1297,7 +1814,7 @@ func readError(rd *internal.BufReader) (error, error) {
 		}
 		m[c] = s
 	}
-	return internal.NewPGError(m), nil
+	return m, nil
 }
 
 func readMessageType(rd *internal.BufReader) (byte, int, error) {
@@ -0,0 +1,79 @@
+/*
+Command pg-migrate runs SQL migrations from a directory.
+
+	pg-migrate -url postgres://postgres@localhost:5432/db?sslmode=disable -dir migrations up
+
+The commands are init, up [target], down, redo, reset, version,
+set_version version and status. Migrations written in Go must be
+compiled into a command of the application that calls migrations.Run.
+*/
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/go-pg/pg/v9"
+	"github.com/go-pg/pg/v9/migrations"
+)
+
+func main() {
+	url := flag.String("url", os.Getenv("DATABASE_URL"),
+		"connection URL or keyword/value string, default is $DATABASE_URL")
+	dir := flag.String("dir", "migrations", "directory with SQL migrations")
+	table := flag.String("table", migrations.DefaultTableName, "table that records versions")
+	tx := flag.Bool("tx", false, "run every migration in a transaction")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s [flags] command [args]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if err := run(*url, *dir, *table, *tx, flag.Args()); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(url, dir, table string, tx bool, args []string) error {
+	opt, err := pg.ParseURL(url)
+	if err != nil {
+		return err
+	}
+	db := pg.Connect(opt)
+	defer db.Close()
+
+	c := migrations.NewCollection().
+		SetTableName(table).
+		SetTransactional(tx)
+	if err := c.DiscoverSQLMigrations(dir); err != nil {
+		return err
+	}
+
+	if len(args) > 0 && args[0] == "status" {
+		statuses, err := c.Status(db)
+		if err != nil {
+			return err
+		}
+		for _, st := range statuses {
+			applied := "pending"
+			if st.Applied {
+				applied = "applied"
+			}
+			fmt.Printf("%-7s %d_%s\n", applied, st.Version, st.Name)
+		}
+		return nil
+	}
+
+	oldVersion, newVersion, err := c.Run(db, args...)
+	if err != nil {
+		return err
+	}
+	if newVersion != oldVersion {
+		fmt.Printf("migrated from version %d to %d\n", oldVersion, newVersion)
+	} else {
+		fmt.Printf("version is %d\n", newVersion)
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package migrations
+
+import (
+	"github.com/go-pg/pg/v9"
+)
+
+// DefaultCollection is the collection used by the package-level functions.
+var DefaultCollection = NewCollection()
+
+// Register adds a migration to DefaultCollection.
+// See Collection.Register.
+func Register(up, down func(DB) error) error {
+	return DefaultCollection.register(false, up, down)
+}
+
+// RegisterTx adds a migration that runs in a transaction to
+// DefaultCollection. See Collection.RegisterTx.
+func RegisterTx(up, down func(DB) error) error {
+	return DefaultCollection.register(true, up, down)
+}
+
+// MustRegister is like Register, but panics on error.
+func MustRegister(up, down func(DB) error) {
+	if err := DefaultCollection.register(false, up, down); err != nil {
+		panic(err)
+	}
+}
+
+// MustRegisterTx is like RegisterTx, but panics on error.
+func MustRegisterTx(up, down func(DB) error) {
+	if err := DefaultCollection.register(true, up, down); err != nil {
+		panic(err)
+	}
+}
+
+// Run runs the command with DefaultCollection. See Collection.Run.
+func Run(db *pg.DB, a ...string) (oldVersion, newVersion int64, err error) {
+	return DefaultCollection.Run(db, a...)
+}
@@ -0,0 +1,514 @@
+/*
+Package migrations runs versioned schema migrations written as Go
+functions or SQL files.
+
+Go migrations are usually registered from files named after the version
+and the name of the migration, e.g. 1_create_books.go:
+
+	func init() {
+		migrations.MustRegisterTx(func(db migrations.DB) error {
+			_, err := db.Exec(`CREATE TABLE books (id serial PRIMARY KEY, title text)`)
+			return err
+		}, func(db migrations.DB) error {
+			_, err := db.Exec(`DROP TABLE books`)
+			return err
+		})
+	}
+
+SQL migrations are discovered in a directory with files named like
+2_add_author.up.sql and 2_add_author.down.sql. Files named like
+2_add_author.tx.up.sql run in a transaction. SQL files can contain
+multiple statements.
+
+The current version is recorded in the gopg_migrations table. Commands
+hold a PostgreSQL advisory lock, so concurrent runners, e.g. several
+instances of a service started at once, apply every migration once.
+
+	oldVersion, newVersion, err := migrations.Run(db, os.Args[1:]...)
+*/
+package migrations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/go-pg/pg/v9"
+	"github.com/go-pg/pg/v9/orm"
+)
+
+// DefaultTableName is the name of the table that records versions.
+const DefaultTableName = "gopg_migrations"
+
+// DB is the database a migration runs against: a *pg.Conn or,
+// for transactional migrations, a *pg.Tx.
+type DB interface {
+	Model(model ...interface{}) *orm.Query
+	Exec(query interface{}, params ...interface{}) (pg.Result, error)
+	ExecOne(query interface{}, params ...interface{}) (pg.Result, error)
+	Query(model, query interface{}, params ...interface{}) (pg.Result, error)
+	QueryOne(model, query interface{}, params ...interface{}) (pg.Result, error)
+	Context() context.Context
+}
+
+var _ DB = (*pg.Conn)(nil)
+var _ DB = (*pg.Tx)(nil)
+
+// Migration is a versioned change of the schema.
+type Migration struct {
+	Version int64
+	Name    string
+
+	Up   func(DB) error
+	Down func(DB) error
+
+	// Whether Up and Down run in a transaction together with
+	// the update of the version.
+	Tx bool
+}
+
+func (m *Migration) String() string {
+	if m.Name == "" {
+		return strconv.FormatInt(m.Version, 10)
+	}
+	return strconv.FormatInt(m.Version, 10) + "_" + m.Name
+}
+
+// MigrationError is returned when a migration fails.
+type MigrationError struct {
+	Migration *Migration
+	Up        bool
+	Err       error
+}
+
+func (e *MigrationError) Error() string {
+	dir := "down"
+	if e.Up {
+		dir = "up"
+	}
+	return fmt.Sprintf("migrations: %s %s failed: %s", e.Migration, dir, e.Err)
+}
+
+func (e *MigrationError) Unwrap() error {
+	return e.Err
+}
+
+// Status describes a known migration.
+type Status struct {
+	Version int64
+	Name    string
+	Applied bool
+}
+
+//------------------------------------------------------------------------------
+
+// Collection is a set of migrations and the options to run them.
+type Collection struct {
+	tableName     string
+	transactional bool
+
+	mu         sync.Mutex
+	migrations map[int64]*Migration
+	err        error
+}
+
+// NewCollection returns a collection with the migrations.
+func NewCollection(migrations ...*Migration) *Collection {
+	c := &Collection{
+		tableName:  DefaultTableName,
+		migrations: make(map[int64]*Migration),
+	}
+	c.Add(migrations...)
+	return c
+}
+
+// SetTableName sets the table that records versions,
+// e.g. "myschema.migrations". Default is gopg_migrations.
+func (c *Collection) SetTableName(name string) *Collection {
+	c.tableName = name
+	return c
+}
+
+// SetTransactional makes every migration run in a transaction
+// as if Migration.Tx was set.
+func (c *Collection) SetTransactional(on bool) *Collection {
+	c.transactional = on
+	return c
+}
+
+// Add adds the migrations to the collection. Adding two migrations
+// with the same version is an error that is returned by the commands.
+func (c *Collection) Add(migrations ...*Migration) *Collection {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, m := range migrations {
+		if _, ok := c.migrations[m.Version]; ok {
+			if c.err == nil {
+				c.err = fmt.Errorf("migrations: version %d is registered twice", m.Version)
+			}
+			continue
+		}
+		c.migrations[m.Version] = m
+	}
+	return c
+}
+
+// Register adds a migration with the version and the name parsed
+// from the name of the file that calls Register, e.g. 1_initial.go.
+func (c *Collection) Register(up, down func(DB) error) error {
+	return c.register(false, up, down)
+}
+
+// RegisterTx is like Register, but the migration runs in a transaction.
+func (c *Collection) RegisterTx(up, down func(DB) error) error {
+	return c.register(true, up, down)
+}
+
+// MustRegister is like Register, but panics on error.
+func (c *Collection) MustRegister(up, down func(DB) error) {
+	if err := c.register(false, up, down); err != nil {
+		panic(err)
+	}
+}
+
+// MustRegisterTx is like RegisterTx, but panics on error.
+func (c *Collection) MustRegisterTx(up, down func(DB) error) {
+	if err := c.register(true, up, down); err != nil {
+		panic(err)
+	}
+}
+
+// register must be called directly by the exported functions,
+// so the caller of them is 2 frames up.
+func (c *Collection) register(tx bool, up, down func(DB) error) error {
+	_, file, _, ok := runtime.Caller(2)
+	if !ok {
+		return errors.New("migrations: can't get the caller file name")
+	}
+
+	version, name, err := parseFileName(file)
+	if err != nil {
+		return err
+	}
+	c.Add(&Migration{
+		Version: version,
+		Name:    strings.TrimSuffix(name, ".go"),
+		Up:      up,
+		Down:    down,
+		Tx:      tx,
+	})
+	return nil
+}
+
+// parseFileName parses the version and the name of the migration
+// from a file name like 1_initial.up.sql.
+func parseFileName(file string) (int64, string, error) {
+	base := filepath.Base(file)
+	n := strings.IndexFunc(base, func(r rune) bool {
+		return r < '0' || r > '9'
+	})
+	if n <= 0 {
+		return 0, "", fmt.Errorf(
+			"migrations: file %q must start with a version, e.g. 1_initial.go", base)
+	}
+
+	version, err := strconv.ParseInt(base[:n], 10, 64)
+	if err != nil {
+		return 0, "", fmt.Errorf("migrations: file %q: %s", base, err)
+	}
+	if version <= 0 {
+		return 0, "", fmt.Errorf("migrations: file %q: version must be positive", base)
+	}
+
+	name := strings.TrimPrefix(base[n:], "_")
+	return version, name, nil
+}
+
+// Migrations returns the migrations sorted by version.
+func (c *Collection) Migrations() []*Migration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.sorted()
+}
+
+func (c *Collection) sorted() []*Migration {
+	ms := make([]*Migration, 0, len(c.migrations))
+	for _, m := range c.migrations {
+		ms = append(ms, m)
+	}
+	sort.Slice(ms, func(i, j int) bool {
+		return ms[i].Version < ms[j].Version
+	})
+	return ms
+}
+
+//------------------------------------------------------------------------------
+
+// Init creates the version table if it does not exist.
+func (c *Collection) Init(db *pg.DB) error {
+	return c.withLock(db, func(conn *pg.Conn) error {
+		return nil
+	})
+}
+
+// Version returns the current version of the schema.
+func (c *Collection) Version(db *pg.DB) (int64, error) {
+	var version int64
+	err := c.withLock(db, func(conn *pg.Conn) error {
+		var err error
+		version, err = c.version(conn)
+		return err
+	})
+	return version, err
+}
+
+// SetVersion records the version without running migrations.
+func (c *Collection) SetVersion(db *pg.DB, version int64) error {
+	return c.withLock(db, func(conn *pg.Conn) error {
+		return c.setVersion(conn, version)
+	})
+}
+
+// Status returns the migrations and whether they are applied.
+func (c *Collection) Status(db *pg.DB) ([]Status, error) {
+	version, err := c.Version(db)
+	if err != nil {
+		return nil, err
+	}
+
+	ms := c.Migrations()
+	statuses := make([]Status, len(ms))
+	for i, m := range ms {
+		statuses[i] = Status{
+			Version: m.Version,
+			Name:    m.Name,
+			Applied: m.Version <= version,
+		}
+	}
+	return statuses, nil
+}
+
+// Up applies all migrations that are not applied yet.
+func (c *Collection) Up(db *pg.DB) (oldVersion, newVersion int64, err error) {
+	return c.UpTo(db, -1)
+}
+
+// UpTo applies migrations up to and including the target version.
+// A negative target applies all migrations.
+func (c *Collection) UpTo(db *pg.DB, target int64) (oldVersion, newVersion int64, err error) {
+	err = c.run(db, func(conn *pg.Conn, ms []*Migration) error {
+		oldVersion, err = c.version(conn)
+		if err != nil {
+			return err
+		}
+		newVersion = oldVersion
+
+		for _, m := range ms {
+			if m.Version <= newVersion {
+				continue
+			}
+			if target >= 0 && m.Version > target {
+				break
+			}
+			if err := c.apply(conn, ms, m, true); err != nil {
+				return err
+			}
+			newVersion = m.Version
+		}
+		return nil
+	})
+	return oldVersion, newVersion, err
+}
+
+// Down rolls back the last applied migration.
+func (c *Collection) Down(db *pg.DB) (oldVersion, newVersion int64, err error) {
+	err = c.run(db, func(conn *pg.Conn, ms []*Migration) error {
+		oldVersion, err = c.version(conn)
+		if err != nil {
+			return err
+		}
+		newVersion, err = c.down(conn, ms, oldVersion)
+		return err
+	})
+	return oldVersion, newVersion, err
+}
+
+// Redo rolls back the last applied migration and applies it again.
+func (c *Collection) Redo(db *pg.DB) (oldVersion, newVersion int64, err error) {
+	err = c.run(db, func(conn *pg.Conn, ms []*Migration) error {
+		oldVersion, err = c.version(conn)
+		if err != nil {
+			return err
+		}
+		newVersion, err = c.down(conn, ms, oldVersion)
+		if err != nil || newVersion == oldVersion {
+			return err
+		}
+
+		m := find(ms, oldVersion)
+		if err := c.apply(conn, ms, m, true); err != nil {
+			return err
+		}
+		newVersion = oldVersion
+		return nil
+	})
+	return oldVersion, newVersion, err
+}
+
+// Reset rolls back all applied migrations.
+func (c *Collection) Reset(db *pg.DB) (oldVersion, newVersion int64, err error) {
+	err = c.run(db, func(conn *pg.Conn, ms []*Migration) error {
+		oldVersion, err = c.version(conn)
+		if err != nil {
+			return err
+		}
+		newVersion = oldVersion
+
+		for newVersion > 0 {
+			newVersion, err = c.down(conn, ms, newVersion)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return oldVersion, newVersion, err
+}
+
+// down rolls back the migration with the version and
+// returns the version of the previous migration.
+func (c *Collection) down(conn *pg.Conn, ms []*Migration, version int64) (int64, error) {
+	if version == 0 {
+		return 0, nil
+	}
+	m := find(ms, version)
+	if m == nil {
+		return version, fmt.Errorf("migrations: version %d is not registered", version)
+	}
+	if err := c.apply(conn, ms, m, false); err != nil {
+		return version, err
+	}
+	return prevVersion(ms, m), nil
+}
+
+func find(ms []*Migration, version int64) *Migration {
+	for _, m := range ms {
+		if m.Version == version {
+			return m
+		}
+	}
+	return nil
+}
+
+func prevVersion(ms []*Migration, m *Migration) int64 {
+	var prev int64
+	for _, other := range ms {
+		if other.Version >= m.Version {
+			break
+		}
+		prev = other.Version
+	}
+	return prev
+}
+
+// apply runs Up or Down of the migration and records the new version.
+func (c *Collection) apply(conn *pg.Conn, ms []*Migration, m *Migration, up bool) error {
+	fn := m.Down
+	version := prevVersion(ms, m)
+	if up {
+		fn = m.Up
+		version = m.Version
+	}
+
+	run := func(db DB) error {
+		if fn != nil {
+			if err := fn(db); err != nil {
+				return &MigrationError{Migration: m, Up: up, Err: err}
+			}
+		}
+		return c.setVersion(db, version)
+	}
+
+	if m.Tx || c.transactional {
+		return conn.RunInTransaction(func(tx *pg.Tx) error {
+			return run(tx)
+		})
+	}
+	return run(conn)
+}
+
+// run runs fn with the sorted migrations holding the lock.
+func (c *Collection) run(db *pg.DB, fn func(*pg.Conn, []*Migration) error) error {
+	c.mu.Lock()
+	err := c.err
+	ms := c.sorted()
+	c.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	return c.withLock(db, func(conn *pg.Conn) error {
+		return fn(conn, ms)
+	})
+}
+
+// withLock runs fn on a single connection that holds an advisory lock
+// derived from the table name and creates the version table.
+func (c *Collection) withLock(db *pg.DB, fn func(*pg.Conn) error) error {
+	conn := db.Conn()
+	defer conn.Close()
+
+	key := c.lockKey()
+	_, err := conn.Exec("SELECT pg_advisory_lock(?)", key)
+	if err != nil {
+		return err
+	}
+
+	err = c.createTable(conn)
+	if err == nil {
+		err = fn(conn)
+	}
+
+	_, unlockErr := conn.Exec("SELECT pg_advisory_unlock(?)", key)
+	if err == nil {
+		err = unlockErr
+	}
+	return err
+}
+
+func (c *Collection) lockKey() int64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(c.tableName))
+	return int64(h.Sum64())
+}
+
+func (c *Collection) createTable(db DB) error {
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS ? (
+		id serial PRIMARY KEY,
+		version bigint NOT NULL,
+		created_at timestamptz NOT NULL DEFAULT now()
+	)`, pg.Ident(c.tableName))
+	return err
+}
+
+func (c *Collection) version(db DB) (int64, error) {
+	var version int64
+	_, err := db.QueryOne(pg.Scan(&version),
+		"SELECT version FROM ? ORDER BY id DESC LIMIT 1", pg.Ident(c.tableName))
+	if err == pg.ErrNoRows {
+		return 0, nil
+	}
+	return version, err
+}
+
+func (c *Collection) setVersion(db DB, version int64) error {
+	_, err := db.Exec("INSERT INTO ? (version) VALUES (?)", pg.Ident(c.tableName), version)
+	return err
+}
@@ -0,0 +1,314 @@
+package migrations_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/go-pg/pg/v9"
+	"github.com/go-pg/pg/v9/migrations"
+)
+
+func connect(t *testing.T) *pg.DB {
+	db := pg.Connect(&pg.Options{
+		User: "postgres",
+	})
+	_, err := db.Exec(`
+		DROP TABLE IF EXISTS migrations_test_versions;
+		DROP TABLE IF EXISTS migrations_test_books;
+		DROP TABLE IF EXISTS migrations_test_authors`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func newCollection(t *testing.T) *migrations.Collection {
+	c := migrations.NewCollection(&migrations.Migration{
+		Version: 1,
+		Name:    "create_books",
+		Up: func(db migrations.DB) error {
+			_, err := db.Exec(`CREATE TABLE migrations_test_books (id serial PRIMARY KEY, title text)`)
+			return err
+		},
+		Down: func(db migrations.DB) error {
+			_, err := db.Exec(`DROP TABLE migrations_test_books`)
+			return err
+		},
+	}, &migrations.Migration{
+		Version: 3,
+		Name:    "create_authors",
+		Up: func(db migrations.DB) error {
+			_, err := db.Exec(`CREATE TABLE migrations_test_authors (id serial PRIMARY KEY)`)
+			return err
+		},
+		Down: func(db migrations.DB) error {
+			_, err := db.Exec(`DROP TABLE migrations_test_authors`)
+			return err
+		},
+	})
+	c.SetTableName("migrations_test_versions")
+	if err := c.DiscoverSQLMigrations("testdata"); err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func checkVersions(t *testing.T, cmd string, oldVersion, newVersion int64, err error, wantedOld, wantedNew int64) {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("%s: %s", cmd, err)
+	}
+	if oldVersion != wantedOld || newVersion != wantedNew {
+		t.Fatalf("%s: got %d -> %d, wanted %d -> %d",
+			cmd, oldVersion, newVersion, wantedOld, wantedNew)
+	}
+}
+
+func tableExists(t *testing.T, db *pg.DB, table string) bool {
+	var exists bool
+	_, err := db.QueryOne(pg.Scan(&exists), "SELECT to_regclass(?) IS NOT NULL", table)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return exists
+}
+
+func TestCommands(t *testing.T) {
+	db := connect(t)
+	defer db.Close()
+
+	c := newCollection(t)
+
+	from, to, err := c.Run(db, "up", "2")
+	checkVersions(t, "up 2", from, to, err, 0, 2)
+
+	_, err = db.Exec("INSERT INTO migrations_test_books (title, author) VALUES ('b', 'a')")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	from, to, err = c.Run(db)
+	checkVersions(t, "up", from, to, err, 2, 3)
+	if !tableExists(t, db, "migrations_test_authors") {
+		t.Fatal("migration 3 is not applied")
+	}
+
+	statuses, err := c.Status(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wanted := []migrations.Status{
+		{Version: 1, Name: "create_books", Applied: true},
+		{Version: 2, Name: "add_author", Applied: true},
+		{Version: 3, Name: "create_authors", Applied: true},
+	}
+	if len(statuses) != len(wanted) {
+		t.Fatalf("got %v, wanted %v", statuses, wanted)
+	}
+	for i := range wanted {
+		if statuses[i] != wanted[i] {
+			t.Fatalf("got %v, wanted %v", statuses, wanted)
+		}
+	}
+
+	from, to, err = c.Run(db, "down")
+	checkVersions(t, "down", from, to, err, 3, 2)
+	if tableExists(t, db, "migrations_test_authors") {
+		t.Fatal("migration 3 is not rolled back")
+	}
+
+	from, to, err = c.Run(db, "redo")
+	checkVersions(t, "redo", from, to, err, 2, 2)
+	var n int
+	_, err = db.QueryOne(pg.Scan(&n), "SELECT count(author) FROM migrations_test_books")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 0 {
+		t.Fatalf("got %d authors, wanted 0 after redo", n)
+	}
+
+	from, to, err = c.Run(db, "reset")
+	checkVersions(t, "reset", from, to, err, 2, 0)
+	if tableExists(t, db, "migrations_test_books") {
+		t.Fatal("migration 1 is not rolled back")
+	}
+
+	from, to, err = c.Run(db, "set_version", "3")
+	checkVersions(t, "set_version", from, to, err, 0, 3)
+
+	from, to, err = c.Run(db, "version")
+	checkVersions(t, "version", from, to, err, 3, 3)
+
+	_, _, err = c.Run(db, "drop")
+	if err == nil || err.Error() != `migrations: unsupported command: "drop"` {
+		t.Fatalf("got %v", err)
+	}
+}
+
+func TestSQLMigrationWithStatementCache(t *testing.T) {
+	db := connect(t)
+	db.Close()
+
+	db = pg.Connect(&pg.Options{
+		User:               "postgres",
+		StatementCacheSize: 10,
+	})
+	defer db.Close()
+
+	c := newCollection(t)
+
+	// 2_add_author.tx.up.sql contains multiple statements.
+	from, to, err := c.Run(db, "up", "2")
+	checkVersions(t, "up 2", from, to, err, 0, 2)
+
+	from, to, err = c.Run(db, "reset")
+	checkVersions(t, "reset", from, to, err, 2, 0)
+}
+
+func TestTransactionalMigration(t *testing.T) {
+	db := connect(t)
+	defer db.Close()
+
+	errBoom := errors.New("boom")
+	c := newCollection(t).SetTransactional(true)
+	c.Add(&migrations.Migration{
+		Version: 4,
+		Name:    "fail",
+		Up: func(db migrations.DB) error {
+			_, err := db.Exec(`CREATE TABLE migrations_test_fail (id int)`)
+			if err != nil {
+				return err
+			}
+			return errBoom
+		},
+	})
+
+	from, to, err := c.Up(db)
+	mErr, ok := err.(*migrations.MigrationError)
+	if !ok || mErr.Unwrap() != errBoom || mErr.Migration.Version != 4 {
+		t.Fatalf("got %v, wanted MigrationError", err)
+	}
+	if err.Error() != "migrations: 4_fail up failed: boom" {
+		t.Fatalf("got %q", err)
+	}
+	if from != 0 || to != 3 {
+		t.Fatalf("got %d -> %d, wanted 0 -> 3", from, to)
+	}
+
+	version, err := c.Version(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version != 3 {
+		t.Fatalf("got version %d, wanted 3", version)
+	}
+	if tableExists(t, db, "migrations_test_fail") {
+		t.Fatal("failed migration is not rolled back")
+	}
+}
+
+func TestConcurrentRunners(t *testing.T) {
+	db := connect(t)
+	defer db.Close()
+
+	c := newCollection(t)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 3)
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _, err := c.Up(db)
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var n int
+	_, err := db.QueryOne(pg.Scan(&n), "SELECT count(*) FROM migrations_test_versions")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 3 {
+		t.Fatalf("got %d version records, wanted 3", n)
+	}
+}
+
+func TestDiscoverSQLMigrations(t *testing.T) {
+	c := migrations.NewCollection()
+	if err := c.DiscoverSQLMigrations("testdata"); err != nil {
+		t.Fatal(err)
+	}
+
+	ms := c.Migrations()
+	if len(ms) != 1 {
+		t.Fatalf("got %d migrations, wanted 1", len(ms))
+	}
+	m := ms[0]
+	if m.Version != 2 || m.Name != "add_author" || !m.Tx {
+		t.Fatalf("got %+v", m)
+	}
+	if m.Up == nil || m.Down == nil {
+		t.Fatal("Up or Down is not set")
+	}
+}
+
+func TestWriteSQLMigration(t *testing.T) {
+	dir, err := ioutil.TempDir("", "migrations")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	up := []string{
+		`ALTER TABLE "books" ADD COLUMN "isbn" text`,
+		`ALTER TABLE "books" ADD CONSTRAINT "books_isbn_key" UNIQUE ("isbn")`,
+	}
+	down := []string{
+		`ALTER TABLE "books" DROP CONSTRAINT "books_isbn_key"`,
+		`ALTER TABLE "books" DROP COLUMN "isbn"`,
+	}
+	if err := migrations.WriteSQLMigration(dir, 5, "add_isbn", up, down); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := ioutil.ReadFile(filepath.Join(dir, "5_add_isbn.up.sql"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	wanted := up[0] + ";\n" + up[1] + ";\n"
+	if string(b) != wanted {
+		t.Fatalf("got %q, wanted %q", b, wanted)
+	}
+
+	c := migrations.NewCollection()
+	if err := c.DiscoverSQLMigrations(dir); err != nil {
+		t.Fatal(err)
+	}
+	ms := c.Migrations()
+	if len(ms) != 1 || ms[0].Version != 5 || ms[0].Name != "add_isbn" || ms[0].Down == nil {
+		t.Fatalf("got %v", ms)
+	}
+}
+
+func TestRegister(t *testing.T) {
+	c := migrations.NewCollection()
+	err := c.Register(nil, nil)
+	wanted := `migrations: file "migrations_test.go" must start with a version, e.g. 1_initial.go`
+	if err == nil || err.Error() != wanted {
+		t.Fatalf("got %v, wanted %q", err, wanted)
+	}
+}
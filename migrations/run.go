@@ -0,0 +1,87 @@
+package migrations
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/go-pg/pg/v9"
+)
+
+// Run runs a command given as command-line arguments:
+//
+//    init                - creates the version table
+//    up [target]         - applies migrations up to the target or all of them
+//    down                - rolls back the last migration
+//    redo                - rolls back the last migration and applies it again
+//    reset               - rolls back all migrations
+//    version             - returns the current version
+//    set_version version - records the version without running migrations
+//
+// Default command is up.
+func (c *Collection) Run(db *pg.DB, a ...string) (oldVersion, newVersion int64, err error) {
+	cmd := "up"
+	if len(a) > 0 {
+		cmd = a[0]
+		a = a[1:]
+	}
+
+	switch cmd {
+	case "up":
+		if len(a) == 0 {
+			return c.Up(db)
+		}
+		target, err := parseVersion(cmd, a)
+		if err != nil {
+			return 0, 0, err
+		}
+		return c.UpTo(db, target)
+	case "set_version":
+		version, err := parseVersion(cmd, a)
+		if err != nil {
+			return 0, 0, err
+		}
+		err = c.withLock(db, func(conn *pg.Conn) error {
+			oldVersion, err = c.version(conn)
+			if err != nil {
+				return err
+			}
+			return c.setVersion(conn, version)
+		})
+		if err != nil {
+			return oldVersion, oldVersion, err
+		}
+		return oldVersion, version, nil
+	case "init", "version", "down", "redo", "reset":
+		if len(a) > 0 {
+			return 0, 0, fmt.Errorf("migrations: %s takes no arguments", cmd)
+		}
+	default:
+		return 0, 0, fmt.Errorf("migrations: unsupported command: %q", cmd)
+	}
+
+	switch cmd {
+	case "init":
+		return 0, 0, c.Init(db)
+	case "version":
+		version, err := c.Version(db)
+		return version, version, err
+	case "down":
+		return c.Down(db)
+	case "redo":
+		return c.Redo(db)
+	default:
+		return c.Reset(db)
+	}
+}
+
+// parseVersion parses the only argument of the command as a version.
+func parseVersion(cmd string, a []string) (int64, error) {
+	if len(a) != 1 {
+		return 0, fmt.Errorf("migrations: %s takes one argument", cmd)
+	}
+	version, err := strconv.ParseInt(a[0], 10, 64)
+	if err != nil || version < 0 {
+		return 0, fmt.Errorf("migrations: invalid version %q", a[0])
+	}
+	return version, nil
+}
@@ -0,0 +1,110 @@
+package migrations
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// DiscoverSQLMigrations adds migrations from the SQL files in the dir.
+// Files are named like 1_initial.up.sql and 1_initial.down.sql.
+// Migrations from files named like 1_initial.tx.up.sql run in
+// a transaction. Other files are ignored.
+func (c *Collection) DiscoverSQLMigrations(dir string) error {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	byVersion := make(map[int64]*Migration)
+	var ms []*Migration
+	for _, f := range files {
+		if f.IsDir() {
+			continue
+		}
+
+		fileName := f.Name()
+		var up bool
+		switch {
+		case strings.HasSuffix(fileName, ".up.sql"):
+			up = true
+		case strings.HasSuffix(fileName, ".down.sql"):
+		default:
+			continue
+		}
+
+		version, name, err := parseFileName(fileName)
+		if err != nil {
+			return err
+		}
+		name = strings.TrimSuffix(name, ".sql")
+		name = strings.TrimSuffix(strings.TrimSuffix(name, ".up"), ".down")
+		tx := strings.HasSuffix(name, ".tx")
+		name = strings.TrimSuffix(name, ".tx")
+
+		b, err := ioutil.ReadFile(filepath.Join(dir, fileName))
+		if err != nil {
+			return err
+		}
+		fn := sqlMigration(string(b))
+
+		m := byVersion[version]
+		if m == nil {
+			m = &Migration{
+				Version: version,
+				Name:    name,
+			}
+			byVersion[version] = m
+			ms = append(ms, m)
+		}
+		if m.Name != name {
+			return fmt.Errorf("migrations: files of version %d have different names: %q and %q",
+				version, m.Name, name)
+		}
+		if up {
+			m.Up = fn
+		} else {
+			m.Down = fn
+		}
+		m.Tx = m.Tx || tx
+	}
+
+	c.Add(ms...)
+	return nil
+}
+
+// WriteSQLMigration writes the up and down statements to the files
+// version_name.up.sql and version_name.down.sql in the dir, e.g. the
+// statements of orm.SchemaDiff. The files can be loaded with
+// DiscoverSQLMigrations.
+func WriteSQLMigration(dir string, version int64, name string, up, down []string) error {
+	if version <= 0 {
+		return fmt.Errorf("migrations: version must be positive, got %d", version)
+	}
+	base := filepath.Join(dir, strconv.FormatInt(version, 10)+"_"+name)
+	if err := writeSQLFile(base+".up.sql", up); err != nil {
+		return err
+	}
+	return writeSQLFile(base+".down.sql", down)
+}
+
+func writeSQLFile(file string, stmts []string) error {
+	var b strings.Builder
+	for _, stmt := range stmts {
+		b.WriteString(stmt)
+		b.WriteString(";\n")
+	}
+	return ioutil.WriteFile(file, []byte(b.String()), 0644)
+}
+
+// sqlMigration returns the migration that runs the SQL file. The file is
+// passed without params, so it is sent using the simple query protocol
+// even when Options.StatementCacheSize is set.
+func sqlMigration(query string) func(DB) error {
+	return func(db DB) error {
+		_, err := db.Exec(query)
+		return err
+	}
+}
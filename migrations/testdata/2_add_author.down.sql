@@ -0,0 +1 @@
+ALTER TABLE migrations_test_books DROP COLUMN author;
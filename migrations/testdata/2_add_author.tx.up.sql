@@ -0,0 +1,2 @@
+ALTER TABLE migrations_test_books ADD COLUMN author text;
+CREATE INDEX ON migrations_test_books (author);
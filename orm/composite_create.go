@@ -36,6 +36,10 @@ func (q *createCompositeQuery) Query() *Query {
 	return q.q
 }
 
+func (q *createCompositeQuery) Operation() QueryOp {
+	return CreateCompositeOp
+}
+
 func (q *createCompositeQuery) AppendTemplate(b []byte) ([]byte, error) {
 	return q.AppendQuery(dummyFormatter{}, b)
 }
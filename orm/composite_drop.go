@@ -33,6 +33,10 @@ func (q *dropCompositeQuery) Query() *Query {
 	return q.q
 }
 
+func (q *dropCompositeQuery) Operation() QueryOp {
+	return DropCompositeOp
+}
+
 func (q *dropCompositeQuery) AppendTemplate(b []byte) ([]byte, error) {
 	return q.AppendQuery(dummyFormatter{}, b)
 }
@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	"github.com/go-pg/pg/v9/internal"
+	"github.com/go-pg/pg/v9/pgerrcode"
 )
 
 // Placeholder that is replaced with count(*).
@@ -66,11 +67,10 @@ func (q *Query) CountEstimate(threshold int) (int, error) {
 			string(query), threshold,
 		)
 		if err != nil {
-			if pgerr, ok := err.(internal.PGError); ok && pgerr.Field('C') == "42883" {
-				// undefined_function
+			if pgerr, ok := internal.AsPGError(err); ok && pgerr.Code() == pgerrcode.UndefinedFunction {
 				err = q.createCountEstimateFunc()
 				if err != nil {
-					pgerr, ok := err.(internal.PGError)
+					pgerr, ok := internal.AsPGError(err)
 					if !ok || !pgerr.IntegrityViolation() {
 						return 0, err
 					}
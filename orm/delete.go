@@ -47,6 +47,10 @@ func (q *deleteQuery) Query() *Query {
 	return q.q
 }
 
+func (q *deleteQuery) Operation() QueryOp {
+	return DeleteOp
+}
+
 func (q *deleteQuery) AppendTemplate(b []byte) ([]byte, error) {
 	cp := q.Clone().(*deleteQuery)
 	cp.placeholder = true
@@ -15,6 +15,8 @@ const (
 	UseZeroFlag
 	UniqueFlag
 	ArrayFlag
+	IdentityFlag
+	serverParamFlag
 )
 
 type Field struct {
@@ -30,11 +32,14 @@ type Field struct {
 	Default     types.Safe
 	OnDelete    string
 	OnUpdate    string
+	Check       string // CHECK constraint expression
+	Generated   string // GENERATED ALWAYS AS expression
 
 	flags uint8
 
-	append types.AppenderFunc
-	scan   types.ScannerFunc
+	append     types.AppenderFunc
+	scan       types.ScannerFunc
+	scanBinary types.BinaryScannerFunc
 
 	isZero zerochecker.Func
 }
@@ -86,6 +91,13 @@ func (f *Field) hasZeroField(v reflect.Value, index []int) bool {
 	return f.isZero(v)
 }
 
+// generated reports whether the value of the column is generated by
+// PostgreSQL. Identity columns are reported only when identity is true,
+// because their values can be inserted explicitly.
+func (f *Field) generated(identity bool) bool {
+	return f.Generated != "" || identity && f.hasFlag(IdentityFlag)
+}
+
 func (f *Field) NullZero() bool {
 	return !f.hasFlag(UseZeroFlag)
 }
@@ -101,6 +113,40 @@ func (f *Field) AppendValue(b []byte, strct reflect.Value, quote int) []byte {
 	return f.append(b, fv, quote)
 }
 
+// serverParamValue returns the value of the field that can be sent
+// to the server as a param. Values that are NULL or formatted with
+// a custom appender, e.g. arrays or JSON, are appended by the client.
+func (f *Field) serverParamValue(strct reflect.Value) (interface{}, bool) {
+	if !f.hasFlag(serverParamFlag) {
+		return nil, false
+	}
+	fv := f.Value(strct)
+	if f.NullZero() && f.isZero(fv) || !fv.CanInterface() {
+		return nil, false
+	}
+	if fv.Kind() == reflect.Ptr {
+		if fv.IsNil() {
+			return nil, false
+		}
+		fv = fv.Elem()
+	}
+	if fv.Kind() == reflect.Uint64 && f.SQLType == pgTypeBigint {
+		return int64(fv.Uint()), true
+	}
+	return fv.Interface(), true
+}
+
+// appendFieldValue appends the value of the field. The value is replaced
+// with a $n placeholder when the formatter sends params to the server.
+func appendFieldValue(fmter QueryFormatter, b []byte, f *Field, strct reflect.Value) []byte {
+	if fmter, ok := fmter.(*Formatter); ok && fmter.args != nil {
+		if v, ok := f.serverParamValue(strct); ok {
+			return fmter.appendServerParam(b, v)
+		}
+	}
+	return f.AppendValue(b, strct, 1)
+}
+
 func (f *Field) ScanValue(strct reflect.Value, rd types.Reader, n int) error {
 	fv := fieldByIndex(strct, f.Index)
 	if f.scan == nil {
@@ -109,6 +155,14 @@ func (f *Field) ScanValue(strct reflect.Value, rd types.Reader, n int) error {
 	return f.scan(fv, rd, n)
 }
 
+func (f *Field) ScanBinaryValue(strct reflect.Value, oid uint32, rd types.Reader, n int) error {
+	fv := fieldByIndex(strct, f.Index)
+	if f.scanBinary == nil {
+		return fmt.Errorf("pg: ScanBinaryValue(unsupported %s)", fv.Type())
+	}
+	return f.scanBinary(fv, oid, rd, n)
+}
+
 type Method struct {
 	Index int
 
@@ -6,6 +6,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/go-pg/pg/v9/internal"
 	"github.com/go-pg/pg/v9/internal/parser"
@@ -125,6 +126,9 @@ func isPlaceholderFormatter(fmter QueryFormatter) bool {
 	if fmter == nil {
 		return false
 	}
+	if _, ok := fmter.(*Formatter); ok {
+		return false
+	}
 	b := fmter.FormatQuery(nil, "?", 0)
 	return bytes.Equal(b, []byte("?"))
 }
@@ -138,6 +142,7 @@ type QueryFormatter interface {
 type Formatter struct {
 	namedParams map[string]interface{}
 	model       TableModel
+	args        *[]interface{}
 }
 
 var _ QueryFormatter = (*Formatter)(nil)
@@ -171,6 +176,7 @@ func (f *Formatter) clone() *Formatter {
 	cp := NewFormatter()
 
 	cp.model = f.model
+	cp.args = f.args
 	if len(f.namedParams) > 0 {
 		cp.namedParams = make(map[string]interface{}, len(f.namedParams))
 	}
@@ -213,6 +219,17 @@ func (f *Formatter) WithParam(param string, value interface{}) *Formatter {
 	return cp
 }
 
+// WithServerParams returns a copy of the formatter that replaces params
+// with $1, $2, ... placeholders and appends the param values to args so
+// they can be sent to the server separately from the query.
+// Only scalar values, e.g. strings, numbers and time.Time, are replaced.
+// Other params, e.g. Ident, Safe or In, are formatted as usual.
+func (f *Formatter) WithServerParams(args *[]interface{}) *Formatter {
+	cp := f.clone()
+	cp.args = args
+	return cp
+}
+
 func (f *Formatter) Param(param string) interface{} {
 	return f.namedParams[param]
 }
@@ -325,6 +342,29 @@ func (f *Formatter) appendParam(b []byte, param interface{}) []byte {
 		}
 		return bb
 	default:
+		if f.args != nil && isServerParam(param) {
+			return f.appendServerParam(b, param)
+		}
 		return types.Append(b, param, 1)
 	}
 }
+
+// appendServerParam appends the $n placeholder of the value to b
+// and the value to the server params.
+func (f *Formatter) appendServerParam(b []byte, v interface{}) []byte {
+	*f.args = append(*f.args, v)
+	b = append(b, '$')
+	return strconv.AppendInt(b, int64(len(*f.args)), 10)
+}
+
+func isServerParam(param interface{}) bool {
+	switch param.(type) {
+	case string, []byte, bool, time.Time,
+		int, int8, int16, int32, int64,
+		uint, uint8, uint16, uint32, uint64,
+		float32, float64:
+		return true
+	default:
+		return false
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"math"
+	"reflect"
 	"testing"
 
 	"github.com/go-pg/pg/v9/orm"
@@ -162,6 +163,23 @@ func TestFormatQuery(t *testing.T) {
 	}
 }
 
+func TestFormatQueryWithServerParams(t *testing.T) {
+	var args []interface{}
+	f := orm.NewFormatter().WithParam("name", "joe").WithServerParams(&args)
+
+	got := f.FormatQuery(nil, "? = ? AND id IN (?) AND name = ?name AND ?0 = ?",
+		types.Ident("id"), 1, types.In([]int{2, 3}), true)
+	wanted := `"id" = $1 AND id IN (2,3) AND name = $2 AND "id" = $3`
+	if string(got) != wanted {
+		t.Fatalf("got %q, wanted %q", got, wanted)
+	}
+
+	wantedArgs := []interface{}{1, "joe", true}
+	if !reflect.DeepEqual(args, wantedArgs) {
+		t.Fatalf("got %v, wanted %v", args, wantedArgs)
+	}
+}
+
 func BenchmarkFormatQueryWithoutParams(b *testing.B) {
 	var f orm.Formatter
 	for i := 0; i < b.N; i++ {
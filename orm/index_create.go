@@ -0,0 +1,155 @@
+package orm
+
+import (
+	"strings"
+
+	"github.com/go-pg/pg/v9/types"
+)
+
+// Index is an index of the table defined with the struct tags. Field tag
+// `pg:",index"` creates an index on the column and fields with the same
+// `pg:"index:name"` tag create a multi-column index. Other indexes are
+// defined with blank fields, e.g.
+//
+//    _ struct{} `pg:"index:books_title_idx,using:gin,on:'to_tsvector(\\'english\\', title)',where:'deleted_at IS NULL'"`
+//
+// Blank fields support the following options:
+//   - on:'expr' - columns and expressions of the index.
+//   - using:method - index method, e.g. btree, hash, gist, gin or brin.
+//   - include:'columns' - columns of a covering index.
+//   - where:'predicate' - creates a partial index.
+//   - unique - creates a unique index.
+//   - concurrently - creates the index without locking out writes.
+type Index struct {
+	Name         string
+	Unique       bool
+	Using        string
+	Columns      []string // quoted columns or expressions
+	Include      string
+	Where        string
+	Concurrently bool
+}
+
+type CreateIndexOptions struct {
+	IfNotExists bool
+}
+
+// CreateIndexes creates the indexes of the model defined with the struct tags.
+// Every index is created with a separate query, so indexes marked with
+// concurrently are created outside of a transaction unless db is a Tx.
+func CreateIndexes(db DB, model interface{}, opt *CreateIndexOptions) error {
+	return NewQuery(db, model).CreateIndexes(opt)
+}
+
+type createIndexQuery struct {
+	q     *Query
+	index *Index
+	opt   *CreateIndexOptions
+
+	concurrently bool
+}
+
+var _ QueryAppender = (*createIndexQuery)(nil)
+var _ queryCommand = (*createIndexQuery)(nil)
+
+func newCreateIndexQuery(q *Query, index *Index, opt *CreateIndexOptions) *createIndexQuery {
+	return &createIndexQuery{
+		q:     q,
+		index: index,
+		opt:   opt,
+
+		concurrently: index.Concurrently,
+	}
+}
+
+func (q *createIndexQuery) Clone() queryCommand {
+	return &createIndexQuery{
+		q:     q.q.Clone(),
+		index: q.index,
+		opt:   q.opt,
+
+		concurrently: q.concurrently,
+	}
+}
+
+func (q *createIndexQuery) Query() *Query {
+	return q.q
+}
+
+func (q *createIndexQuery) Operation() QueryOp {
+	return CreateIndexOp
+}
+
+func (q *createIndexQuery) AppendTemplate(b []byte) ([]byte, error) {
+	return q.AppendQuery(dummyFormatter{}, b)
+}
+
+func (q *createIndexQuery) AppendQuery(fmter QueryFormatter, b []byte) (_ []byte, err error) {
+	if q.q.stickyErr != nil {
+		return nil, q.q.stickyErr
+	}
+	if q.q.model == nil {
+		return nil, errModelNil
+	}
+
+	index := q.index
+
+	b = append(b, "CREATE "...)
+	if index.Unique {
+		b = append(b, "UNIQUE "...)
+	}
+	b = append(b, "INDEX "...)
+	if q.concurrently {
+		b = append(b, "CONCURRENTLY "...)
+	}
+	if q.opt != nil && q.opt.IfNotExists {
+		b = append(b, "IF NOT EXISTS "...)
+	}
+	b = types.AppendIdent(b, index.Name, 1)
+	b = append(b, " ON "...)
+	b, err = q.q.appendFirstTable(fmter, b)
+	if err != nil {
+		return nil, err
+	}
+	if index.Using != "" {
+		b = append(b, " USING "...)
+		b = append(b, index.Using...)
+	}
+
+	b = append(b, " ("...)
+	b = append(b, strings.Join(index.Columns, ", ")...)
+	b = append(b, ")"...)
+
+	if index.Include != "" {
+		b = append(b, " INCLUDE ("...)
+		b = append(b, index.Include...)
+		b = append(b, ")"...)
+	}
+	if index.Where != "" {
+		b = append(b, " WHERE "...)
+		b = append(b, index.Where...)
+	}
+
+	return b, q.q.stickyErr
+}
+
+//------------------------------------------------------------------------------
+
+// defaultIndexName returns the name of the index like the one PostgreSQL
+// chooses, e.g. books_author_id_title_idx.
+func defaultIndexName(table string, columns []string) string {
+	var b strings.Builder
+	b.WriteString(table)
+	for _, col := range columns {
+		for _, part := range strings.FieldsFunc(col, isNotIdentChar) {
+			b.WriteByte('_')
+			b.WriteString(strings.ToLower(part))
+		}
+	}
+	b.WriteString("_idx")
+	return b.String()
+}
+
+func isNotIdentChar(c rune) bool {
+	return !(c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9')
+}
@@ -0,0 +1,71 @@
+package orm
+
+import (
+	"reflect"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type CreateIndexModel struct {
+	_ struct{} `pg:"index:create_index_models_title_idx,using:gin,on:'to_tsvector(\\'english\\', title)',concurrently"`
+	_ struct{} `pg:"index:create_index_models_email_idx,unique,on:'lower(email)',include:'title',where:'deleted_at IS NULL'"`
+
+	ID        int
+	Title     string
+	Email     string
+	AuthorID  int      `pg:",index"`
+	Tags      []string `pg:",array,index,using:gin"`
+	AccountID int      `pg:"index:'account_created,account_title'"`
+	CreatedAt int      `pg:"index:account_created,using:brin"`
+	DeletedAt int      `pg:"index:account_title"`
+}
+
+var _ = Describe("CreateIndex", func() {
+	It("parses index tags", func() {
+		table := GetTable(reflect.TypeOf(CreateIndexModel{}))
+
+		var names []string
+		for _, index := range table.Indexes {
+			names = append(names, index.Name)
+		}
+		Expect(names).To(Equal([]string{
+			"create_index_models_title_idx",
+			"create_index_models_email_idx",
+			"create_index_models_author_id_idx",
+			"create_index_models_tags_idx",
+			"account_created",
+			"account_title",
+		}))
+	})
+
+	It("creates indexes", func() {
+		q := NewQuery(nil, &CreateIndexModel{})
+
+		var ss []string
+		for _, index := range q.model.Table().Indexes {
+			ss = append(ss, queryString(newCreateIndexQuery(q, index, nil)))
+		}
+		Expect(ss).To(Equal([]string{
+			`CREATE INDEX CONCURRENTLY "create_index_models_title_idx" ON "create_index_models" USING gin (to_tsvector('english', title))`,
+			`CREATE UNIQUE INDEX "create_index_models_email_idx" ON "create_index_models" (lower(email)) INCLUDE (title) WHERE deleted_at IS NULL`,
+			`CREATE INDEX "create_index_models_author_id_idx" ON "create_index_models" ("author_id")`,
+			`CREATE INDEX "create_index_models_tags_idx" ON "create_index_models" USING gin ("tags")`,
+			`CREATE INDEX "account_created" ON "create_index_models" USING brin ("account_id", "created_at")`,
+			`CREATE INDEX "account_title" ON "create_index_models" ("account_id", "deleted_at")`,
+		}))
+	})
+
+	It("creates index if not exists", func() {
+		q := NewQuery(nil, &CreateIndexModel{})
+		index := q.model.Table().Indexes[2]
+
+		s := queryString(newCreateIndexQuery(q, index, &CreateIndexOptions{IfNotExists: true}))
+		Expect(s).To(Equal(`CREATE INDEX IF NOT EXISTS "create_index_models_author_id_idx" ON "create_index_models" ("author_id")`))
+	})
+
+	It("names expression indexes", func() {
+		Expect(defaultIndexName("books", []string{"lower(email), created_at DESC"})).
+			To(Equal("books_lower_email_created_at_desc_idx"))
+	})
+})
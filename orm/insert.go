@@ -38,6 +38,10 @@ func (q *insertQuery) Query() *Query {
 	return q.q
 }
 
+func (q *insertQuery) Operation() QueryOp {
+	return InsertOp
+}
+
 func (q *insertQuery) AppendTemplate(b []byte) ([]byte, error) {
 	cp := q.Clone().(*insertQuery)
 	cp.placeholder = true
@@ -93,6 +97,10 @@ func (q *insertQuery) AppendQuery(fmter QueryFormatter, b []byte) (_ []byte, err
 		if len(fields) == 0 {
 			fields = q.q.model.Table().Fields
 		}
+		fields, generated := omitGeneratedFields(fields, false)
+		for _, f := range generated {
+			q.addReturningField(f)
+		}
 		value := q.q.model.Value()
 
 		b = append(b, " ("...)
@@ -138,6 +146,7 @@ func (q *insertQuery) AppendQuery(fmter QueryFormatter, b []byte) (_ []byte, err
 				if len(fields) == 0 {
 					fields = q.q.model.Table().DataFields
 				}
+				fields, _ = omitGeneratedFields(fields, true)
 
 				b = q.appendSetExcluded(b, fields)
 			}
@@ -185,11 +194,11 @@ func (q *insertQuery) appendValues(
 		switch {
 		case q.placeholder:
 			b = append(b, '?')
-		case (f.Default != "" || f.NullZero()) && f.HasZeroValue(strct):
+		case (f.Default != "" || f.NullZero() || f.hasFlag(IdentityFlag)) && f.HasZeroValue(strct):
 			b = append(b, "DEFAULT"...)
 			q.addReturningField(f)
 		default:
-			b = f.AppendValue(b, strct, 1)
+			b = appendFieldValue(fmter, b, f, strct)
 		}
 	}
 
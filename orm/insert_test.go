@@ -42,6 +42,21 @@ type InsertDefaultTest struct {
 	Value string `pg:"default:hello"`
 }
 
+type InsertGeneratedTest struct {
+	ID       int `pg:",identity"`
+	Price    int
+	Quantity int
+	Total    int `pg:"generated:'price * quantity'"`
+}
+
+type InsertServerParamsTest struct {
+	Id    int
+	Name  string
+	Count *int
+	Tags  []string `pg:",array"`
+	Big   uint64
+}
+
 type InsertQTest struct {
 	Geo  types.Safe
 	Func types.ValueAppender
@@ -264,6 +279,44 @@ var _ = Describe("Insert", func() {
 		s := insertQueryString(q)
 		Expect(s).To(Equal(`INSERT INTO "uint_models" ("id", "other_id") VALUES (1, 2), (2, DEFAULT) RETURNING "other_id"`))
 	})
+
+	It("omits generated columns and returns them", func() {
+		q := NewQuery(nil, &InsertGeneratedTest{Price: 2, Quantity: 3})
+
+		s := insertQueryString(q)
+		Expect(s).To(Equal(`INSERT INTO "insert_generated_tests" ("id", "price", "quantity") VALUES (DEFAULT, 2, 3) RETURNING "total", "id"`))
+	})
+
+	It("omits generated columns when other columns are excluded", func() {
+		q := NewQuery(nil, &InsertGeneratedTest{ID: 1, Price: 2}).ExcludeColumn("quantity")
+
+		s := insertQueryString(q)
+		Expect(s).To(Equal(`INSERT INTO "insert_generated_tests" ("id", "price") VALUES (1, 2) RETURNING "total"`))
+	})
+
+	It("omits generated and identity columns on conflict", func() {
+		q := NewQuery(nil, &InsertGeneratedTest{ID: 1, Price: 2, Quantity: 3}).
+			OnConflict("(id) DO UPDATE")
+
+		s := insertQueryString(q)
+		Expect(s).To(Equal(`INSERT INTO "insert_generated_tests" AS "insert_generated_test" ("id", "price", "quantity") VALUES (1, 2, 3) ON CONFLICT (id) DO UPDATE SET "price" = EXCLUDED."price", "quantity" = EXCLUDED."quantity" RETURNING "total"`))
+	})
+
+	It("sends model values as server params", func() {
+		q := NewQuery(nil, &InsertServerParamsTest{
+			Id:   1,
+			Name: "it's",
+			Tags: []string{"a"},
+			Big:  2,
+		})
+
+		var args []interface{}
+		ins := newInsertQuery(q)
+		b, err := ins.AppendQuery(NewFormatter().WithModel(ins).WithServerParams(&args), nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(b)).To(Equal(`INSERT INTO "insert_server_params_tests" ("id", "name", "count", "tags", "big") VALUES ($1, $2, DEFAULT, '{"a"}', $3) RETURNING "count"`))
+		Expect(args).To(Equal([]interface{}{1, "it's", int64(2)}))
+	})
 })
 
 func insertQueryString(q *Query) string {
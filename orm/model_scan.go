@@ -37,6 +37,16 @@ func (m scanValuesModel) ScanColumn(colIdx int, colName string, rd types.Reader,
 	return types.Scan(m.values[colIdx], rd, n)
 }
 
+func (m scanValuesModel) ScanBinaryColumn(
+	colIdx int, colName string, oid uint32, rd types.Reader, n int,
+) error {
+	if colIdx >= len(m.values) {
+		return fmt.Errorf("pg: no Scan var for column index=%d name=%q",
+			colIdx, colName)
+	}
+	return types.ScanBinary(m.values[colIdx], oid, rd, n)
+}
+
 //------------------------------------------------------------------------------
 
 type scanReflectValuesModel struct {
@@ -67,3 +77,13 @@ func (m scanReflectValuesModel) ScanColumn(colIdx int, colName string, rd types.
 	}
 	return types.ScanValue(m.values[colIdx], rd, n)
 }
+
+func (m scanReflectValuesModel) ScanBinaryColumn(
+	colIdx int, colName string, oid uint32, rd types.Reader, n int,
+) error {
+	if colIdx >= len(m.values) {
+		return fmt.Errorf("pg: no Scan var for column index=%d name=%q",
+			colIdx, colName)
+	}
+	return types.ScanBinaryValue(m.values[colIdx], oid, rd, n)
+}
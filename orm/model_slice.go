@@ -9,17 +9,20 @@ import (
 
 type sliceModel struct {
 	Discard
-	slice    reflect.Value
-	nextElem func() reflect.Value
-	scan     func(reflect.Value, types.Reader, int) error
+	slice      reflect.Value
+	nextElem   func() reflect.Value
+	scan       func(reflect.Value, types.Reader, int) error
+	scanBinary types.BinaryScannerFunc
 }
 
 var _ Model = (*sliceModel)(nil)
 
 func newSliceModel(slice reflect.Value, elemType reflect.Type) *sliceModel {
+	scan := types.Scanner(elemType)
 	return &sliceModel{
-		slice: slice,
-		scan:  types.Scanner(elemType),
+		slice:      slice,
+		scan:       scan,
+		scanBinary: types.BinaryScanner(elemType, scan),
 	}
 }
 
@@ -41,3 +44,13 @@ func (m *sliceModel) ScanColumn(colIdx int, _ string, rd types.Reader, n int) er
 	v := m.nextElem()
 	return m.scan(v, rd, n)
 }
+
+func (m *sliceModel) ScanBinaryColumn(
+	colIdx int, _ string, oid uint32, rd types.Reader, n int,
+) error {
+	if m.nextElem == nil {
+		m.nextElem = internal.MakeSliceNextElemFunc(m.slice)
+	}
+	v := m.nextElem()
+	return m.scanBinary(v, oid, rd, n)
+}
@@ -29,6 +29,7 @@ type TableModel interface {
 
 	setSoftDeleteField()
 	scanColumn(int, string, types.Reader, int) (bool, error)
+	scanBinaryColumn(int, string, uint32, types.Reader, int) (bool, error)
 }
 
 func newTableModel(value interface{}) (TableModel, error) {
@@ -104,3 +104,25 @@ func (m *m2mModel) ScanColumn(colIdx int, colName string, rd types.Reader, n int
 	m.columns[colName] = string(tmp)
 	return nil
 }
+
+func (m *m2mModel) ScanBinaryColumn(
+	colIdx int, colName string, oid uint32, rd types.Reader, n int,
+) error {
+	ok, err := m.sliceTableModel.scanBinaryColumn(colIdx, colName, oid, rd, n)
+	if ok {
+		return err
+	}
+
+	tmp, err := rd.ReadFullTemp()
+	if err != nil {
+		return err
+	}
+
+	text, err := types.AppendBinaryText(nil, oid, tmp)
+	if err != nil {
+		return err
+	}
+
+	m.columns[colName] = string(text)
+	return nil
+}
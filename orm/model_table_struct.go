@@ -264,6 +264,54 @@ func (m *structTableModel) scanColumn(
 	return true, field.ScanValue(m.strct, rd, n)
 }
 
+func (m *structTableModel) ScanBinaryColumn(
+	colIdx int, colName string, oid uint32, rd types.Reader, n int,
+) error {
+	ok, err := m.scanBinaryColumn(colIdx, colName, oid, rd, n)
+	if ok {
+		return err
+	}
+	if m.table.hasFlag(discardUnknownColumnsFlag) {
+		return nil
+	}
+	return fmt.Errorf("pg: can't find column=%s in %s (try discard_unknown_columns)",
+		colName, m.table)
+}
+
+func (m *structTableModel) scanBinaryColumn(
+	colIdx int, colName string, oid uint32, rd types.Reader, n int,
+) (bool, error) {
+	// Don't init nil struct when value is NULL.
+	if n == -1 &&
+		!m.structInited &&
+		m.strct.Kind() == reflect.Ptr &&
+		m.strct.IsNil() {
+		return true, nil
+	}
+
+	err := m.initStruct()
+	if err != nil {
+		return true, err
+	}
+
+	joinName, fieldName := splitColumn(colName)
+	if joinName != "" {
+		if join := m.GetJoin(joinName); join != nil {
+			return join.JoinModel.scanBinaryColumn(colIdx, fieldName, oid, rd, n)
+		}
+		if m.table.ModelName == joinName {
+			return m.scanBinaryColumn(colIdx, fieldName, oid, rd, n)
+		}
+	}
+
+	field, ok := m.table.FieldsMap[colName]
+	if !ok {
+		return false, nil
+	}
+
+	return true, field.ScanBinaryValue(m.strct, oid, rd, n)
+}
+
 func (m *structTableModel) GetJoin(name string) *join {
 	for i := range m.joins {
 		j := &m.joins[i]
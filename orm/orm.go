@@ -16,6 +16,13 @@ type ColumnScanner interface {
 	ScanColumn(colIdx int, colName string, rd types.Reader, n int) error
 }
 
+// BinaryColumnScanner is implemented by column scanners that can scan
+// column values received in binary format without converting them
+// to the text format first.
+type BinaryColumnScanner interface {
+	ScanBinaryColumn(colIdx int, colName string, oid uint32, rd types.Reader, n int) error
+}
+
 type QueryAppender interface {
 	AppendQuery(fmter QueryFormatter, b []byte) ([]byte, error)
 }
@@ -24,11 +31,28 @@ type TemplateAppender interface {
 	AppendTemplate(b []byte) ([]byte, error)
 }
 
+// QueryOp is the SQL operation of a query built with Query,
+// e.g. SelectOp for Query.Select.
+type QueryOp string
+
+const (
+	SelectOp          QueryOp = "SELECT"
+	InsertOp          QueryOp = "INSERT"
+	UpdateOp          QueryOp = "UPDATE"
+	DeleteOp          QueryOp = "DELETE"
+	CreateTableOp     QueryOp = "CREATE TABLE"
+	DropTableOp       QueryOp = "DROP TABLE"
+	CreateIndexOp     QueryOp = "CREATE INDEX"
+	CreateCompositeOp QueryOp = "CREATE COMPOSITE"
+	DropCompositeOp   QueryOp = "DROP COMPOSITE"
+)
+
 type queryCommand interface {
 	QueryAppender
 	TemplateAppender
 	Clone() queryCommand
 	Query() *Query
+	Operation() QueryOp
 }
 
 // DB is a common interface for pg.DB and pg.Tx types.
@@ -0,0 +1,275 @@
+package orm
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-pg/pg/v9/internal"
+	"github.com/go-pg/pg/v9/types"
+)
+
+// RangeBound returns the bound of a RANGE partition that contains values
+// from from (inclusive) to to (exclusive). Use types.Safe("MINVALUE") or
+// types.Safe("MAXVALUE") for an unbounded range.
+func RangeBound(from, to interface{}) *SafeQueryAppender {
+	return SafeQuery("FOR VALUES FROM (?) TO (?)", boundValue(from), boundValue(to))
+}
+
+// ListBound returns the bound of a LIST partition that contains the values.
+func ListBound(values ...interface{}) *SafeQueryAppender {
+	return SafeQuery("FOR VALUES IN (?)", boundValue(types.In(values)))
+}
+
+// HashBound returns the bound of a HASH partition.
+func HashBound(modulus, remainder int) *SafeQueryAppender {
+	return SafeQuery("FOR VALUES WITH (MODULUS ?, REMAINDER ?)",
+		boundValue(modulus), boundValue(remainder))
+}
+
+// DefaultBound returns the bound of the default partition that contains
+// values that don't fit other partitions.
+func DefaultBound() *SafeQueryAppender {
+	return SafeQuery("DEFAULT")
+}
+
+// Partition is a partition of the partitioned table.
+type Partition struct {
+	Name  string
+	Bound string
+}
+
+type CreatePartitionOptions struct {
+	IfNotExists bool
+}
+
+// CreatePartition creates the partition of the table partitioned with
+// `pg:"partitionBy:RANGE (column)"` tag. PostgreSQL routes inserted rows
+// into the partitions, so models are inserted into the partitioned table.
+//
+//    err := orm.CreatePartition(db, (*Event)(nil), "events_2020_01",
+//        orm.RangeBound("2020-01-01", "2020-02-01"), nil)
+func CreatePartition(
+	db DB, model interface{}, name string, bound *SafeQueryAppender, opt *CreatePartitionOptions,
+) error {
+	table, err := partitionedTable(db, model)
+	if err != nil {
+		return err
+	}
+
+	query := "CREATE TABLE ? PARTITION OF ? ?"
+	if opt != nil && opt.IfNotExists {
+		query = "CREATE TABLE IF NOT EXISTS ? PARTITION OF ? ?"
+	}
+	_, err = db.Exec(query, partitionName(name), table.FullName, bound)
+	return err
+}
+
+// AttachPartition attaches the existing table as a partition.
+func AttachPartition(db DB, model interface{}, name string, bound *SafeQueryAppender) error {
+	table, err := partitionedTable(db, model)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec("ALTER TABLE ? ATTACH PARTITION ? ?", table.FullName, partitionName(name), bound)
+	return err
+}
+
+type DetachPartitionOptions struct {
+	// Concurrently detaches the partition without blocking queries.
+	// It requires PostgreSQL 14 and can't be used in a transaction.
+	Concurrently bool
+}
+
+// DetachPartition detaches the partition. The partition becomes
+// a standalone table.
+func DetachPartition(db DB, model interface{}, name string, opt *DetachPartitionOptions) error {
+	table, err := partitionedTable(db, model)
+	if err != nil {
+		return err
+	}
+
+	query := "ALTER TABLE ? DETACH PARTITION ?"
+	if opt != nil && opt.Concurrently {
+		query += " CONCURRENTLY"
+	}
+	_, err = db.Exec(query, table.FullName, partitionName(name))
+	return err
+}
+
+// Partitions returns the partitions of the table ordered by name.
+func Partitions(db DB, model interface{}) ([]Partition, error) {
+	table, err := partitionedTable(db, model)
+	if err != nil {
+		return nil, err
+	}
+
+	var partitions []Partition
+	_, err = db.Query(&partitions, `
+		SELECT c.oid::regclass::text AS name, pg_get_expr(c.relpartbound, c.oid) AS bound
+		FROM pg_inherits i
+		JOIN pg_class c ON c.oid = i.inhrelid
+		WHERE i.inhparent = to_regclass(?)::oid
+		ORDER BY name`, string(table.FullName))
+	if err != nil {
+		return nil, err
+	}
+	return partitions, nil
+}
+
+//------------------------------------------------------------------------------
+
+// PartitionPeriod is the time range of a partition.
+type PartitionPeriod string
+
+const (
+	PartitionDaily   PartitionPeriod = "day"
+	PartitionWeekly  PartitionPeriod = "week"
+	PartitionMonthly PartitionPeriod = "month"
+	PartitionYearly  PartitionPeriod = "year"
+)
+
+type TimePartitionOptions struct {
+	// Period of every partition. Default is PartitionMonthly.
+	Period PartitionPeriod
+	// Start is the time in the first partition. Default is the current
+	// time in UTC. Partitions start in the location of Start and weekly
+	// partitions start on Monday.
+	Start time.Time
+	// Count of partitions to create. Default is 1.
+	Count int
+}
+
+// CreateTimePartitions creates Count partitions of the table partitioned
+// by RANGE on a date or time column starting with the partition that
+// contains Start. Partitions are named after the table and the start of
+// the range, e.g. events_p202001 for monthly partitions of events.
+// Existing partitions are skipped, so it can run periodically to create
+// partitions ahead of time.
+func CreateTimePartitions(db DB, model interface{}, opt *TimePartitionOptions) error {
+	table, err := partitionedTable(db, model)
+	if err != nil {
+		return err
+	}
+
+	partitions, err := timePartitions(table, opt)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range partitions {
+		_, err := db.Exec("CREATE TABLE IF NOT EXISTS ? PARTITION OF ? ?",
+			types.Safe(p.name), table.FullName, RangeBound(p.from, p.to))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type timePartition struct {
+	name     string
+	from, to time.Time
+}
+
+func timePartitions(table *Table, opt *TimePartitionOptions) ([]timePartition, error) {
+	if !strings.HasPrefix(strings.ToUpper(table.PartitionBy), "RANGE") {
+		return nil, fmt.Errorf("pg: %s is not partitioned by RANGE", table)
+	}
+
+	period := PartitionMonthly
+	start := time.Now().UTC()
+	count := 1
+	if opt != nil {
+		if opt.Period != "" {
+			period = opt.Period
+		}
+		if !opt.Start.IsZero() {
+			start = opt.Start
+		}
+		if opt.Count > 0 {
+			count = opt.Count
+		}
+	}
+
+	y, m, d := start.Date()
+	loc := start.Location()
+
+	var from time.Time
+	var layout string
+	var next func(time.Time) time.Time
+	switch period {
+	case PartitionDaily:
+		from = time.Date(y, m, d, 0, 0, 0, 0, loc)
+		layout = "20060102"
+		next = func(tm time.Time) time.Time { return tm.AddDate(0, 0, 1) }
+	case PartitionWeekly:
+		from = time.Date(y, m, d, 0, 0, 0, 0, loc)
+		from = from.AddDate(0, 0, -((int(from.Weekday()) + 6) % 7))
+		layout = "20060102"
+		next = func(tm time.Time) time.Time { return tm.AddDate(0, 0, 7) }
+	case PartitionMonthly:
+		from = time.Date(y, m, 1, 0, 0, 0, 0, loc)
+		layout = "200601"
+		next = func(tm time.Time) time.Time { return tm.AddDate(0, 1, 0) }
+	case PartitionYearly:
+		from = time.Date(y, 1, 1, 0, 0, 0, 0, loc)
+		layout = "2006"
+		next = func(tm time.Time) time.Time { return tm.AddDate(1, 0, 0) }
+	default:
+		return nil, fmt.Errorf("pg: unsupported partition period: %q", period)
+	}
+
+	prefix := string(table.FullName)
+	if i := strings.LastIndexByte(prefix, '.'); i >= 0 {
+		prefix = prefix[:i+1]
+	} else {
+		prefix = ""
+	}
+	base := baseTableName(table.FullName)
+
+	partitions := make([]timePartition, count)
+	for i := range partitions {
+		to := next(from)
+		name := base + "_p" + from.Format(layout)
+		partitions[i] = timePartition{
+			name: prefix + string(quoteIdent(name)),
+			from: from,
+			to:   to,
+		}
+		from = to
+	}
+	return partitions, nil
+}
+
+func partitionedTable(db DB, model interface{}) (*Table, error) {
+	q := NewQuery(db, model)
+	if q.stickyErr != nil {
+		return nil, q.stickyErr
+	}
+	if q.model == nil {
+		return nil, errModelNil
+	}
+	return q.model.Table(), nil
+}
+
+// boundTimeFormat keeps the offset of the time, so date and timestamp
+// columns get the wall clock time in the location of the time.
+const boundTimeFormat = "2006-01-02 15:04:05.999999999-07:00:00"
+
+// boundValue formats the value of a partition bound on the client,
+// because partition bounds can't be sent as server params.
+func boundValue(v interface{}) types.Safe {
+	if tm, ok := v.(time.Time); ok {
+		b := []byte{'\''}
+		b = tm.AppendFormat(b, boundTimeFormat)
+		b = append(b, '\'')
+		return types.Safe(b)
+	}
+	return types.Safe(types.Append(nil, v, 1))
+}
+
+func partitionName(name string) types.Safe {
+	return types.Safe(internal.QuoteTableName(name))
+}
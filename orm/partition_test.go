@@ -0,0 +1,121 @@
+package orm
+
+import (
+	"reflect"
+	"time"
+
+	"github.com/go-pg/pg/v9/types"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type PartitionEvent struct {
+	tableName struct{} `pg:"app.partition_events,partitionBy:RANGE (created_at)"`
+
+	ID        int
+	CreatedAt time.Time
+}
+
+type ListPartitionEvent struct {
+	tableName struct{} `pg:"partitionBy:LIST (country)"`
+
+	Country string
+}
+
+var _ = Describe("Partition", func() {
+	It("formats bounds", func() {
+		fmter := NewFormatter()
+
+		b, err := RangeBound("2020-01-01", types.Safe("MAXVALUE")).AppendQuery(fmter, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(b)).To(Equal(`FOR VALUES FROM ('2020-01-01') TO (MAXVALUE)`))
+
+		b, err = ListBound("de", "fr").AppendQuery(fmter, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(b)).To(Equal(`FOR VALUES IN ('de','fr')`))
+
+		b, err = HashBound(4, 1).AppendQuery(fmter, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(b)).To(Equal(`FOR VALUES WITH (MODULUS 4, REMAINDER 1)`))
+
+		b, err = DefaultBound().AppendQuery(fmter, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(b)).To(Equal(`DEFAULT`))
+	})
+
+	It("formats bounds with server params", func() {
+		var args []interface{}
+		fmter := NewFormatter().WithServerParams(&args)
+
+		b, err := RangeBound(time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC), 1).AppendQuery(fmter, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(b)).To(Equal(`FOR VALUES FROM ('2020-01-01 00:00:00+00:00:00') TO (1)`))
+
+		b, err = ListBound("it's").AppendQuery(fmter, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(b)).To(Equal(`FOR VALUES IN ('it''s')`))
+
+		b, err = HashBound(4, 1).AppendQuery(fmter, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(b)).To(Equal(`FOR VALUES WITH (MODULUS 4, REMAINDER 1)`))
+
+		Expect(args).To(BeEmpty())
+	})
+
+	It("creates monthly time partitions", func() {
+		table := GetTable(reflect.TypeOf(PartitionEvent{}))
+		ps, err := timePartitions(table, &TimePartitionOptions{
+			Start: time.Date(2019, time.December, 15, 10, 0, 0, 0, time.UTC),
+			Count: 2,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ps).To(Equal([]timePartition{{
+			name: `app."partition_events_p201912"`,
+			from: time.Date(2019, time.December, 1, 0, 0, 0, 0, time.UTC),
+			to:   time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC),
+		}, {
+			name: `app."partition_events_p202001"`,
+			from: time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC),
+			to:   time.Date(2020, time.February, 1, 0, 0, 0, 0, time.UTC),
+		}}))
+	})
+
+	It("formats bounds of time partitions in the location of Start", func() {
+		table := GetTable(reflect.TypeOf(PartitionEvent{}))
+		loc := time.FixedZone("CET", 3600)
+		ps, err := timePartitions(table, &TimePartitionOptions{
+			Start: time.Date(2020, time.January, 15, 10, 0, 0, 0, loc),
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ps).To(HaveLen(1))
+		Expect(ps[0].name).To(Equal(`app."partition_events_p202001"`))
+
+		b, err := RangeBound(ps[0].from, ps[0].to).AppendQuery(NewFormatter(), nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(b)).To(Equal(
+			`FOR VALUES FROM ('2020-01-01 00:00:00+01:00:00') TO ('2020-02-01 00:00:00+01:00:00')`))
+	})
+
+	It("creates weekly time partitions starting on Monday", func() {
+		table := GetTable(reflect.TypeOf(PartitionEvent{}))
+		ps, err := timePartitions(table, &TimePartitionOptions{
+			Period: PartitionWeekly,
+			Start:  time.Date(2020, time.January, 5, 10, 0, 0, 0, time.UTC), // Sunday
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ps).To(HaveLen(1))
+		Expect(ps[0].name).To(Equal(`app."partition_events_p20191230"`))
+		Expect(ps[0].to).To(Equal(time.Date(2020, time.January, 6, 0, 0, 0, 0, time.UTC)))
+	})
+
+	It("returns an error for tables not partitioned by range", func() {
+		table := GetTable(reflect.TypeOf(ListPartitionEvent{}))
+		_, err := timePartitions(table, nil)
+		Expect(err).To(MatchError("pg: model=ListPartitionEvent is not partitioned by RANGE"))
+
+		table = GetTable(reflect.TypeOf(PartitionEvent{}))
+		_, err = timePartitions(table, &TimePartitionOptions{Period: "hour"})
+		Expect(err).To(MatchError(`pg: unsupported partition period: "hour"`))
+	})
+})
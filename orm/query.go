@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/go-pg/pg/v9/internal"
+	"github.com/go-pg/pg/v9/pgerrcode"
 	"github.com/go-pg/pg/v9/types"
 )
 
@@ -297,8 +298,8 @@ func (q *Query) Column(columns ...string) *Query {
 		//TODO: remove
 		if q.model != nil {
 			if j := q.model.Join(column, nil); j != nil {
-				internal.Logger.Printf("DEPRECATED: replace Column(%q) with Relation(%q)",
-					column, column)
+				internal.Warn("DEPRECATED: replace Column with Relation",
+					"column", column)
 				continue
 			}
 		}
@@ -951,6 +952,27 @@ func (q *Query) ForEach(fn interface{}) error {
 	return q.Select(m)
 }
 
+// rowsQuerier is implemented by pg.DB, pg.Tx and pg.Conn.
+type rowsQuerier interface {
+	QueryRowsContext(c context.Context, query interface{}, params ...interface{}) (Rows, error)
+}
+
+// Rows runs the select query and returns an iterator over the selected
+// rows. Unlike Select and ForEach, rows are read from the connection as
+// the iterator advances. Relations added with Relation are not supported.
+// It returns an error when the DB of the query can't iterate over rows,
+// e.g. in a pg.Batch.
+func (q *Query) Rows() (Rows, error) {
+	if q.stickyErr != nil {
+		return nil, q.stickyErr
+	}
+	db, ok := q.db.(rowsQuerier)
+	if !ok {
+		return nil, fmt.Errorf("pg: Rows is not supported by %T", q.db)
+	}
+	return db.QueryRowsContext(q.ctx, newSelectQuery(q), q.model)
+}
+
 func (q *Query) forEachHasOneJoin(fn func(*join) error) error {
 	if q.model == nil {
 		return nil
@@ -1068,11 +1090,11 @@ func (q *Query) SelectOrInsert(values ...interface{}) (inserted bool, _ error) {
 			if err == internal.ErrNoRows {
 				continue
 			}
-			if pgErr, ok := err.(internal.PGError); ok {
+			if pgErr, ok := internal.AsPGError(err); ok {
 				if pgErr.IntegrityViolation() {
 					continue
 				}
-				if pgErr.Field('C') == "55000" {
+				if pgErr.Code() == pgerrcode.ObjectNotInPrerequisiteState {
 					// Retry on "#55000 attempted to delete invisible tuple".
 					continue
 				}
@@ -1215,7 +1237,47 @@ func (q *Query) ForceDelete(values ...interface{}) (Result, error) {
 
 func (q *Query) CreateTable(opt *CreateTableOptions) error {
 	_, err := q.db.ExecContext(q.ctx, newCreateTableQuery(q, opt))
-	return err
+	if err != nil {
+		return err
+	}
+	return q.createIndexes(opt)
+}
+
+// createIndexes creates the indexes of the new table. Indexes are
+// never created concurrently, because the table is empty.
+func (q *Query) createIndexes(opt *CreateTableOptions) error {
+	if q.model == nil {
+		return nil
+	}
+	var indexOpt CreateIndexOptions
+	if opt != nil {
+		indexOpt.IfNotExists = opt.IfNotExists
+	}
+	for _, index := range q.model.Table().Indexes {
+		iq := newCreateIndexQuery(q, index, &indexOpt)
+		iq.concurrently = false
+		if _, err := q.db.ExecContext(q.ctx, iq); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CreateIndexes creates the indexes of the model defined with the struct tags.
+func (q *Query) CreateIndexes(opt *CreateIndexOptions) error {
+	if q.stickyErr != nil {
+		return q.stickyErr
+	}
+	if q.model == nil {
+		return errModelNil
+	}
+	for _, index := range q.model.Table().Indexes {
+		_, err := q.db.ExecContext(q.ctx, newCreateIndexQuery(q, index, opt))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func (q *Query) DropTable(opt *DropTableOptions) error {
@@ -1549,7 +1611,7 @@ func appendColumnAndValue(
 		if isPlaceholder {
 			b = append(b, '?')
 		} else {
-			b = f.AppendValue(b, v, 1)
+			b = appendFieldValue(fmter, b, f, v)
 		}
 	}
 	return b
@@ -1586,7 +1648,7 @@ func appendColumnAndSliceValue(
 			if isPlaceholder {
 				b = append(b, '?')
 			} else {
-				b = f.AppendValue(b, el, 1)
+				b = appendFieldValue(fmter, b, f, el)
 			}
 		}
 		if len(fields) > 1 {
@@ -0,0 +1,39 @@
+package orm
+
+// Rows is an iterator over the rows returned by a query. Rows are
+// read from the connection as the iterator advances instead of being
+// loaded into memory at once. The connection is busy until Rows is closed.
+//
+//    rows, err := db.QueryRows("SELECT * FROM books")
+//    if err != nil {
+//    	panic(err)
+//    }
+//    defer rows.Close()
+//
+//    for rows.Next() {
+//    	var book Book
+//    	if err := rows.Scan(&book); err != nil {
+//    		panic(err)
+//    	}
+//    }
+//    if err := rows.Err(); err != nil {
+//    	panic(err)
+//    }
+type Rows interface {
+	// Next prepares the next row for reading with Scan. It returns false
+	// when there are no more rows or an error happened. Err should be
+	// consulted to distinguish between the two cases.
+	Next() bool
+
+	// Scan scans the current row into the values. Values are the same as
+	// accepted by Query.Select, e.g. a struct pointer or pointers to
+	// scalar values, one per column.
+	Scan(values ...interface{}) error
+
+	// Err returns the error, if any, that was encountered during iteration.
+	Err() error
+
+	// Close discards the remaining rows and releases the connection.
+	// It is safe to call Close multiple times.
+	Close() error
+}
@@ -23,6 +23,32 @@ func newSelectQuery(q *Query) *selectQuery {
 	}
 }
 
+// IsReadOnly reports whether the query built with Query only reads data,
+// i.e. it is a query created by Select, Count or Exists without
+// a locking clause and data-modifying WITH queries.
+func IsReadOnly(query interface{}) bool {
+	switch query := query.(type) {
+	case *Query:
+		return query.isReadOnly()
+	case *selectQuery:
+		return query.q.isReadOnly()
+	default:
+		return false
+	}
+}
+
+func (q *Query) isReadOnly() bool {
+	if q.selFor != nil {
+		return false
+	}
+	for _, w := range q.with {
+		if !IsReadOnly(w.query) {
+			return false
+		}
+	}
+	return true
+}
+
 func (q *selectQuery) Clone() queryCommand {
 	return &selectQuery{
 		q:     q.q.Clone(),
@@ -34,6 +60,10 @@ func (q *selectQuery) Query() *Query {
 	return q.q
 }
 
+func (q *selectQuery) Operation() QueryOp {
+	return SelectOp
+}
+
 func (q *selectQuery) AppendTemplate(b []byte) ([]byte, error) {
 	return q.AppendQuery(dummyFormatter{}, b)
 }
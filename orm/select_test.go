@@ -426,6 +426,25 @@ var _ = Describe("SoftDeleteModel", func() {
 	})
 })
 
+var _ = Describe("IsReadOnly", func() {
+	It("returns true for select queries", func() {
+		q := NewQuery(nil, &SelectModel{})
+		Expect(IsReadOnly(q)).To(BeTrue())
+		Expect(IsReadOnly(newSelectQuery(q))).To(BeTrue())
+		Expect(IsReadOnly(q.countSelectQuery("count(*)"))).To(BeTrue())
+		Expect(IsReadOnly(newSelectQuery(q.WrapWith("wrapper")))).To(BeTrue())
+	})
+
+	It("returns false for locking and modifying queries", func() {
+		q := NewQuery(nil, &SelectModel{})
+		Expect(IsReadOnly(newSelectQuery(q.Clone().For("UPDATE")))).To(BeFalse())
+		Expect(IsReadOnly(newSelectQuery(q.Clone().WithInsert("ins", q.Clone())))).To(BeFalse())
+		Expect(IsReadOnly(newInsertQuery(q))).To(BeFalse())
+		Expect(IsReadOnly(newDeleteQuery(q))).To(BeFalse())
+		Expect(IsReadOnly("SELECT 1")).To(BeFalse())
+	})
+})
+
 func selectQueryString(q *Query) string {
 	sel := newSelectQuery(q)
 	s := queryString(sel)
@@ -33,6 +33,7 @@ const (
 
 var (
 	timeType           = reflect.TypeOf((*time.Time)(nil)).Elem()
+	bytesType          = reflect.TypeOf((*[]byte)(nil)).Elem()
 	nullTimeType       = reflect.TypeOf((*types.NullTime)(nil)).Elem()
 	ipType             = reflect.TypeOf((*net.IP)(nil)).Elem()
 	ipNetType          = reflect.TypeOf((*net.IPNet)(nil)).Elem()
@@ -81,6 +82,8 @@ type Table struct {
 	Methods   map[string]*Method
 	Relations map[string]*Relation
 	Unique    map[string][]*Field
+	Indexes   []*Index
+	Checks    []string
 
 	SoftDeleteField *Field
 
@@ -125,8 +128,8 @@ func newTable(typ reflect.Type) *Table {
 
 	for _, hook := range oldHooks {
 		if typ.Implements(hook) {
-			internal.Logger.Printf("DEPRECATED: model hooks on %s must be updated - "+
-				"see https://github.com/go-pg/pg/wiki/Model-Hooks", t.TypeName)
+			internal.Warn("DEPRECATED: model hooks must be updated - "+
+				"see https://github.com/go-pg/pg/wiki/Model-Hooks", "model", t.TypeName)
 		}
 	}
 
@@ -135,6 +138,7 @@ func newTable(typ reflect.Type) *Table {
 
 func (t *Table) init1() {
 	t.initFields()
+	t.initIndexes()
 	t.initMethods()
 }
 
@@ -275,8 +279,8 @@ func (t *Table) addFields(typ reflect.Type, baseIndex []int) {
 			_, inherit := pgTag.Options["inherit"]
 			_, override := pgTag.Options["override"]
 			if override {
-				internal.Logger.Printf(
-					`DEPRECATED: %s: replace pg:",override" with pg:",inherit"`, t)
+				internal.Warn(
+					`DEPRECATED: replace pg:",override" with pg:",inherit"`, "model", t)
 			}
 			if inherit || override {
 				embeddedTable := _tables.get(fieldType, true)
@@ -319,7 +323,7 @@ func (t *Table) newField(f reflect.StructField, index []int) *Field {
 	switch f.Name {
 	case "tableName", "TableName":
 		if f.Name == "TableName" {
-			internal.Logger.Printf("DEPRECATED: %s: rename TableName to tableName", t)
+			internal.Warn("DEPRECATED: rename TableName to tableName", "model", t)
 		}
 		if len(index) > 0 {
 			return nil
@@ -359,6 +363,15 @@ func (t *Table) newField(f reflect.StructField, index []int) *Field {
 			t.setFlag(discardUnknownColumnsFlag)
 		}
 
+		return nil
+	case "_":
+		if v, ok := pgTag.Options["index"]; ok {
+			t.Indexes = append(t.Indexes, newTagIndex(v, pgTag))
+		}
+		if v, ok := pgTag.Options["check"]; ok {
+			v, _ = tagparser.Unquote(v)
+			t.Checks = append(t.Checks, v)
+		}
 		return nil
 	}
 
@@ -452,6 +465,16 @@ func (t *Table) newField(f reflect.StructField, index []int) *Field {
 		field.OnUpdate = v
 	}
 
+	if v, ok := pgTag.Options["check"]; ok {
+		field.Check, _ = tagparser.Unquote(v)
+	}
+	if v, ok := pgTag.Options["generated"]; ok {
+		field.Generated, _ = tagparser.Unquote(v)
+	}
+	if _, ok := pgTag.Options["identity"]; ok {
+		field.setFlag(IdentityFlag)
+	}
+
 	if _, ok := pgTag.Options["composite"]; ok {
 		field.append = compositeAppender(f.Type)
 		field.scan = compositeScanner(f.Type)
@@ -474,10 +497,15 @@ func (t *Table) newField(f reflect.StructField, index []int) *Field {
 			field.append = appendUintAsInt
 		}
 		field.scan = types.Scanner(f.Type)
+		field.setFlag(serverParamFlag)
 	} else {
 		field.append = types.Appender(f.Type)
 		field.scan = types.Scanner(f.Type)
+		if isServerParamType(field.Type) {
+			field.setFlag(serverParamFlag)
+		}
 	}
+	field.scanBinary = types.BinaryScanner(f.Type, field.scan)
 	field.isZero = zerochecker.Checker(f.Type)
 
 	if v, ok := pgTag.Options["alias"]; ok {
@@ -492,6 +520,10 @@ func (t *Table) newField(f reflect.StructField, index []int) *Field {
 		return nil
 	}
 
+	if v, ok := pgTag.Options["index"]; ok {
+		t.addFieldIndexes(field, v, pgTag.Options["using"])
+	}
+
 	if _, ok := pgTag.Options["soft_delete"]; ok {
 		switch field.Type {
 		case timeType, nullTimeType:
@@ -506,6 +538,68 @@ func (t *Table) newField(f reflect.StructField, index []int) *Field {
 	return field
 }
 
+func newTagIndex(name string, pgTag *tagparser.Tag) *Index {
+	index := &Index{
+		Unique:       pgTag.HasOption("unique"),
+		Using:        pgTag.Options["using"],
+		Concurrently: pgTag.HasOption("concurrently"),
+	}
+	index.Name, _ = tagparser.Unquote(name)
+	if s, ok := pgTag.Options["on"]; ok {
+		s, _ = tagparser.Unquote(s)
+		index.Columns = []string{s}
+	}
+	if s, ok := pgTag.Options["include"]; ok {
+		index.Include, _ = tagparser.Unquote(s)
+	}
+	if s, ok := pgTag.Options["where"]; ok {
+		index.Where, _ = tagparser.Unquote(s)
+	}
+	return index
+}
+
+func (t *Table) addFieldIndexes(field *Field, names, using string) {
+	if names == "" {
+		t.Indexes = append(t.Indexes, &Index{
+			Using:   using,
+			Columns: []string{string(field.Column)},
+		})
+		return
+	}
+
+	// Like unique, multiple names can be specified to add the field
+	// to several indexes.
+	names, _ = tagparser.Unquote(names)
+	for _, name := range strings.Split(names, ",") {
+		index := t.index(name)
+		if index == nil {
+			index = &Index{Name: name}
+			t.Indexes = append(t.Indexes, index)
+		}
+		if using != "" {
+			index.Using = using
+		}
+		index.Columns = append(index.Columns, string(field.Column))
+	}
+}
+
+func (t *Table) index(name string) *Index {
+	for _, index := range t.Indexes {
+		if index.Name == name {
+			return index
+		}
+	}
+	return nil
+}
+
+func (t *Table) initIndexes() {
+	for _, index := range t.Indexes {
+		if index.Name == "" {
+			index.Name = defaultIndexName(baseTableName(t.FullName), index.Columns)
+		}
+	}
+}
+
 func (t *Table) initMethods() {
 	t.Methods = make(map[string]*Method)
 	typ := reflect.PtrTo(t.Type)
@@ -1010,6 +1104,27 @@ func appendUintPtrAsInt(b []byte, v reflect.Value, _ int) []byte {
 	return strconv.AppendInt(b, int64(v.Elem().Uint()), 10)
 }
 
+// isServerParamType reports whether values of the type are sent to
+// the server as params instead of being formatted with the appender.
+// Named types are excluded, because they can have custom appenders.
+func isServerParamType(typ reflect.Type) bool {
+	if typ == timeType || typ == bytesType {
+		return true
+	}
+	if typ.PkgPath() != "" {
+		return false
+	}
+	switch typ.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	default:
+		return false
+	}
+}
+
 func tryUnderscorePrefix(s string) string {
 	if s == "" {
 		return s
@@ -1028,6 +1143,6 @@ var sqlTagDeprecatedOnce sync.Once
 
 func logSQLTagDeprecated() {
 	sqlTagDeprecatedOnce.Do(func() {
-		internal.Logger.Printf(`DEPRECATED: use pg:"..." struct field tag instead of sql:"..." `)
+		internal.Warn(`DEPRECATED: use pg:"..." struct field tag instead of sql:"..."`)
 	})
 }
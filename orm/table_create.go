@@ -49,6 +49,10 @@ func (q *createTableQuery) Query() *Query {
 	return q.q
 }
 
+func (q *createTableQuery) Operation() QueryOp {
+	return CreateTableOp
+}
+
 func (q *createTableQuery) AppendTemplate(b []byte) ([]byte, error) {
 	return q.AppendQuery(dummyFormatter{}, b)
 }
@@ -95,10 +99,12 @@ func (q *createTableQuery) AppendQuery(fmter QueryFormatter, b []byte) (_ []byte
 			b = append(b, " DEFAULT "...)
 			b = append(b, field.Default...)
 		}
+		b = appendGeneratedAndCheck(b, field)
 	}
 
 	b = appendPKConstraint(b, table.PKs)
 	b = appendUniqueConstraints(b, table)
+	b = appendCheckConstraints(b, table.Checks)
 
 	if q.opt != nil && q.opt.FKConstraints {
 		for _, rel := range table.Relations {
@@ -131,12 +137,29 @@ func (q *createTableQuery) appendSQLType(b []byte, field *Field) []byte {
 		b = append(b, ")"...)
 		return b
 	}
-	if field.hasFlag(PrimaryKeyFlag) {
+	if field.hasFlag(PrimaryKeyFlag) && !field.hasFlag(IdentityFlag) {
 		return append(b, pkSQLType(field.SQLType)...)
 	}
 	return append(b, field.SQLType...)
 }
 
+func appendGeneratedAndCheck(b []byte, field *Field) []byte {
+	if field.hasFlag(IdentityFlag) {
+		b = append(b, " GENERATED BY DEFAULT AS IDENTITY"...)
+	}
+	if field.Generated != "" {
+		b = append(b, " GENERATED ALWAYS AS ("...)
+		b = append(b, field.Generated...)
+		b = append(b, ") STORED"...)
+	}
+	if field.Check != "" {
+		b = append(b, " CHECK ("...)
+		b = append(b, field.Check...)
+		b = append(b, ")"...)
+	}
+	return b
+}
+
 func pkSQLType(s string) string {
 	switch s {
 	case pgTypeSmallint:
@@ -174,6 +197,15 @@ func appendUniqueConstraints(b []byte, table *Table) []byte {
 	return b
 }
 
+func appendCheckConstraints(b []byte, checks []string) []byte {
+	for _, check := range checks {
+		b = append(b, ", CHECK ("...)
+		b = append(b, check...)
+		b = append(b, ")"...)
+	}
+	return b
+}
+
 func appendUnique(b []byte, fields []*Field) []byte {
 	b = append(b, ", UNIQUE ("...)
 	b = appendColumns(b, "", fields)
@@ -91,6 +91,18 @@ type CreateTableWithMultipleNamedUniques struct {
 	StoreOrderNumber string `pg:",unique:per_store"`
 }
 
+type CreateTableWithGeneratedColumns struct {
+	_ struct{} `pg:"check:'starts_at < ends_at'"`
+
+	ID       int64 `pg:",identity"`
+	Number   int   `pg:",identity"`
+	Price    int   `pg:",notnull,check:'price > 0'"`
+	Quantity int
+	Total    int `pg:"generated:'price * quantity'"`
+	StartsAt time.Time
+	EndsAt   time.Time
+}
+
 var _ = Describe("CreateTable", func() {
 	It("creates new table", func() {
 		q := NewQuery(nil, &CreateTableModel{})
@@ -155,6 +167,13 @@ var _ = Describe("CreateTable", func() {
 		Expect(s).To(Equal(`CREATE TABLE "create_table_with_multiple_named_uniques" ("id" bigserial, "account_id" bigint, "order_number" text, "store_order_number" text, PRIMARY KEY ("id"), UNIQUE ("account_id", "order_number"), UNIQUE ("account_id", "store_order_number"))`))
 	})
 
+	It("creates new table with check constraints, generated and identity columns", func() {
+		q := NewQuery(nil, &CreateTableWithGeneratedColumns{})
+
+		s := createTableQueryString(q, nil)
+		Expect(s).To(Equal(`CREATE TABLE "create_table_with_generated_columns" ("id" bigint GENERATED BY DEFAULT AS IDENTITY, "number" bigint GENERATED BY DEFAULT AS IDENTITY, "price" bigint NOT NULL CHECK (price > 0), "quantity" bigint, "total" bigint GENERATED ALWAYS AS (price * quantity) STORED, "starts_at" timestamptz, "ends_at" timestamptz, PRIMARY KEY ("id"), CHECK (starts_at < ends_at))`))
+	})
+
 	It("supports model without a table name", func() {
 		type Model struct {
 			tableName struct{} `pg:"_"`
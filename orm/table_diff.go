@@ -0,0 +1,760 @@
+package orm
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/go-pg/pg/v9/types"
+)
+
+// SchemaDiff contains the statements that change the database schema
+// to match the models and the statements that revert the change.
+type SchemaDiff struct {
+	Up   []string
+	Down []string
+}
+
+// Empty reports whether the schema matches the models.
+func (d *SchemaDiff) Empty() bool {
+	return len(d.Up) == 0
+}
+
+// String returns the Up statements as SQL text.
+func (d *SchemaDiff) String() string {
+	return joinStatements(d.Up)
+}
+
+func joinStatements(stmts []string) string {
+	var b strings.Builder
+	for _, stmt := range stmts {
+		b.WriteString(stmt)
+		b.WriteString(";\n")
+	}
+	return b.String()
+}
+
+func (d *SchemaDiff) add(up, down string) {
+	d.Up = append(d.Up, up)
+	if down != "" {
+		d.Down = append([]string{down}, d.Down...)
+	}
+}
+
+// DiffTables reads the schema of the model tables from pg_catalog and
+// compares it with the models. It returns ALTER TABLE statements that add,
+// drop and alter columns, primary keys, unique and foreign key constraints,
+// CREATE TABLE statements for missing tables and CREATE INDEX statements
+// for missing indexes. Indexes are compared by name, so an index must be
+// renamed to be recreated with a different definition. Statements are ordered,
+// so constraints are dropped before the columns they use and foreign keys
+// are added after the tables they reference. Only the Varchar and
+// FKConstraints options are used.
+func DiffTables(db DB, models []interface{}, opt *CreateTableOptions) (*SchemaDiff, error) {
+	tables := make([]*Table, len(models))
+	lives := make([]*liveTable, len(models))
+	for i, model := range models {
+		q := NewQuery(db, model)
+		if q.stickyErr != nil {
+			return nil, q.stickyErr
+		}
+		if q.model == nil {
+			return nil, errModelNil
+		}
+		tables[i] = q.model.Table()
+
+		live, err := readLiveTable(db, tables[i])
+		if err != nil {
+			return nil, err
+		}
+		lives[i] = live
+	}
+	return diffTables(db.Formatter(), tables, lives, opt)
+}
+
+//------------------------------------------------------------------------------
+
+// liveTable is the schema of a table read from the database.
+// It is nil when the table does not exist.
+type liveTable struct {
+	Columns     []*liveColumn
+	Constraints []*liveConstraint
+	Indexes     []*liveIndex
+}
+
+type liveColumn struct {
+	Name      string
+	Type      string
+	NotNull   bool
+	Default   string // default or generation expression
+	Generated bool
+	Identity  string // ALWAYS or BY DEFAULT
+}
+
+type liveIndex struct {
+	Name string
+	Def  string
+}
+
+type liveConstraint struct {
+	Name    string
+	Type    string   // p, u or f
+	Columns []string `pg:",array"`
+	Def     string
+}
+
+func (t *liveTable) column(name string) *liveColumn {
+	for _, col := range t.Columns {
+		if col.Name == name {
+			return col
+		}
+	}
+	return nil
+}
+
+func readLiveTable(db DB, table *Table) (*liveTable, error) {
+	name := string(table.FullName)
+
+	var exists bool
+	_, err := db.QueryOne(Scan(&exists), "SELECT to_regclass(?) IS NOT NULL", name)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, nil
+	}
+
+	live := new(liveTable)
+	_, err = db.Query(&live.Columns, `
+		SELECT a.attname AS name,
+			format_type(a.atttypid, a.atttypmod) AS type,
+			a.attnotnull AS not_null,
+			coalesce(pg_get_expr(d.adbin, d.adrelid), '') AS default,
+			coalesce(ic.is_generated = 'ALWAYS', false) AS generated,
+			coalesce(ic.identity_generation, '') AS identity
+		FROM pg_attribute a
+		LEFT JOIN pg_attrdef d ON d.adrelid = a.attrelid AND d.adnum = a.attnum
+		JOIN pg_class cl ON cl.oid = a.attrelid
+		JOIN pg_namespace n ON n.oid = cl.relnamespace
+		LEFT JOIN information_schema.columns ic ON ic.table_schema = n.nspname
+			AND ic.table_name = cl.relname AND ic.column_name = a.attname
+		WHERE a.attrelid = to_regclass(?)::oid AND a.attnum > 0 AND NOT a.attisdropped
+		ORDER BY a.attnum`, name)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = db.Query(&live.Constraints, `
+		SELECT c.conname AS name,
+			c.contype AS type,
+			array(
+				SELECT a.attname
+				FROM unnest(c.conkey) WITH ORDINALITY AS k(attnum, i)
+				JOIN pg_attribute a ON a.attrelid = c.conrelid AND a.attnum = k.attnum
+				ORDER BY k.i
+			) AS columns,
+			pg_get_constraintdef(c.oid) AS def
+		FROM pg_constraint c
+		WHERE c.conrelid = to_regclass(?)::oid AND c.contype IN ('p', 'u', 'f')
+		ORDER BY c.conname`, name)
+	if err != nil {
+		return nil, err
+	}
+
+	// Indexes of primary key and unique constraints are skipped.
+	_, err = db.Query(&live.Indexes, `
+		SELECT i.relname AS name, pg_get_indexdef(x.indexrelid) AS def
+		FROM pg_index x
+		JOIN pg_class i ON i.oid = x.indexrelid
+		WHERE x.indrelid = to_regclass(?)::oid AND NOT EXISTS (
+			SELECT 1 FROM pg_constraint c
+			WHERE c.conrelid = x.indrelid AND c.conindid = x.indexrelid
+		)
+		ORDER BY i.relname`, name)
+	if err != nil {
+		return nil, err
+	}
+
+	return live, nil
+}
+
+//------------------------------------------------------------------------------
+
+// tableDiffer compares a model table with the live table.
+type tableDiffer struct {
+	fmter QueryFormatter
+	opt   *CreateTableOptions
+	table *Table
+	live  *liveTable
+	name  string // unquoted table name without schema used for constraint names
+}
+
+// wantedConstraint is a constraint defined by the model.
+type wantedConstraint struct {
+	typ     string
+	columns []string
+	def     string
+}
+
+func diffTables(
+	fmter QueryFormatter, tables []*Table, lives []*liveTable, opt *CreateTableOptions,
+) (*SchemaDiff, error) {
+	differs := make([]*tableDiffer, len(tables))
+	for i, table := range tables {
+		differs[i] = &tableDiffer{
+			fmter: fmter,
+			opt:   opt,
+			table: table,
+			live:  lives[i],
+			name:  baseTableName(table.FullName),
+		}
+	}
+
+	diff := new(SchemaDiff)
+	for _, d := range differs {
+		d.dropConstraints(diff, "f")
+	}
+	for _, d := range differs {
+		d.dropConstraints(diff, "p")
+		d.dropConstraints(diff, "u")
+		d.dropIndexes(diff)
+		if d.live == nil {
+			if err := d.createTable(diff); err != nil {
+				return nil, err
+			}
+		} else {
+			d.diffColumns(diff)
+			d.addConstraints(diff, "p")
+			d.addConstraints(diff, "u")
+		}
+		if err := d.createIndexes(diff); err != nil {
+			return nil, err
+		}
+	}
+	for _, d := range differs {
+		d.addConstraints(diff, "f")
+	}
+	return diff, nil
+}
+
+func (d *tableDiffer) alterTable(s string, args ...interface{}) string {
+	return fmt.Sprintf("ALTER TABLE %s "+s, append([]interface{}{d.table.FullName}, args...)...)
+}
+
+// createTable creates a missing table. Foreign keys are added
+// separately after all tables are created.
+func (d *tableDiffer) createTable(diff *SchemaDiff) error {
+	var opt CreateTableOptions
+	if d.opt != nil {
+		opt.Varchar = d.opt.Varchar
+	}
+	q := newCreateTableQuery(NewQuery(nil, newStructTableModel(d.table)), &opt)
+	b, err := q.AppendQuery(d.fmter, nil)
+	if err != nil {
+		return err
+	}
+	diff.add(string(b), "DROP TABLE "+string(d.table.FullName))
+	return nil
+}
+
+func (d *tableDiffer) diffColumns(diff *SchemaDiff) {
+	for _, field := range d.table.Fields {
+		col := d.live.column(field.SQLName)
+		if col == nil {
+			up := d.alterTable("ADD COLUMN %s", d.columnDef(field))
+			down := d.alterTable("DROP COLUMN %s", field.Column)
+			diff.add(up, down)
+			continue
+		}
+
+		if d.diffGenerated(diff, field, col) {
+			continue
+		}
+
+		typ := d.sqlType(field)
+		if isSerialType(typ) {
+			// Serial types are only available in CREATE TABLE.
+			typ = canonicalSQLType(typ)
+		}
+		if canonicalSQLType(typ) != canonicalSQLType(col.Type) {
+			diff.add(
+				d.alterTable("ALTER COLUMN %s TYPE %s USING %s::%s",
+					field.Column, typ, field.Column, typ),
+				d.alterTable("ALTER COLUMN %s TYPE %s USING %s::%s",
+					field.Column, col.Type, field.Column, col.Type))
+		}
+
+		// Identity columns are implicitly NOT NULL.
+		notNull := field.hasFlag(NotNullFlag) || field.hasFlag(PrimaryKeyFlag) ||
+			field.hasFlag(IdentityFlag)
+		if notNull && !col.NotNull {
+			diff.add(
+				d.alterTable("ALTER COLUMN %s SET NOT NULL", field.Column),
+				d.alterTable("ALTER COLUMN %s DROP NOT NULL", field.Column))
+		} else if !notNull && col.NotNull {
+			diff.add(
+				d.alterTable("ALTER COLUMN %s DROP NOT NULL", field.Column),
+				d.alterTable("ALTER COLUMN %s SET NOT NULL", field.Column))
+		}
+
+		d.diffDefault(diff, field, col)
+		d.diffIdentity(diff, field, col)
+	}
+
+	for _, col := range d.live.Columns {
+		if d.hasColumn(col.Name) {
+			continue
+		}
+		diff.add(
+			d.alterTable("DROP COLUMN %s", quoteIdent(col.Name)),
+			d.alterTable("ADD COLUMN %s", liveColumnDef(col)))
+	}
+}
+
+func (d *tableDiffer) hasColumn(name string) bool {
+	for _, field := range d.table.Fields {
+		if field.SQLName == name {
+			return true
+		}
+	}
+	return false
+}
+
+// diffGenerated changes the generation expression of the column.
+// The column is recreated, because the expression can't be altered.
+// It returns true when the column is recreated.
+func (d *tableDiffer) diffGenerated(diff *SchemaDiff, field *Field, col *liveColumn) bool {
+	recreate := d.alterTable("DROP COLUMN %s, ADD COLUMN %s", field.Column, d.columnDef(field))
+	restore := d.alterTable("DROP COLUMN %s, ADD COLUMN %s", field.Column, liveColumnDef(col))
+	switch {
+	case field.Generated != "" && !col.Generated:
+		diff.add(recreate, restore)
+		return true
+	case field.Generated != "" && col.Generated:
+		if normalizeDefault(field.Generated) == normalizeDefault(col.Default) {
+			return false
+		}
+		diff.add(recreate, restore)
+		return true
+	case field.Generated == "" && col.Generated:
+		// The column keeps the generated values.
+		diff.add(d.alterTable("ALTER COLUMN %s DROP EXPRESSION", field.Column), restore)
+	}
+	return false
+}
+
+func (d *tableDiffer) diffIdentity(diff *SchemaDiff, field *Field, col *liveColumn) {
+	identity := field.hasFlag(IdentityFlag)
+	if identity && col.Identity == "" {
+		diff.add(
+			d.alterTable("ALTER COLUMN %s ADD GENERATED BY DEFAULT AS IDENTITY", field.Column),
+			d.alterTable("ALTER COLUMN %s DROP IDENTITY", field.Column))
+	} else if !identity && col.Identity != "" {
+		diff.add(
+			d.alterTable("ALTER COLUMN %s DROP IDENTITY", field.Column),
+			d.alterTable("ALTER COLUMN %s ADD GENERATED %s AS IDENTITY", field.Column, col.Identity))
+	}
+}
+
+func (d *tableDiffer) diffDefault(diff *SchemaDiff, field *Field, col *liveColumn) {
+	if field.Generated != "" || col.Generated {
+		return
+	}
+	if isSerialType(d.sqlType(field)) && strings.HasPrefix(col.Default, "nextval(") {
+		return
+	}
+	if normalizeDefault(string(field.Default)) == normalizeDefault(col.Default) {
+		return
+	}
+
+	var up, down string
+	if field.Default != "" {
+		up = d.alterTable("ALTER COLUMN %s SET DEFAULT %s", field.Column, field.Default)
+	} else {
+		up = d.alterTable("ALTER COLUMN %s DROP DEFAULT", field.Column)
+	}
+	if col.Default != "" {
+		down = d.alterTable("ALTER COLUMN %s SET DEFAULT %s", field.Column, col.Default)
+	} else {
+		down = d.alterTable("ALTER COLUMN %s DROP DEFAULT", field.Column)
+	}
+	diff.add(up, down)
+}
+
+func (d *tableDiffer) sqlType(field *Field) string {
+	q := createTableQuery{opt: d.opt}
+	return string(q.appendSQLType(nil, field))
+}
+
+func (d *tableDiffer) columnDef(field *Field) string {
+	b := append([]byte(nil), field.Column...)
+	b = append(b, ' ')
+	b = append(b, d.sqlType(field)...)
+	if field.hasFlag(NotNullFlag) {
+		b = append(b, " NOT NULL"...)
+	}
+	if field.Default != "" {
+		b = append(b, " DEFAULT "...)
+		b = append(b, field.Default...)
+	}
+	b = appendGeneratedAndCheck(b, field)
+	return string(b)
+}
+
+func liveColumnDef(col *liveColumn) string {
+	b := types.AppendIdent(nil, col.Name, 1)
+	b = append(b, ' ')
+	b = append(b, col.Type...)
+	if col.NotNull {
+		b = append(b, " NOT NULL"...)
+	}
+	switch {
+	case col.Generated:
+		b = append(b, " GENERATED ALWAYS AS ("...)
+		b = append(b, col.Default...)
+		b = append(b, ") STORED"...)
+	case col.Identity != "":
+		b = append(b, " GENERATED "...)
+		b = append(b, col.Identity...)
+		b = append(b, " AS IDENTITY"...)
+	case col.Default != "":
+		b = append(b, " DEFAULT "...)
+		b = append(b, col.Default...)
+	}
+	return string(b)
+}
+
+//------------------------------------------------------------------------------
+
+// wantedConstraints returns the constraints of the type defined by the model.
+func (d *tableDiffer) wantedConstraints(typ string) []*wantedConstraint {
+	var cs []*wantedConstraint
+	switch typ {
+	case "p":
+		if len(d.table.PKs) > 0 {
+			cs = append(cs, &wantedConstraint{
+				typ:     typ,
+				columns: fieldNames(d.table.PKs),
+				def:     "PRIMARY KEY (" + string(appendColumns(nil, "", d.table.PKs)) + ")",
+			})
+		}
+	case "u":
+		seen := make(map[string]bool)
+		addUnique := func(fields []*Field) {
+			columns := fieldNames(fields)
+			key := strings.Join(columns, ",")
+			if seen[key] {
+				return
+			}
+			seen[key] = true
+			cs = append(cs, &wantedConstraint{
+				typ:     typ,
+				columns: columns,
+				def:     "UNIQUE (" + string(appendColumns(nil, "", fields)) + ")",
+			})
+		}
+		for _, field := range d.table.Fields {
+			if field.hasFlag(UniqueFlag) {
+				addUnique([]*Field{field})
+			}
+		}
+		for _, key := range sortedKeys(d.table.Unique) {
+			addUnique(d.table.Unique[key])
+		}
+	case "f":
+		if d.opt == nil || !d.opt.FKConstraints {
+			return nil
+		}
+		for _, name := range sortedRelationNames(d.table.Relations) {
+			rel := d.table.Relations[name]
+			if rel.Type != HasOneRelation {
+				continue
+			}
+			q := createTableQuery{}
+			b := q.appendFKConstraint(d.fmter, nil, rel)
+			cs = append(cs, &wantedConstraint{
+				typ:     typ,
+				columns: fieldNames(rel.FKs),
+				def:     strings.TrimPrefix(string(b), ", "),
+			})
+		}
+	}
+	return cs
+}
+
+func (d *tableDiffer) liveConstraints(typ string) []*liveConstraint {
+	if d.live == nil {
+		return nil
+	}
+	var cs []*liveConstraint
+	for _, c := range d.live.Constraints {
+		if c.Type == typ {
+			cs = append(cs, c)
+		}
+	}
+	return cs
+}
+
+// matches reports whether the live constraint is the same as
+// the constraint defined by the model.
+func (d *tableDiffer) matches(c *liveConstraint, w *wantedConstraint) bool {
+	if !equalStrings(c.Columns, w.columns) {
+		return false
+	}
+	if c.Type != "f" {
+		return true
+	}
+	return normalizeConstraintDef(c.Def) == normalizeConstraintDef(w.def)
+}
+
+func (d *tableDiffer) dropConstraints(diff *SchemaDiff, typ string) {
+	if d.live == nil {
+		return
+	}
+	if typ == "f" && (d.opt == nil || !d.opt.FKConstraints) {
+		// Foreign keys are not managed.
+		return
+	}
+	wanted := d.wantedConstraints(typ)
+outer:
+	for _, c := range d.liveConstraints(typ) {
+		for _, w := range wanted {
+			if d.matches(c, w) {
+				continue outer
+			}
+		}
+		name := quoteIdent(c.Name)
+		diff.add(
+			d.alterTable("DROP CONSTRAINT %s", name),
+			d.alterTable("ADD CONSTRAINT %s %s", name, c.Def))
+	}
+}
+
+func (d *tableDiffer) addConstraints(diff *SchemaDiff, typ string) {
+	live := d.liveConstraints(typ)
+outer:
+	for _, w := range d.wantedConstraints(typ) {
+		for _, c := range live {
+			if d.matches(c, w) {
+				continue outer
+			}
+		}
+		name := quoteIdent(d.constraintName(w))
+		diff.add(
+			d.alterTable("ADD CONSTRAINT %s %s", name, w.def),
+			d.alterTable("DROP CONSTRAINT %s", name))
+	}
+}
+
+// constraintName returns the name PostgreSQL gives to the constraint
+// when it is created without a name.
+func (d *tableDiffer) constraintName(w *wantedConstraint) string {
+	switch w.typ {
+	case "p":
+		return d.name + "_pkey"
+	case "u":
+		return d.name + "_" + strings.Join(w.columns, "_") + "_key"
+	default:
+		return d.name + "_" + strings.Join(w.columns, "_") + "_fkey"
+	}
+}
+
+func (d *tableDiffer) hasIndex(name string) bool {
+	if d.live == nil {
+		return false
+	}
+	for _, index := range d.live.Indexes {
+		if index.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (d *tableDiffer) dropIndexes(diff *SchemaDiff) {
+	if d.live == nil {
+		return
+	}
+	for _, index := range d.live.Indexes {
+		if d.table.index(index.Name) != nil {
+			continue
+		}
+		diff.add("DROP INDEX "+d.qualifiedName(index.Name), index.Def)
+	}
+}
+
+func (d *tableDiffer) createIndexes(diff *SchemaDiff) error {
+	q := NewQuery(nil, newStructTableModel(d.table))
+	for _, index := range d.table.Indexes {
+		if d.hasIndex(index.Name) {
+			continue
+		}
+		b, err := newCreateIndexQuery(q, index, nil).AppendQuery(d.fmter, nil)
+		if err != nil {
+			return err
+		}
+		diff.add(string(b), "DROP INDEX "+d.qualifiedName(index.Name))
+	}
+	return nil
+}
+
+// qualifiedName returns the quoted name of the relation
+// in the schema of the table.
+func (d *tableDiffer) qualifiedName(name string) string {
+	s := string(quoteIdent(name))
+	if i := strings.LastIndexByte(string(d.table.FullName), '.'); i >= 0 {
+		return string(d.table.FullName[:i+1]) + s
+	}
+	return s
+}
+
+//------------------------------------------------------------------------------
+
+func fieldNames(fields []*Field) []string {
+	names := make([]string, len(fields))
+	for i, f := range fields {
+		names[i] = f.SQLName
+	}
+	return names
+}
+
+func sortedKeys(m map[string][]*Field) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func sortedRelationNames(m map[string]*Relation) []string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func baseTableName(name types.Safe) string {
+	s := strings.Replace(string(name), `"`, "", -1)
+	if i := strings.LastIndexByte(s, '.'); i >= 0 {
+		s = s[i+1:]
+	}
+	return s
+}
+
+var sqlTypeAliases = map[string]string{
+	"int":         "integer",
+	"int4":        "integer",
+	"serial":      "integer",
+	"serial4":     "integer",
+	"int8":        "bigint",
+	"bigserial":   "bigint",
+	"serial8":     "bigint",
+	"int2":        "smallint",
+	"smallserial": "smallint",
+	"serial2":     "smallint",
+	"float8":      "double precision",
+	"float4":      "real",
+	"bool":        "boolean",
+	"varchar":     "character varying",
+	"char":        "character",
+	"bpchar":      "character",
+	"decimal":     "numeric",
+	"timestamptz": "timestamp with time zone",
+	"timestamp":   "timestamp without time zone",
+	"timetz":      "time with time zone",
+	"time":        "time without time zone",
+}
+
+// canonicalSQLType converts the type to the form used by format_type,
+// e.g. timestamptz to timestamp with time zone or varchar(10)
+// to character varying(10).
+func canonicalSQLType(s string) string {
+	s = strings.ToLower(strings.TrimSpace(s))
+
+	var array string
+	for strings.HasSuffix(s, "[]") {
+		s = strings.TrimSpace(s[:len(s)-2])
+		array += "[]"
+	}
+
+	var mods string
+	if i := strings.IndexByte(s, '('); i >= 0 {
+		mods = strings.Replace(s[i:], " ", "", -1)
+		s = strings.TrimSpace(s[:i])
+	}
+
+	if alias, ok := sqlTypeAliases[s]; ok {
+		s = alias
+	}
+	return s + mods + array
+}
+
+func isSerialType(s string) bool {
+	switch strings.ToLower(s) {
+	case pgTypeSerial, pgTypeBigserial, pgTypeSmallserial:
+		return true
+	}
+	return false
+}
+
+var castRe = regexp.MustCompile(`::[a-z][a-z0-9_ ]*(\(\d+(,\s*\d+)?\))?(\[\])*$`)
+
+// normalizeDefault removes the type casts PostgreSQL adds to
+// default expressions, e.g. 'foo'::text becomes 'foo'.
+func normalizeDefault(s string) string {
+	s = strings.TrimSpace(s)
+	for {
+		for strings.HasPrefix(s, "(") && strings.HasSuffix(s, ")") {
+			s = strings.TrimSpace(s[1 : len(s)-1])
+		}
+		loc := castRe.FindStringIndex(s)
+		if loc == nil {
+			break
+		}
+		s = strings.TrimSpace(s[:loc[0]])
+	}
+	// Negative numbers are quoted, e.g. '-1'::integer.
+	if len(s) > 2 && s[0] == '\'' && s[len(s)-1] == '\'' && isNumber(s[1:len(s)-1]) {
+		s = s[1 : len(s)-1]
+	}
+	return s
+}
+
+func isNumber(s string) bool {
+	s = strings.TrimPrefix(s, "-")
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if (c < '0' || c > '9') && c != '.' {
+			return false
+		}
+	}
+	return true
+}
+
+// normalizeConstraintDef makes a constraint definition built from the model
+// comparable with the one returned by pg_get_constraintdef.
+func normalizeConstraintDef(s string) string {
+	s = strings.Replace(s, `"`, "", -1)
+	s = strings.Replace(s, " (", "(", -1)
+	s = strings.Replace(s, "ON DELETE NO ACTION", "", -1)
+	s = strings.Replace(s, "ON UPDATE NO ACTION", "", -1)
+	return strings.Join(strings.Fields(strings.ToUpper(s)), " ")
+}
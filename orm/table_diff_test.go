@@ -0,0 +1,205 @@
+package orm
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type DiffAuthor struct {
+	ID   int
+	Name string `pg:",notnull,unique"`
+}
+
+type DiffBook struct {
+	ID       int
+	Title    string `pg:",notnull"`
+	Rating   int    `pg:"default:0"`
+	AuthorID int    `pg:"on_delete:CASCADE"`
+	Author   *DiffAuthor
+}
+
+type DiffIndexModel struct {
+	tableName struct{} `pg:"app.diff_index_models"`
+
+	ID        int
+	Email     string `pg:",index"`
+	CreatedAt int    `pg:"index:diff_index_models_created_idx,using:brin"`
+}
+
+type DiffGeneratedModel struct {
+	ID       int `pg:",identity"`
+	Price    int
+	Quantity int
+	Total    int `pg:"generated:'price * quantity'"`
+	Discount int
+}
+
+func diffModels(live []*liveTable, opt *CreateTableOptions, models ...interface{}) *SchemaDiff {
+	tables := make([]*Table, len(models))
+	for i, model := range models {
+		tables[i] = NewQuery(nil, model).model.Table()
+	}
+	diff, err := diffTables(NewFormatter(), tables, live, opt)
+	Expect(err).NotTo(HaveOccurred())
+	return diff
+}
+
+var _ = Describe("DiffTables", func() {
+	It("creates missing tables and adds foreign keys last", func() {
+		diff := diffModels(
+			[]*liveTable{nil, nil}, &CreateTableOptions{FKConstraints: true},
+			(*DiffBook)(nil), (*DiffAuthor)(nil))
+		Expect(diff.Up).To(Equal([]string{
+			`CREATE TABLE "diff_books" ("id" bigserial, "title" text NOT NULL, "rating" bigint DEFAULT 0, "author_id" bigint, PRIMARY KEY ("id"))`,
+			`CREATE TABLE "diff_authors" ("id" bigserial, "name" text NOT NULL UNIQUE, PRIMARY KEY ("id"), UNIQUE ("name"))`,
+			`ALTER TABLE "diff_books" ADD CONSTRAINT "diff_books_author_id_fkey" FOREIGN KEY ("author_id") REFERENCES "diff_authors" ("id") ON DELETE CASCADE`,
+		}))
+		Expect(diff.Down).To(Equal([]string{
+			`ALTER TABLE "diff_books" DROP CONSTRAINT "diff_books_author_id_fkey"`,
+			`DROP TABLE "diff_authors"`,
+			`DROP TABLE "diff_books"`,
+		}))
+	})
+
+	It("returns nothing when the schema matches", func() {
+		live := &liveTable{
+			Columns: []*liveColumn{
+				{Name: "id", Type: "bigint", NotNull: true, Default: "nextval('diff_books_id_seq'::regclass)"},
+				{Name: "title", Type: "text", NotNull: true},
+				{Name: "rating", Type: "bigint", Default: "0"},
+				{Name: "author_id", Type: "bigint"},
+			},
+			Constraints: []*liveConstraint{
+				{Name: "diff_books_pkey", Type: "p", Columns: []string{"id"}, Def: "PRIMARY KEY (id)"},
+				{
+					Name:    "diff_books_author_id_fkey",
+					Type:    "f",
+					Columns: []string{"author_id"},
+					Def:     "FOREIGN KEY (author_id) REFERENCES diff_authors(id) ON DELETE CASCADE",
+				},
+			},
+		}
+		diff := diffModels([]*liveTable{live}, &CreateTableOptions{FKConstraints: true}, (*DiffBook)(nil))
+		Expect(diff.Empty()).To(BeTrue())
+		Expect(diff.String()).To(Equal(""))
+	})
+
+	It("alters columns and constraints", func() {
+		live := &liveTable{
+			Columns: []*liveColumn{
+				{Name: "id", Type: "integer", NotNull: true},
+				{Name: "title", Type: "character varying(100)", Default: "'untitled'::character varying"},
+				{Name: "rating", Type: "bigint", NotNull: true, Default: "'-1'::integer"},
+				{Name: "isbn", Type: "text", NotNull: true},
+			},
+			Constraints: []*liveConstraint{
+				{Name: "diff_books_pkey", Type: "p", Columns: []string{"id"}, Def: "PRIMARY KEY (id)"},
+				{Name: "diff_books_isbn_key", Type: "u", Columns: []string{"isbn"}, Def: "UNIQUE (isbn)"},
+			},
+		}
+		diff := diffModels([]*liveTable{live}, &CreateTableOptions{FKConstraints: true}, (*DiffBook)(nil))
+		Expect(diff.Up).To(Equal([]string{
+			`ALTER TABLE "diff_books" DROP CONSTRAINT "diff_books_isbn_key"`,
+			`ALTER TABLE "diff_books" ALTER COLUMN "id" TYPE bigint USING "id"::bigint`,
+			`ALTER TABLE "diff_books" ALTER COLUMN "title" TYPE text USING "title"::text`,
+			`ALTER TABLE "diff_books" ALTER COLUMN "title" SET NOT NULL`,
+			`ALTER TABLE "diff_books" ALTER COLUMN "title" DROP DEFAULT`,
+			`ALTER TABLE "diff_books" ALTER COLUMN "rating" DROP NOT NULL`,
+			`ALTER TABLE "diff_books" ALTER COLUMN "rating" SET DEFAULT 0`,
+			`ALTER TABLE "diff_books" ADD COLUMN "author_id" bigint`,
+			`ALTER TABLE "diff_books" DROP COLUMN "isbn"`,
+			`ALTER TABLE "diff_books" ADD CONSTRAINT "diff_books_author_id_fkey" FOREIGN KEY ("author_id") REFERENCES "diff_authors" ("id") ON DELETE CASCADE`,
+		}))
+		Expect(diff.Down).To(Equal([]string{
+			`ALTER TABLE "diff_books" DROP CONSTRAINT "diff_books_author_id_fkey"`,
+			`ALTER TABLE "diff_books" ADD COLUMN "isbn" text NOT NULL`,
+			`ALTER TABLE "diff_books" DROP COLUMN "author_id"`,
+			`ALTER TABLE "diff_books" ALTER COLUMN "rating" SET DEFAULT '-1'::integer`,
+			`ALTER TABLE "diff_books" ALTER COLUMN "rating" SET NOT NULL`,
+			`ALTER TABLE "diff_books" ALTER COLUMN "title" SET DEFAULT 'untitled'::character varying`,
+			`ALTER TABLE "diff_books" ALTER COLUMN "title" DROP NOT NULL`,
+			`ALTER TABLE "diff_books" ALTER COLUMN "title" TYPE character varying(100) USING "title"::character varying(100)`,
+			`ALTER TABLE "diff_books" ALTER COLUMN "id" TYPE integer USING "id"::integer`,
+			`ALTER TABLE "diff_books" ADD CONSTRAINT "diff_books_isbn_key" UNIQUE (isbn)`,
+		}))
+	})
+
+	It("ignores foreign keys without FKConstraints", func() {
+		live := &liveTable{
+			Columns: []*liveColumn{
+				{Name: "id", Type: "bigint", NotNull: true},
+				{Name: "name", Type: "text", NotNull: true},
+			},
+			Constraints: []*liveConstraint{
+				{Name: "diff_authors_pkey", Type: "p", Columns: []string{"id"}},
+				{Name: "diff_authors_name_fkey", Type: "f", Columns: []string{"name"}},
+			},
+		}
+		diff := diffModels([]*liveTable{live}, nil, (*DiffAuthor)(nil))
+		Expect(diff.String()).To(Equal(
+			`ALTER TABLE "diff_authors" ADD CONSTRAINT "diff_authors_name_key" UNIQUE ("name");` + "\n"))
+	})
+
+	It("creates and drops indexes", func() {
+		live := &liveTable{
+			Columns: []*liveColumn{
+				{Name: "id", Type: "bigint", NotNull: true},
+				{Name: "email", Type: "text"},
+				{Name: "created_at", Type: "bigint"},
+			},
+			Constraints: []*liveConstraint{
+				{Name: "diff_index_models_pkey", Type: "p", Columns: []string{"id"}},
+			},
+			Indexes: []*liveIndex{
+				{Name: "diff_index_models_email_idx"},
+				{
+					Name: "diff_index_models_old_idx",
+					Def:  "CREATE INDEX diff_index_models_old_idx ON app.diff_index_models USING btree (created_at)",
+				},
+			},
+		}
+		diff := diffModels([]*liveTable{live}, nil, (*DiffIndexModel)(nil))
+		Expect(diff.Up).To(Equal([]string{
+			`DROP INDEX app."diff_index_models_old_idx"`,
+			`CREATE INDEX "diff_index_models_created_idx" ON app.diff_index_models USING brin ("created_at")`,
+		}))
+		Expect(diff.Down).To(Equal([]string{
+			`DROP INDEX app."diff_index_models_created_idx"`,
+			"CREATE INDEX diff_index_models_old_idx ON app.diff_index_models USING btree (created_at)",
+		}))
+	})
+
+	It("diffs generated and identity columns", func() {
+		live := &liveTable{
+			Columns: []*liveColumn{
+				{Name: "id", Type: "bigint", NotNull: true, Default: "nextval('diff_generated_models_id_seq'::regclass)"},
+				{Name: "price", Type: "bigint"},
+				{Name: "quantity", Type: "bigint"},
+				{Name: "total", Type: "bigint", Default: "(price * quantity)", Generated: true},
+				{Name: "discount", Type: "bigint", Default: "(price / 10)", Generated: true},
+			},
+			Constraints: []*liveConstraint{
+				{Name: "diff_generated_models_pkey", Type: "p", Columns: []string{"id"}},
+			},
+		}
+		diff := diffModels([]*liveTable{live}, nil, (*DiffGeneratedModel)(nil))
+		Expect(diff.Up).To(Equal([]string{
+			`ALTER TABLE "diff_generated_models" ALTER COLUMN "id" DROP DEFAULT`,
+			`ALTER TABLE "diff_generated_models" ALTER COLUMN "id" ADD GENERATED BY DEFAULT AS IDENTITY`,
+			`ALTER TABLE "diff_generated_models" ALTER COLUMN "discount" DROP EXPRESSION`,
+		}))
+		Expect(diff.Down).To(Equal([]string{
+			`ALTER TABLE "diff_generated_models" DROP COLUMN "discount", ADD COLUMN "discount" bigint GENERATED ALWAYS AS ((price / 10)) STORED`,
+			`ALTER TABLE "diff_generated_models" ALTER COLUMN "id" DROP IDENTITY`,
+			`ALTER TABLE "diff_generated_models" ALTER COLUMN "id" SET DEFAULT nextval('diff_generated_models_id_seq'::regclass)`,
+		}))
+
+		live.Columns[3].Default = "(price + quantity)"
+		live.Columns[4].Generated = false
+		live.Columns[4].Default = ""
+		diff = diffModels([]*liveTable{live}, nil, (*DiffGeneratedModel)(nil))
+		Expect(diff.Up[2]).To(Equal(
+			`ALTER TABLE "diff_generated_models" DROP COLUMN "total", ADD COLUMN "total" bigint GENERATED ALWAYS AS (price * quantity) STORED`))
+		Expect(diff.Up).To(HaveLen(3))
+	})
+})
@@ -35,6 +35,10 @@ func (q *dropTableQuery) Query() *Query {
 	return q.q
 }
 
+func (q *dropTableQuery) Operation() QueryOp {
+	return DropTableOp
+}
+
 func (q *dropTableQuery) AppendTemplate(b []byte) ([]byte, error) {
 	return q.AppendQuery(dummyFormatter{}, b)
 }
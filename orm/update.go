@@ -17,9 +17,10 @@ func Update(db DB, model interface{}) error {
 }
 
 type updateQuery struct {
-	q           *Query
-	omitZero    bool
-	placeholder bool
+	q               *Query
+	returningFields []*Field
+	omitZero        bool
+	placeholder     bool
 }
 
 var _ QueryAppender = (*updateQuery)(nil)
@@ -44,6 +45,10 @@ func (q *updateQuery) Query() *Query {
 	return q.q
 }
 
+func (q *updateQuery) Operation() QueryOp {
+	return UpdateOp
+}
+
 func (q *updateQuery) AppendTemplate(b []byte) ([]byte, error) {
 	cp := q.Clone().(*updateQuery)
 	cp.placeholder = true
@@ -100,6 +105,8 @@ func (q *updateQuery) AppendQuery(fmter QueryFormatter, b []byte) (_ []byte, err
 		if err != nil {
 			return nil, err
 		}
+	} else if len(q.returningFields) > 0 {
+		b = appendReturningFields(b, q.returningFields)
 	}
 
 	return b, q.q.stickyErr
@@ -164,6 +171,15 @@ func (q *updateQuery) appendSetStruct(fmter QueryFormatter, b []byte, strct refl
 	if len(fields) == 0 {
 		fields = q.q.model.Table().DataFields
 	}
+	fields, generated := omitGeneratedFields(fields, true)
+
+	// Generated columns may change together with other columns.
+	q.returningFields = q.returningFields[:0]
+	for _, f := range generated {
+		if f.Generated != "" {
+			q.returningFields = append(q.returningFields, f)
+		}
+	}
 
 	pos := len(b)
 	for _, f := range fields {
@@ -191,7 +207,7 @@ func (q *updateQuery) appendSetStruct(fmter QueryFormatter, b []byte, strct refl
 				return nil, err
 			}
 		} else {
-			b = f.AppendValue(b, strct, 1)
+			b = appendFieldValue(fmter, b, f, strct)
 		}
 	}
 
@@ -221,6 +237,7 @@ func (q *updateQuery) appendSetSlice(b []byte) ([]byte, error) {
 	if len(fields) == 0 {
 		fields = q.q.model.Table().DataFields
 	}
+	fields, _ = omitGeneratedFields(fields, true)
 
 	var table *Table
 	if q.omitZero {
@@ -317,7 +334,7 @@ func (q *updateQuery) appendValues(
 		if q.placeholder {
 			b = append(b, '?')
 		} else {
-			b = f.AppendValue(b, indirect(strct), 1)
+			b = appendFieldValue(fmter, b, f, indirect(strct))
 		}
 		b = append(b, "::"...)
 		b = append(b, f.SQLType...)
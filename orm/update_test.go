@@ -15,6 +15,14 @@ type SerialUpdateTest struct {
 	Value string
 }
 
+type UpdateGeneratedTest struct {
+	ID       int
+	Number   int `pg:",identity"`
+	Price    int
+	Quantity int
+	Total    int `pg:"generated:'price * quantity'"`
+}
+
 var _ = Describe("Update", func() {
 	It("updates model", func() {
 		q := NewQuery(nil, &UpdateTest{}).WherePK()
@@ -146,6 +154,43 @@ var _ = Describe("Update", func() {
 		s := updateQueryString(q)
 		Expect(s).To(Equal(`UPDATE "models" SET  WHERE "models"."id" = NULL`))
 	})
+
+	It("omits generated and identity columns and returns generated columns", func() {
+		q := NewQuery(nil, &UpdateGeneratedTest{ID: 1, Price: 2, Quantity: 3}).WherePK()
+
+		s := updateQueryString(q)
+		Expect(s).To(Equal(`UPDATE "update_generated_tests" AS "update_generated_test" SET "price" = 2, "quantity" = 3 WHERE "update_generated_test"."id" = 1 RETURNING "total"`))
+	})
+
+	It("bulk updates omitting generated and identity columns", func() {
+		q := NewQuery(nil, &UpdateGeneratedTest{ID: 1, Price: 2}, &UpdateGeneratedTest{ID: 2})
+
+		s := updateQueryString(q)
+		Expect(s).To(Equal(`UPDATE "update_generated_tests" AS "update_generated_test" SET "price" = _data."price", "quantity" = _data."quantity" FROM (VALUES (1::bigint, NULL::bigint, 2::bigint, NULL::bigint, NULL::bigint), (2::bigint, NULL::bigint, NULL::bigint, NULL::bigint, NULL::bigint)) AS _data("id", "number", "price", "quantity", "total") WHERE "update_generated_test"."id" = _data."id"`))
+	})
+
+	It("sends model values as server params", func() {
+		q := NewQuery(nil, &UpdateTest{Id: 1, Value: "hello"}).WherePK()
+
+		var args []interface{}
+		upd := newUpdateQuery(q, false)
+		b, err := upd.AppendQuery(NewFormatter().WithModel(upd).WithServerParams(&args), nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(b)).To(Equal(`UPDATE "update_tests" AS "update_test" SET "value" = $1 WHERE "update_test"."id" = $2`))
+		Expect(args).To(Equal([]interface{}{"hello", 1}))
+	})
+
+	It("sends slice model values as server params", func() {
+		slice := []SerialUpdateTest{{Id: 1, Value: "foo"}, {Id: 2}}
+		q := NewQuery(nil, &slice).WherePK()
+
+		var args []interface{}
+		upd := newUpdateQuery(q, false)
+		b, err := upd.AppendQuery(NewFormatter().WithModel(upd).WithServerParams(&args), nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(b)).To(Equal(`UPDATE "serial_update_tests" AS "serial_update_test" SET "value" = _data."value" FROM (VALUES ($1::bigint, $2::text), ($3::bigint, NULL::text)) AS _data("id", "value") WHERE "serial_update_test"."id" IN ($4, $5)`))
+		Expect(args).To(Equal([]interface{}{int64(1), "foo", int64(2), int64(1), int64(2)}))
+	})
 })
 
 func updateQueryString(q *Query) string {
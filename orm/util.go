@@ -110,6 +110,26 @@ func modelID(b []byte, v reflect.Value, fields []*Field) []byte {
 	return b
 }
 
+// omitGeneratedFields returns the fields without the columns generated
+// by PostgreSQL and the generated columns.
+func omitGeneratedFields(fields []*Field, identity bool) (columns, generated []*Field) {
+	for i, f := range fields {
+		if f.generated(identity) {
+			if generated == nil {
+				columns = make([]*Field, i, len(fields))
+				copy(columns, fields[:i])
+			}
+			generated = append(generated, f)
+		} else if generated != nil {
+			columns = append(columns, f)
+		}
+	}
+	if generated == nil {
+		return fields, nil
+	}
+	return columns, generated
+}
+
 func appendColumns(b []byte, table types.Safe, fields []*Field) []byte {
 	for i, f := range fields {
 		if i > 0 {
@@ -3,7 +3,6 @@ package pg
 import (
 	"context"
 	"io"
-	"log"
 	"strconv"
 	"sync"
 
@@ -51,7 +50,7 @@ var qWarn sync.Once
 // DEPRECATED. Use Safe instead.
 func Q(query string, params ...interface{}) types.ValueAppender {
 	qWarn.Do(func() {
-		internal.Logger.Printf("DEPRECATED: pg.Q is replaced with pg.SafeQuery")
+		internal.Warn("DEPRECATED: pg.Q is replaced with pg.SafeQuery")
 	})
 	return SafeQuery(query, params...)
 }
@@ -61,7 +60,7 @@ var fWarn sync.Once
 // DEPRECATED. Use Ident instead.
 func F(field string) types.ValueAppender {
 	fWarn.Do(func() {
-		internal.Logger.Printf("DEPRECATED: pg.F is replaced with pg.Ident")
+		internal.Warn("DEPRECATED: pg.F is replaced with pg.Ident")
 	})
 	return Ident(field)
 }
@@ -111,11 +110,6 @@ func Hstore(v interface{}) *types.Hstore {
 	return types.NewHstore(v)
 }
 
-// SetLogger sets the logger to the given one
-func SetLogger(logger *log.Logger) {
-	internal.Logger = logger
-}
-
 //------------------------------------------------------------------------------
 
 // Strings is a typealias for a slice of strings
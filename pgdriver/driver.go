@@ -0,0 +1,126 @@
+/*
+Package pgdriver registers go-pg as a database/sql driver named "pg".
+
+	import _ "github.com/go-pg/pg/v9/pgdriver"
+
+	sqldb, err := sql.Open("pg", "postgres://postgres@localhost:5432/postgres?sslmode=disable")
+
+The data source name is parsed with pg.ParseURL, so both URLs and libpq
+keyword/value strings are accepted. Use NewConnector with sql.OpenDB to
+configure the connection with pg.Options instead.
+
+Queries without arguments are sent using the simple query protocol and
+can contain multiple statements. Queries with arguments use $1, $2, ...
+placeholders and the extended query protocol. Arguments are encoded
+by go-pg, so values such as pg.Array or structs marshalled as JSON can
+be passed directly.
+*/
+package pgdriver
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+
+	"github.com/go-pg/pg/v9"
+)
+
+func init() {
+	sql.Register("pg", Driver{})
+}
+
+// Driver is the database/sql driver for PostgreSQL.
+type Driver struct{}
+
+var _ driver.DriverContext = Driver{}
+
+// Open returns a new connection to the database. The connection is
+// dialed by a pg.DB of its own that is closed with the connection.
+func (Driver) Open(dsn string) (driver.Conn, error) {
+	opt, err := pg.ParseURL(dsn)
+	if err != nil {
+		return nil, err
+	}
+	opt.PoolSize = 1
+
+	db := pg.Connect(opt)
+	cn, err := db.Connector().Connect(context.Background())
+	if err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	return &dbConn{sqlConn: cn.(sqlConn), db: db}, nil
+}
+
+// OpenConnector parses the data source name and returns a connector.
+func (Driver) OpenConnector(dsn string) (driver.Connector, error) {
+	opt, err := pg.ParseURL(dsn)
+	if err != nil {
+		return nil, err
+	}
+	return NewConnector(opt), nil
+}
+
+// Connector opens connections using pg.Options.
+type Connector struct {
+	db   *pg.DB
+	conn driver.Connector
+}
+
+var _ driver.Connector = (*Connector)(nil)
+
+// NewConnector returns a connector that can be used with sql.OpenDB.
+// The connector owns a pg.DB that is closed by Close.
+func NewConnector(opt *pg.Options) *Connector {
+	db := pg.Connect(opt)
+	return &Connector{
+		db:   db,
+		conn: db.Connector(),
+	}
+}
+
+// Connect returns a new connection to the database.
+func (c *Connector) Connect(ctx context.Context) (driver.Conn, error) {
+	return c.conn.Connect(ctx)
+}
+
+// Driver returns the driver of the connector.
+func (c *Connector) Driver() driver.Driver {
+	return Driver{}
+}
+
+// Close closes the connections opened by the connector.
+// database/sql calls it when sql.DB is closed.
+func (c *Connector) Close() error {
+	return c.db.Close()
+}
+
+//------------------------------------------------------------------------------
+
+// sqlConn is the set of interfaces implemented by connections of
+// pg.DB.Connector.
+type sqlConn interface {
+	driver.Conn
+	driver.ConnPrepareContext
+	driver.QueryerContext
+	driver.ExecerContext
+	driver.ConnBeginTx
+	driver.Pinger
+	driver.NamedValueChecker
+	driver.SessionResetter
+}
+
+// dbConn is a connection that owns the pg.DB it was dialed with.
+type dbConn struct {
+	sqlConn
+	db *pg.DB
+}
+
+// Close closes the connection and its pg.DB.
+func (cn *dbConn) Close() error {
+	err := cn.sqlConn.Close()
+	if err2 := cn.db.Close(); err == nil {
+		err = err2
+	}
+	return err
+}
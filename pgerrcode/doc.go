@@ -0,0 +1,14 @@
+/*
+Package pgerrcode contains constants for PostgreSQL error codes (SQLSTATE)
+and helpers that check whether a code belongs to a class of errors.
+
+    var pgErr pg.PGError
+    if errors.As(err, &pgErr) && pgErr.Code() == pgerrcode.UniqueViolation {
+        // Handle duplicate key.
+    }
+
+https://www.postgresql.org/docs/current/errcodes-appendix.html
+*/
+package pgerrcode
+
+//go:generate go run gen.go
@@ -0,0 +1,202 @@
+//go:build ignore
+// +build ignore
+
+// This program generates errcode.go from PostgreSQL errcodes.txt.
+//
+//	go run gen.go [path or URL to errcodes.txt]
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"go/format"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+)
+
+const defaultSource = "https://raw.githubusercontent.com/postgres/postgres/REL_12_STABLE/src/backend/utils/errcodes.txt"
+
+var sectionRe = regexp.MustCompile(`^Section: Class (\w\w) - (.+)$`)
+
+// prefixes expands abbreviations used in errcodes.txt macro names.
+var prefixes = map[string]string{
+	"S_R_E_":   "SQL_ROUTINE_EXCEPTION_",
+	"E_R_E_":   "EXTERNAL_ROUTINE_EXCEPTION_",
+	"E_R_I_E_": "EXTERNAL_ROUTINE_INVOCATION_EXCEPTION_",
+	"T_R_":     "TRANSACTION_ROLLBACK_",
+	"L_E_":     "LOCATOR_EXCEPTION_",
+	"S_E_":     "SAVEPOINT_EXCEPTION_",
+}
+
+var initialisms = map[string]string{
+	"fdw":           "FDW",
+	"id":            "ID",
+	"io":            "IO",
+	"json":          "JSON",
+	"sql":           "SQL",
+	"sqlclient":     "SQLClient",
+	"sqlconnection": "SQLConnection",
+	"sqlserver":     "SQLServer",
+	"sqlstate":      "SQLState",
+	"srf":           "SRF",
+	"xml":           "XML",
+}
+
+type class struct {
+	code, name string
+}
+
+type condition struct {
+	code, macro, name string
+}
+
+func main() {
+	src := defaultSource
+	if len(os.Args) > 1 {
+		src = os.Args[1]
+	}
+
+	b, err := readSource(src)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	classes, conds := parse(b)
+
+	var buf bytes.Buffer
+	write(&buf, classes, conds)
+
+	out, err := format.Source(buf.Bytes())
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := ioutil.WriteFile("errcode.go", out, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func readSource(src string) ([]byte, error) {
+	if !strings.HasPrefix(src, "http://") && !strings.HasPrefix(src, "https://") {
+		return ioutil.ReadFile(src)
+	}
+
+	resp, err := http.Get(src)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: %s", src, resp.Status)
+	}
+	return ioutil.ReadAll(resp.Body)
+}
+
+func parse(b []byte) ([]class, []condition) {
+	var classes []class
+	var conds []condition
+
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' {
+			continue
+		}
+
+		if m := sectionRe.FindStringSubmatch(line); m != nil {
+			classes = append(classes, class{code: m[1], name: m[2]})
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) < 4 {
+			// Macros without a condition name are aliases.
+			continue
+		}
+		conds = append(conds, condition{
+			code:  fields[0],
+			macro: strings.TrimPrefix(fields[2], "ERRCODE_"),
+			name:  fields[3],
+		})
+	}
+	return classes, conds
+}
+
+func write(w io.Writer, classes []class, conds []condition) {
+	// Condition names are not unique across classes,
+	// e.g. string_data_right_truncation is both a warning and an error.
+	// Such conditions are named after their macros instead.
+	seen := make(map[string]int)
+	for _, c := range conds {
+		seen[c.name]++
+	}
+
+	fmt.Fprint(w, "// Code generated by gen.go. DO NOT EDIT.\n\n")
+	fmt.Fprint(w, "package pgerrcode\n\n")
+
+	for _, cl := range classes {
+		fmt.Fprintf(w, "// Class %s - %s\nconst (\n", cl.code, cl.name)
+		for _, c := range conds {
+			if c.code[:2] != cl.code {
+				continue
+			}
+			name := c.name
+			if seen[name] > 1 {
+				name = macroName(c.macro)
+			}
+			fmt.Fprintf(w, "%s = %q\n", camelCase(name), c.code)
+		}
+		fmt.Fprint(w, ")\n\n")
+	}
+
+	for _, cl := range classes {
+		name := className(cl.name)
+		fmt.Fprintf(w, "// Is%s reports whether the code belongs to\n", name)
+		fmt.Fprintf(w, "// Class %s - %s.\n", cl.code, cl.name)
+		fmt.Fprintf(w, "func Is%s(code string) bool {\n", name)
+		fmt.Fprintf(w, "return len(code) == 5 && code[:2] == %q\n}\n\n", cl.code)
+	}
+}
+
+func macroName(macro string) string {
+	for abbr, s := range prefixes {
+		if strings.HasPrefix(macro, abbr) {
+			return s + macro[len(abbr):]
+		}
+	}
+	return macro
+}
+
+func className(s string) string {
+	if i := strings.Index(s, " ("); i >= 0 {
+		s = s[:i]
+	}
+	s = strings.Replace(s, "PL/pgSQL", "PLpgSQL", -1)
+	return camelCase(strings.Replace(s, " ", "_", -1))
+}
+
+func camelCase(s string) string {
+	var b strings.Builder
+	for _, part := range strings.Split(s, "_") {
+		if part == "" {
+			continue
+		}
+		lower := strings.ToLower(part)
+		if s, ok := initialisms[lower]; ok {
+			b.WriteString(s)
+			continue
+		}
+		if part != strings.ToUpper(part) {
+			// Keep mixed case words such as PLpgSQL as is.
+			b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+			continue
+		}
+		b.WriteString(strings.ToUpper(lower[:1]) + lower[1:])
+	}
+	return b.String()
+}
@@ -0,0 +1,48 @@
+package pgerrcode_test
+
+import (
+	"testing"
+
+	"github.com/go-pg/pg/v9/pgerrcode"
+)
+
+func TestClasses(t *testing.T) {
+	tests := []struct {
+		code   string
+		is     func(string) bool
+		wanted bool
+	}{
+		{pgerrcode.UniqueViolation, pgerrcode.IsIntegrityConstraintViolation, true},
+		{pgerrcode.SerializationFailure, pgerrcode.IsTransactionRollback, true},
+		{pgerrcode.DeadlockDetected, pgerrcode.IsTransactionRollback, true},
+		{pgerrcode.QueryCanceled, pgerrcode.IsOperatorIntervention, true},
+		{pgerrcode.UndefinedTable, pgerrcode.IsSyntaxErrorOrAccessRuleViolation, true},
+		{pgerrcode.UniqueViolation, pgerrcode.IsDataException, false},
+		{"23", pgerrcode.IsIntegrityConstraintViolation, false},
+		{"", pgerrcode.IsIntegrityConstraintViolation, false},
+	}
+	for _, test := range tests {
+		if got := test.is(test.code); got != test.wanted {
+			t.Fatalf("code %q: got %v, wanted %v", test.code, got, test.wanted)
+		}
+	}
+}
+
+func TestCodes(t *testing.T) {
+	tests := []struct {
+		got, wanted string
+	}{
+		{pgerrcode.UniqueViolation, "23505"},
+		{pgerrcode.ForeignKeyViolation, "23503"},
+		{pgerrcode.SerializationFailure, "40001"},
+		{pgerrcode.DeadlockDetected, "40P01"},
+		{pgerrcode.QueryCanceled, "57014"},
+		{pgerrcode.StringDataRightTruncation, "22001"},
+		{pgerrcode.WarningStringDataRightTruncation, "01004"},
+	}
+	for _, test := range tests {
+		if test.got != test.wanted {
+			t.Fatalf("got %q, wanted %q", test.got, test.wanted)
+		}
+	}
+}
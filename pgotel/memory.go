@@ -0,0 +1,159 @@
+package pgotel
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// SpanData is a span recorded by InMemoryExporter.
+type SpanData struct {
+	Name       string
+	Attributes []Attribute
+	Err        error
+	StartTime  time.Time
+	EndTime    time.Time
+}
+
+// Attribute returns the value of the last attribute with the key.
+func (s *SpanData) Attribute(key string) (interface{}, bool) {
+	for i := len(s.Attributes) - 1; i >= 0; i-- {
+		if s.Attributes[i].Key == key {
+			return s.Attributes[i].Value, true
+		}
+	}
+	return nil, false
+}
+
+// Measurement is a value recorded by a histogram of InMemoryExporter.
+type Measurement struct {
+	Value      float64
+	Attributes []Attribute
+}
+
+// InMemoryExporter is a Tracer and Meter that keeps spans and
+// measurements in memory. It is meant for tests.
+type InMemoryExporter struct {
+	mu           sync.Mutex
+	spans        []SpanData
+	measurements map[string][]Measurement
+	gauges       map[string]func() int64
+}
+
+var _ Tracer = (*InMemoryExporter)(nil)
+var _ Meter = (*InMemoryExporter)(nil)
+
+// NewInMemoryExporter returns an empty exporter.
+func NewInMemoryExporter() *InMemoryExporter {
+	return &InMemoryExporter{
+		measurements: make(map[string][]Measurement),
+		gauges:       make(map[string]func() int64),
+	}
+}
+
+// Spans returns the ended spans in the order they were ended.
+func (e *InMemoryExporter) Spans() []SpanData {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]SpanData(nil), e.spans...)
+}
+
+// Measurements returns the values recorded by the named histogram.
+func (e *InMemoryExporter) Measurements(name string) []Measurement {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]Measurement(nil), e.measurements[name]...)
+}
+
+// Gauges observes and returns the current values of the registered gauges.
+func (e *InMemoryExporter) Gauges() map[string]int64 {
+	e.mu.Lock()
+	gauges := make(map[string]func() int64, len(e.gauges))
+	for name, observe := range e.gauges {
+		gauges[name] = observe
+	}
+	e.mu.Unlock()
+
+	values := make(map[string]int64, len(gauges))
+	for name, observe := range gauges {
+		values[name] = observe()
+	}
+	return values
+}
+
+// Reset discards the recorded spans and measurements.
+func (e *InMemoryExporter) Reset() {
+	e.mu.Lock()
+	e.spans = nil
+	e.measurements = make(map[string][]Measurement)
+	e.mu.Unlock()
+}
+
+func (e *InMemoryExporter) Start(
+	ctx context.Context, name string, attrs ...Attribute,
+) (context.Context, Span) {
+	span := &memorySpan{
+		exp: e,
+		data: SpanData{
+			Name:       name,
+			Attributes: append([]Attribute(nil), attrs...),
+			StartTime:  time.Now(),
+		},
+	}
+	return ctx, span
+}
+
+func (e *InMemoryExporter) Float64Histogram(name, unit, description string) Float64Histogram {
+	return memoryHistogram{exp: e, name: name}
+}
+
+func (e *InMemoryExporter) Int64ObservableGauge(
+	name, unit, description string, observe func() int64,
+) {
+	e.mu.Lock()
+	e.gauges[name] = observe
+	e.mu.Unlock()
+}
+
+type memorySpan struct {
+	exp  *InMemoryExporter
+	mu   sync.Mutex
+	data SpanData
+}
+
+func (s *memorySpan) SetAttributes(attrs ...Attribute) {
+	s.mu.Lock()
+	s.data.Attributes = append(s.data.Attributes, attrs...)
+	s.mu.Unlock()
+}
+
+func (s *memorySpan) RecordError(err error) {
+	s.mu.Lock()
+	s.data.Err = err
+	s.mu.Unlock()
+}
+
+func (s *memorySpan) End() {
+	s.mu.Lock()
+	s.data.EndTime = time.Now()
+	data := s.data
+	s.mu.Unlock()
+
+	s.exp.mu.Lock()
+	s.exp.spans = append(s.exp.spans, data)
+	s.exp.mu.Unlock()
+}
+
+type memoryHistogram struct {
+	exp  *InMemoryExporter
+	name string
+}
+
+func (h memoryHistogram) Record(ctx context.Context, value float64, attrs ...Attribute) {
+	h.exp.mu.Lock()
+	h.exp.measurements[h.name] = append(h.exp.measurements[h.name], Measurement{
+		Value:      value,
+		Attributes: append([]Attribute(nil), attrs...),
+	})
+	h.exp.mu.Unlock()
+}
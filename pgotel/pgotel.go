@@ -0,0 +1,232 @@
+/*
+Package pgotel instruments go-pg with tracing and metrics that follow
+the OpenTelemetry semantic conventions for database clients.
+
+The package does not depend on OpenTelemetry. Tracer, Span and Meter
+have the shape of the OpenTelemetry API, so a few lines of glue code
+connect them to an OpenTelemetry SDK or any other backend.
+InMemoryExporter implements them for tests.
+
+    exp := pgotel.NewInMemoryExporter()
+    db.AddQueryHook(pgotel.NewQueryHook(exp, exp))
+    pgotel.ReportPoolStats(db, exp)
+
+Spans are named by the operation and the table, e.g. "SELECT books".
+*/
+package pgotel
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/go-pg/pg/v9"
+	"github.com/go-pg/pg/v9/orm"
+)
+
+// Attribute keys used for spans and metrics.
+const (
+	DBSystemKey       = "db.system"
+	DBStatementKey    = "db.statement"
+	DBOperationKey    = "db.operation"
+	DBSQLTableKey     = "db.sql.table"
+	DBRowsAffectedKey = "db.rows_affected"
+	DBRowsReturnedKey = "db.rows_returned"
+)
+
+// QueryDurationMetric is the name of the query latency histogram.
+const QueryDurationMetric = "db.client.operation.duration"
+
+// Formatted queries longer than this are truncated in db.statement.
+const maxStatementLen = 5000
+
+// Attribute is a key/value pair attached to spans and measurements.
+type Attribute struct {
+	Key   string
+	Value interface{}
+}
+
+// Tracer starts spans.
+type Tracer interface {
+	Start(ctx context.Context, name string, attrs ...Attribute) (context.Context, Span)
+}
+
+// Span is an operation started by Tracer.
+type Span interface {
+	SetAttributes(attrs ...Attribute)
+	RecordError(err error)
+	End()
+}
+
+// Meter creates metric instruments.
+type Meter interface {
+	Float64Histogram(name, unit, description string) Float64Histogram
+	// Int64ObservableGauge registers a gauge whose value is read
+	// by calling observe when metrics are collected.
+	Int64ObservableGauge(name, unit, description string, observe func() int64)
+}
+
+// Float64Histogram records a distribution of values.
+type Float64Histogram interface {
+	Record(ctx context.Context, value float64, attrs ...Attribute)
+}
+
+//------------------------------------------------------------------------------
+
+type spanKey struct{}
+
+// QueryHook is a pg.QueryHook that traces queries and records their
+// latency. Spans have db.system, db.operation, db.sql.table, db.statement
+// and db.rows_affected attributes.
+type QueryHook struct {
+	tracer  Tracer
+	latency Float64Histogram
+}
+
+var _ pg.QueryHook = (*QueryHook)(nil)
+
+// NewQueryHook returns a hook that starts spans using the tracer and
+// records the query duration in seconds using the meter.
+// Either of them can be nil.
+func NewQueryHook(tracer Tracer, meter Meter) *QueryHook {
+	h := &QueryHook{
+		tracer: tracer,
+	}
+	if meter != nil {
+		h.latency = meter.Float64Histogram(
+			QueryDurationMetric, "s", "Duration of database client queries.")
+	}
+	return h
+}
+
+func (h *QueryHook) BeforeQuery(c context.Context, evt *pg.QueryEvent) (context.Context, error) {
+	if h.tracer == nil {
+		return c, nil
+	}
+
+	op, table := queryInfo(evt)
+	name := string(op)
+	if name == "" {
+		name = "query"
+	}
+	if table != "" {
+		name += " " + table
+	}
+
+	attrs := append(baseAttrs(op, table), Attribute{DBStatementKey, queryStatement(evt)})
+	c, span := h.tracer.Start(c, name, attrs...)
+	return context.WithValue(c, spanKey{}, span), nil
+}
+
+func (h *QueryHook) AfterQuery(c context.Context, evt *pg.QueryEvent) error {
+	if span, ok := c.Value(spanKey{}).(Span); ok {
+		if evt.Result != nil {
+			if n := evt.Result.RowsAffected(); n >= 0 {
+				span.SetAttributes(Attribute{DBRowsAffectedKey, n})
+			}
+			span.SetAttributes(Attribute{DBRowsReturnedKey, evt.Result.RowsReturned()})
+		}
+		if isError(evt.Err) {
+			span.RecordError(evt.Err)
+		}
+		span.End()
+	}
+
+	if h.latency != nil {
+		op, table := queryInfo(evt)
+		h.latency.Record(c, time.Since(evt.StartTime).Seconds(), baseAttrs(op, table)...)
+	}
+
+	return nil
+}
+
+func baseAttrs(op orm.QueryOp, table string) []Attribute {
+	attrs := []Attribute{{DBSystemKey, "postgresql"}}
+	if op != "" {
+		attrs = append(attrs, Attribute{DBOperationKey, string(op)})
+	}
+	if table != "" {
+		attrs = append(attrs, Attribute{DBSQLTableKey, table})
+	}
+	return attrs
+}
+
+// queryInfo returns the operation of the query and the table of its model.
+func queryInfo(evt *pg.QueryEvent) (orm.QueryOp, string) {
+	op := evt.Operation()
+
+	q, ok := evt.Query.(interface{ Query() *orm.Query })
+	if !ok {
+		return op, ""
+	}
+	tm := q.Query().TableModel()
+	if tm == nil {
+		return op, ""
+	}
+	return op, strings.Replace(string(tm.Table().FullName), `"`, "", -1)
+}
+
+func queryStatement(evt *pg.QueryEvent) string {
+	query, err := evt.FormattedQuery()
+	if err != nil {
+		query, _ = evt.UnformattedQuery()
+	}
+	if len(query) > maxStatementLen {
+		query = query[:maxStatementLen]
+	}
+	return query
+}
+
+// isError reports whether err should be recorded on the span.
+// ErrNoRows and ErrMultiRows are expected results rather than failures.
+func isError(err error) bool {
+	return err != nil && err != pg.ErrNoRows && err != pg.ErrMultiRows
+}
+
+//------------------------------------------------------------------------------
+
+// ReportPoolStats registers gauges that report the connection pool
+// stats of the database, e.g. total, idle and in-use connections,
+// waits for a free connection and closed connections by reason.
+func ReportPoolStats(db *pg.DB, meter Meter) {
+	gauges := []struct {
+		name string
+		unit string
+		desc string
+		stat func(*pg.PoolStats) int64
+	}{
+		{"hits", "{request}", "Number of times a free connection was found in the pool.",
+			func(s *pg.PoolStats) int64 { return int64(s.Hits) }},
+		{"misses", "{request}", "Number of times a free connection was not found in the pool.",
+			func(s *pg.PoolStats) int64 { return int64(s.Misses) }},
+		{"timeouts", "{request}", "Number of times a wait for a connection timed out.",
+			func(s *pg.PoolStats) int64 { return int64(s.Timeouts) }},
+		{"wait_count", "{request}", "Number of times a query waited for a free connection.",
+			func(s *pg.PoolStats) int64 { return int64(s.WaitCount) }},
+		{"wait_duration", "ms", "Total time queries waited for a free connection.",
+			func(s *pg.PoolStats) int64 { return int64(s.WaitDuration / time.Millisecond) }},
+		{"total", "{connection}", "Number of connections in the pool.",
+			func(s *pg.PoolStats) int64 { return int64(s.TotalConns) }},
+		{"idle", "{connection}", "Number of idle connections in the pool.",
+			func(s *pg.PoolStats) int64 { return int64(s.IdleConns) }},
+		{"in_use", "{connection}", "Number of connections in use.",
+			func(s *pg.PoolStats) int64 { return int64(s.InUseConns) }},
+		{"stale", "{connection}", "Number of stale connections removed from the pool.",
+			func(s *pg.PoolStats) int64 { return int64(s.StaleConns) }},
+		{"dial_errors", "{error}", "Number of failed attempts to dial a connection.",
+			func(s *pg.PoolStats) int64 { return int64(s.DialErrors) }},
+		{"idle_closed", "{connection}", "Number of connections closed because of IdleTimeout.",
+			func(s *pg.PoolStats) int64 { return int64(s.IdleClosed) }},
+		{"max_age_closed", "{connection}", "Number of connections closed because of MaxConnAge.",
+			func(s *pg.PoolStats) int64 { return int64(s.MaxAgeClosed) }},
+		{"bad_conn_closed", "{connection}", "Number of connections removed because of an error.",
+			func(s *pg.PoolStats) int64 { return int64(s.BadConnClosed) }},
+	}
+	for _, g := range gauges {
+		stat := g.stat
+		meter.Int64ObservableGauge("db.client.connections."+g.name, g.unit, g.desc,
+			func() int64 {
+				return stat(db.PoolStats())
+			})
+	}
+}
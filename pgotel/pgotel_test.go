@@ -0,0 +1,182 @@
+package pgotel_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-pg/pg/v9"
+	"github.com/go-pg/pg/v9/orm"
+	"github.com/go-pg/pg/v9/pgotel"
+)
+
+type Book struct {
+	ID    int
+	Title string
+}
+
+var errCaptured = errors.New("captured")
+
+// captureHook saves the query event and stops the query before
+// a connection is used.
+type captureHook struct {
+	evt *pg.QueryEvent
+}
+
+func (h *captureHook) BeforeQuery(c context.Context, evt *pg.QueryEvent) (context.Context, error) {
+	h.evt = evt
+	return c, errCaptured
+}
+
+func (h *captureHook) AfterQuery(c context.Context, evt *pg.QueryEvent) error {
+	return nil
+}
+
+type result struct {
+	affected, returned int
+}
+
+func (res result) Model() orm.Model  { return nil }
+func (res result) RowsAffected() int { return res.affected }
+func (res result) RowsReturned() int { return res.returned }
+
+func runHook(
+	t *testing.T, hook *pgotel.QueryHook, evt *pg.QueryEvent, res pg.Result, err error,
+) {
+	c, herr := hook.BeforeQuery(context.Background(), evt)
+	if herr != nil {
+		t.Fatal(herr)
+	}
+	evt.Result = res
+	evt.Err = err
+	if herr := hook.AfterQuery(c, evt); herr != nil {
+		t.Fatal(herr)
+	}
+}
+
+func TestQueryHook(t *testing.T) {
+	db := pg.Connect(&pg.Options{
+		Addr: "localhost:1",
+	})
+	defer db.Close()
+
+	exp := pgotel.NewInMemoryExporter()
+	hook := pgotel.NewQueryHook(exp, exp)
+
+	hookedDB := db.WithContext(context.Background())
+	capture := new(captureHook)
+	hookedDB.AddQueryHook(capture)
+
+	err := hookedDB.Model(new(Book)).Where("id = ?", 1).Select()
+	if err != errCaptured {
+		t.Fatalf("got %v, wanted %v", err, errCaptured)
+	}
+	runHook(t, hook, capture.evt, result{affected: 1, returned: 1}, nil)
+
+	_, err = hookedDB.Exec("  with t as (select 1) delete from books where id = ?", 2)
+	if err != errCaptured {
+		t.Fatalf("got %v, wanted %v", err, errCaptured)
+	}
+	runHook(t, hook, capture.evt, nil, errors.New("boom"))
+
+	_, err = hookedDB.Model(new(Book)).Where("id = ?", 3).Update()
+	if err != errCaptured {
+		t.Fatalf("got %v, wanted %v", err, errCaptured)
+	}
+	runHook(t, hook, capture.evt, result{affected: 0}, pg.ErrNoRows)
+
+	spans := exp.Spans()
+	if len(spans) != 3 {
+		t.Fatalf("got %d spans, wanted 3", len(spans))
+	}
+
+	span := spans[0]
+	if span.Name != "SELECT books" {
+		t.Fatalf("got %q, wanted SELECT books", span.Name)
+	}
+	wanted := map[string]interface{}{
+		pgotel.DBSystemKey:       "postgresql",
+		pgotel.DBOperationKey:    "SELECT",
+		pgotel.DBSQLTableKey:     "books",
+		pgotel.DBStatementKey:    `SELECT "book"."id", "book"."title" FROM "books" AS "book" WHERE (id = 1)`,
+		pgotel.DBRowsAffectedKey: 1,
+		pgotel.DBRowsReturnedKey: 1,
+	}
+	for key, value := range wanted {
+		if got, _ := span.Attribute(key); got != value {
+			t.Fatalf("%s: got %#v, wanted %#v", key, got, value)
+		}
+	}
+	if span.Err != nil {
+		t.Fatal(span.Err)
+	}
+	if span.EndTime.Before(span.StartTime) {
+		t.Fatalf("span ended before it started")
+	}
+
+	span = spans[1]
+	if span.Name != "WITH" {
+		t.Fatalf("got %q, wanted WITH", span.Name)
+	}
+	if got, _ := span.Attribute(pgotel.DBStatementKey); got != "  with t as (select 1) delete from books where id = 2" {
+		t.Fatalf("got %q", got)
+	}
+	if _, ok := span.Attribute(pgotel.DBSQLTableKey); ok {
+		t.Fatalf("span of a raw query has a table")
+	}
+	if span.Err == nil || span.Err.Error() != "boom" {
+		t.Fatalf("got %v, wanted boom", span.Err)
+	}
+
+	span = spans[2]
+	if span.Name != "UPDATE books" {
+		t.Fatalf("got %q, wanted UPDATE books", span.Name)
+	}
+	if span.Err != nil {
+		t.Fatalf("ErrNoRows is recorded: %v", span.Err)
+	}
+
+	measurements := exp.Measurements(pgotel.QueryDurationMetric)
+	if len(measurements) != 3 {
+		t.Fatalf("got %d measurements, wanted 3", len(measurements))
+	}
+	for _, m := range measurements {
+		if m.Value < 0 || m.Value > time.Minute.Seconds() {
+			t.Fatalf("unexpected duration %v", m.Value)
+		}
+	}
+	attrs := measurements[2].Attributes
+	if len(attrs) != 3 || attrs[1].Value != "UPDATE" || attrs[2].Value != "books" {
+		t.Fatalf("got %v", attrs)
+	}
+}
+
+func TestReportPoolStats(t *testing.T) {
+	db := pg.Connect(&pg.Options{
+		Addr: "localhost:1",
+	})
+	defer db.Close()
+
+	exp := pgotel.NewInMemoryExporter()
+	pgotel.ReportPoolStats(db, exp)
+
+	gauges := exp.Gauges()
+	names := []string{
+		"hits", "misses", "timeouts", "wait_count", "wait_duration",
+		"total", "idle", "in_use", "stale", "dial_errors",
+		"idle_closed", "max_age_closed", "bad_conn_closed",
+	}
+	if len(gauges) != len(names) {
+		t.Fatalf("got %v", gauges)
+	}
+	for _, name := range names {
+		value, ok := gauges["db.client.connections."+name]
+		if !ok {
+			t.Fatalf("%s gauge is not registered", name)
+		}
+		if value != 0 {
+			t.Fatalf("%s: got %d, wanted 0", name, value)
+		}
+	}
+}
@@ -0,0 +1,405 @@
+/*
+Package pgoutput decodes messages of the pgoutput logical decoding
+output plugin received with pg.ReplicationConn.
+
+	rc, err := db.Replication()
+	...
+	err = rc.StartReplication("slot", lsn, pgoutput.PluginArgs("publication")...)
+	...
+	var dec pgoutput.Decoder
+	for {
+		msg, err := rc.ReceiveMessage()
+		...
+		xld, ok := msg.(*pg.XLogData)
+		if !ok {
+			continue
+		}
+
+		m, err := dec.Decode(xld.WALData)
+		...
+		if insert, ok := m.(*pgoutput.Insert); ok {
+			book := new(Book)
+			err := insert.New.Scan(dec.Relation(insert.RelationID), book)
+			...
+		}
+	}
+*/
+package pgoutput
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-pg/pg/v9"
+	"github.com/go-pg/pg/v9/internal"
+)
+
+var errShortMessage = errors.New("pgoutput: message is too short")
+
+// PluginArgs returns arguments of the pgoutput plugin for
+// pg.ReplicationConn.StartReplication.
+func PluginArgs(publications ...string) []string {
+	quoted := make([]string, len(publications))
+	for i, pub := range publications {
+		quoted[i] = `"` + strings.Replace(pub, `"`, `""`, -1) + `"`
+	}
+	names := strings.Replace(strings.Join(quoted, ","), "'", "''", -1)
+	return []string{
+		"proto_version '1'",
+		"publication_names '" + names + "'",
+	}
+}
+
+// Message is a decoded pgoutput message.
+type Message interface {
+	// MessageType returns the message type byte, e.g. 'I' for Insert.
+	MessageType() byte
+}
+
+// Begin marks the beginning of a transaction.
+type Begin struct {
+	FinalLSN   pg.LSN
+	CommitTime time.Time
+	XID        uint32
+}
+
+func (*Begin) MessageType() byte { return 'B' }
+
+// Commit marks the end of a transaction.
+type Commit struct {
+	Flags      uint8
+	LSN        pg.LSN
+	EndLSN     pg.LSN
+	CommitTime time.Time
+}
+
+func (*Commit) MessageType() byte { return 'C' }
+
+// Origin reports the origin of the transaction.
+type Origin struct {
+	LSN  pg.LSN
+	Name string
+}
+
+func (*Origin) MessageType() byte { return 'O' }
+
+// Relation describes a table. It is sent before the first change
+// of the table in the stream and after the table definition changes.
+type Relation struct {
+	ID              uint32
+	Namespace       string
+	Name            string
+	ReplicaIdentity uint8
+	Columns         []Column
+}
+
+func (*Relation) MessageType() byte { return 'R' }
+
+// Column describes a column of the relation.
+type Column struct {
+	// Key is true when the column is part of the replica identity.
+	Key     bool
+	Name    string
+	TypeOID uint32
+	TypeMod int32
+}
+
+// Type describes a custom data type used by the relation.
+type Type struct {
+	ID        uint32
+	Namespace string
+	Name      string
+}
+
+func (*Type) MessageType() byte { return 'Y' }
+
+// Insert is a row inserted into the relation.
+type Insert struct {
+	RelationID uint32
+	New        *Tuple
+}
+
+func (*Insert) MessageType() byte { return 'I' }
+
+// Update is a row updated in the relation. Old contains the key columns
+// or the whole old row depending on the replica identity of the relation.
+// It is nil when the key has not changed.
+type Update struct {
+	RelationID uint32
+	Old        *Tuple
+	New        *Tuple
+}
+
+func (*Update) MessageType() byte { return 'U' }
+
+// Delete is a row deleted from the relation. Old contains the key columns
+// or the whole old row depending on the replica identity of the relation.
+type Delete struct {
+	RelationID uint32
+	Old        *Tuple
+}
+
+func (*Delete) MessageType() byte { return 'D' }
+
+// Truncate is a truncation of the relations.
+type Truncate struct {
+	Options     uint8
+	RelationIDs []uint32
+}
+
+func (*Truncate) MessageType() byte { return 'T' }
+
+// Tuple column kinds.
+const (
+	NullColumn      = 'n'
+	UnchangedColumn = 'u'
+	TextColumn      = 't'
+)
+
+// Tuple is a row of the relation.
+type Tuple struct {
+	Columns []TupleColumn
+}
+
+// TupleColumn is a column value in text format. Value is nil
+// for NULL and unchanged TOASTed values.
+type TupleColumn struct {
+	Kind  byte
+	Value []byte
+}
+
+// Decoder decodes pgoutput messages and keeps track of the relations.
+// The zero value is ready to use.
+type Decoder struct {
+	relations map[uint32]*Relation
+}
+
+// Decode decodes the message. Relation messages are remembered
+// and can be retrieved with Relation later.
+func (d *Decoder) Decode(b []byte) (Message, error) {
+	msg, err := Parse(b)
+	if err != nil {
+		return nil, err
+	}
+
+	if rel, ok := msg.(*Relation); ok {
+		if d.relations == nil {
+			d.relations = make(map[uint32]*Relation)
+		}
+		d.relations[rel.ID] = rel
+	}
+
+	return msg, nil
+}
+
+// Relation returns the relation with the id or nil.
+func (d *Decoder) Relation(id uint32) *Relation {
+	return d.relations[id]
+}
+
+// Parse decodes the message.
+func Parse(b []byte) (Message, error) {
+	if len(b) == 0 {
+		return nil, errShortMessage
+	}
+
+	r := &reader{b: b[1:]}
+	var msg Message
+	switch b[0] {
+	case 'B':
+		msg = &Begin{
+			FinalLSN:   pg.LSN(r.uint64()),
+			CommitTime: r.time(),
+			XID:        r.uint32(),
+		}
+	case 'C':
+		msg = &Commit{
+			Flags:      r.uint8(),
+			LSN:        pg.LSN(r.uint64()),
+			EndLSN:     pg.LSN(r.uint64()),
+			CommitTime: r.time(),
+		}
+	case 'O':
+		msg = &Origin{
+			LSN:  pg.LSN(r.uint64()),
+			Name: r.string(),
+		}
+	case 'R':
+		msg = r.relation()
+	case 'Y':
+		msg = &Type{
+			ID:        r.uint32(),
+			Namespace: r.string(),
+			Name:      r.string(),
+		}
+	case 'I':
+		m := &Insert{
+			RelationID: r.uint32(),
+		}
+		if kind := r.uint8(); kind != 'N' && r.err == nil {
+			return nil, fmt.Errorf("pgoutput: unexpected Insert tuple %q", kind)
+		}
+		m.New = r.tuple()
+		msg = m
+	case 'U':
+		m := &Update{
+			RelationID: r.uint32(),
+		}
+		kind := r.uint8()
+		if kind == 'K' || kind == 'O' {
+			m.Old = r.tuple()
+			kind = r.uint8()
+		}
+		if kind != 'N' && r.err == nil {
+			return nil, fmt.Errorf("pgoutput: unexpected Update tuple %q", kind)
+		}
+		m.New = r.tuple()
+		msg = m
+	case 'D':
+		m := &Delete{
+			RelationID: r.uint32(),
+		}
+		if kind := r.uint8(); kind != 'K' && kind != 'O' && r.err == nil {
+			return nil, fmt.Errorf("pgoutput: unexpected Delete tuple %q", kind)
+		}
+		m.Old = r.tuple()
+		msg = m
+	case 'T':
+		n := int(r.uint32())
+		m := &Truncate{
+			Options: r.uint8(),
+		}
+		for i := 0; i < n && r.err == nil; i++ {
+			m.RelationIDs = append(m.RelationIDs, r.uint32())
+		}
+		msg = m
+	default:
+		return nil, fmt.Errorf("pgoutput: unknown message type %q", b[0])
+	}
+
+	if r.err != nil {
+		return nil, r.err
+	}
+	return msg, nil
+}
+
+type reader struct {
+	b   []byte
+	err error
+}
+
+func (r *reader) next(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if len(r.b) < n {
+		r.err = errShortMessage
+		return nil
+	}
+	b := r.b[:n]
+	r.b = r.b[n:]
+	return b
+}
+
+func (r *reader) uint8() uint8 {
+	b := r.next(1)
+	if b == nil {
+		return 0
+	}
+	return b[0]
+}
+
+func (r *reader) uint16() uint16 {
+	b := r.next(2)
+	if b == nil {
+		return 0
+	}
+	return binary.BigEndian.Uint16(b)
+}
+
+func (r *reader) uint32() uint32 {
+	b := r.next(4)
+	if b == nil {
+		return 0
+	}
+	return binary.BigEndian.Uint32(b)
+}
+
+func (r *reader) uint64() uint64 {
+	b := r.next(8)
+	if b == nil {
+		return 0
+	}
+	return binary.BigEndian.Uint64(b)
+}
+
+func (r *reader) time() time.Time {
+	usec := int64(r.uint64())
+	return internal.PGEpoch.Add(time.Duration(usec) * time.Microsecond)
+}
+
+func (r *reader) string() string {
+	if r.err != nil {
+		return ""
+	}
+	for i, c := range r.b {
+		if c == 0 {
+			s := string(r.b[:i])
+			r.b = r.b[i+1:]
+			return s
+		}
+	}
+	r.err = errShortMessage
+	return ""
+}
+
+func (r *reader) relation() *Relation {
+	rel := &Relation{
+		ID:              r.uint32(),
+		Namespace:       r.string(),
+		Name:            r.string(),
+		ReplicaIdentity: r.uint8(),
+	}
+
+	n := int(r.uint16())
+	for i := 0; i < n && r.err == nil; i++ {
+		rel.Columns = append(rel.Columns, Column{
+			Key:     r.uint8()&1 == 1,
+			Name:    r.string(),
+			TypeOID: r.uint32(),
+			TypeMod: int32(r.uint32()),
+		})
+	}
+
+	return rel
+}
+
+func (r *reader) tuple() *Tuple {
+	n := int(r.uint16())
+	t := &Tuple{
+		Columns: make([]TupleColumn, 0, n),
+	}
+	for i := 0; i < n && r.err == nil; i++ {
+		col := TupleColumn{
+			Kind: r.uint8(),
+		}
+		switch col.Kind {
+		case NullColumn, UnchangedColumn:
+		case TextColumn:
+			size := int(r.uint32())
+			if b := r.next(size); b != nil {
+				col.Value = make([]byte, size)
+				copy(col.Value, b)
+			}
+		default:
+			if r.err == nil {
+				r.err = fmt.Errorf("pgoutput: unknown tuple column kind %q", col.Kind)
+			}
+		}
+		t.Columns = append(t.Columns, col)
+	}
+	return t
+}
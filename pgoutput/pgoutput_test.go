@@ -0,0 +1,174 @@
+package pgoutput_test
+
+import (
+	"encoding/binary"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/go-pg/pg/v9"
+	"github.com/go-pg/pg/v9/pgoutput"
+)
+
+type msgBuilder []byte
+
+func (b msgBuilder) byte(c byte) msgBuilder {
+	return append(b, c)
+}
+
+func (b msgBuilder) uint16(n uint16) msgBuilder {
+	return append(b, byte(n>>8), byte(n))
+}
+
+func (b msgBuilder) uint32(n uint32) msgBuilder {
+	return append(b, byte(n>>24), byte(n>>16), byte(n>>8), byte(n))
+}
+
+func (b msgBuilder) uint64(n uint64) msgBuilder {
+	b = append(b, 0, 0, 0, 0, 0, 0, 0, 0)
+	binary.BigEndian.PutUint64(b[len(b)-8:], n)
+	return b
+}
+
+func (b msgBuilder) string(s string) msgBuilder {
+	return append(append(b, s...), 0)
+}
+
+func (b msgBuilder) text(s string) msgBuilder {
+	return append(b.byte('t').uint32(uint32(len(s))), s...)
+}
+
+func relationMsg() []byte {
+	return msgBuilder{'R'}.
+		uint32(16385).
+		string("public").
+		string("books").
+		byte('d').
+		uint16(3).
+		byte(1).string("id").uint32(23).uint32(0xffffffff).
+		byte(0).string("title").uint32(25).uint32(0xffffffff).
+		byte(0).string("author_id").uint32(23).uint32(0xffffffff)
+}
+
+type Book struct {
+	Id       int
+	Title    string
+	AuthorID *int
+}
+
+func TestParseRelation(t *testing.T) {
+	msg, err := pgoutput.Parse(relationMsg())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wanted := &pgoutput.Relation{
+		ID:              16385,
+		Namespace:       "public",
+		Name:            "books",
+		ReplicaIdentity: 'd',
+		Columns: []pgoutput.Column{
+			{Key: true, Name: "id", TypeOID: 23, TypeMod: -1},
+			{Name: "title", TypeOID: 25, TypeMod: -1},
+			{Name: "author_id", TypeOID: 23, TypeMod: -1},
+		},
+	}
+	if !reflect.DeepEqual(msg, wanted) {
+		t.Fatalf("got %#v, wanted %#v", msg, wanted)
+	}
+}
+
+func TestParseBeginCommit(t *testing.T) {
+	tm := time.Date(2019, time.March, 4, 5, 6, 7, 0, time.UTC)
+	usec := uint64(tm.Sub(time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)) / time.Microsecond)
+
+	msg, err := pgoutput.Parse(msgBuilder{'B'}.uint64(0x16B3748).uint64(usec).uint32(571))
+	if err != nil {
+		t.Fatal(err)
+	}
+	begin := msg.(*pgoutput.Begin)
+	if begin.FinalLSN != pg.LSN(0x16B3748) || !begin.CommitTime.Equal(tm) || begin.XID != 571 {
+		t.Fatalf("got %#v", begin)
+	}
+
+	msg, err = pgoutput.Parse(
+		msgBuilder{'C'}.byte(0).uint64(0x16B3748).uint64(0x16B3778).uint64(usec))
+	if err != nil {
+		t.Fatal(err)
+	}
+	commit := msg.(*pgoutput.Commit)
+	if commit.LSN != pg.LSN(0x16B3748) || commit.EndLSN != pg.LSN(0x16B3778) {
+		t.Fatalf("got %#v", commit)
+	}
+}
+
+func TestDecodeAndScan(t *testing.T) {
+	var dec pgoutput.Decoder
+	if _, err := dec.Decode(relationMsg()); err != nil {
+		t.Fatal(err)
+	}
+
+	insert := msgBuilder{'I'}.uint32(16385).byte('N').
+		uint16(3).text("1").text("Hello").byte('n')
+	msg, err := dec.Decode(insert)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ins := msg.(*pgoutput.Insert)
+
+	book := new(Book)
+	if err := ins.New.Scan(dec.Relation(ins.RelationID), book); err != nil {
+		t.Fatal(err)
+	}
+	if book.Id != 1 || book.Title != "Hello" || book.AuthorID != nil {
+		t.Fatalf("got %#v", book)
+	}
+
+	update := msgBuilder{'U'}.uint32(16385).
+		byte('K').uint16(3).text("1").byte('n').byte('n').
+		byte('N').uint16(3).text("2").byte('u').text("10")
+	msg, err = dec.Decode(update)
+	if err != nil {
+		t.Fatal(err)
+	}
+	upd := msg.(*pgoutput.Update)
+	if upd.Old == nil {
+		t.Fatal("Old tuple is nil")
+	}
+	if err := upd.New.Scan(dec.Relation(upd.RelationID), book); err != nil {
+		t.Fatal(err)
+	}
+	if book.Id != 2 || book.Title != "Hello" || book.AuthorID == nil || *book.AuthorID != 10 {
+		t.Fatalf("got %#v", book)
+	}
+
+	del := msgBuilder{'D'}.uint32(16385).
+		byte('K').uint16(3).text("2").byte('n').byte('n')
+	msg, err = dec.Decode(del)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.(*pgoutput.Delete).Old.Columns[0].Kind != pgoutput.TextColumn {
+		t.Fatalf("got %#v", msg)
+	}
+}
+
+func TestParseTruncated(t *testing.T) {
+	b := relationMsg()
+	for i := 1; i < len(b); i++ {
+		if _, err := pgoutput.Parse(b[:i]); err == nil {
+			t.Fatalf("expected an error for %d bytes", i)
+		}
+	}
+}
+
+func TestPluginArgs(t *testing.T) {
+	got := pgoutput.PluginArgs("pub1", "it's")
+	wanted := []string{
+		"proto_version '1'",
+		`publication_names '"pub1","it''s"'`,
+	}
+	if !reflect.DeepEqual(got, wanted) {
+		t.Fatalf("got %q, wanted %q", got, wanted)
+	}
+}
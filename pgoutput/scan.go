@@ -0,0 +1,52 @@
+package pgoutput
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/go-pg/pg/v9/orm"
+	"github.com/go-pg/pg/v9/types"
+)
+
+// Scan scans the tuple of the relation into the struct model using
+// the ORM table of the model. Columns that don't have a matching field
+// and unchanged TOASTed columns are skipped.
+func (t *Tuple) Scan(rel *Relation, model interface{}) error {
+	if rel == nil {
+		return fmt.Errorf("pgoutput: Scan(nil relation)")
+	}
+	if len(t.Columns) != len(rel.Columns) {
+		return fmt.Errorf("pgoutput: tuple has %d columns, relation %s has %d",
+			len(t.Columns), rel.Name, len(rel.Columns))
+	}
+
+	v := reflect.ValueOf(model)
+	if !v.IsValid() || v.Kind() != reflect.Ptr || v.IsNil() {
+		return fmt.Errorf("pgoutput: Scan(non-pointer %T)", model)
+	}
+	strct := v.Elem()
+	if strct.Kind() != reflect.Struct {
+		return fmt.Errorf("pgoutput: Scan(unsupported %T)", model)
+	}
+
+	table := orm.GetTable(strct.Type())
+	for i, col := range t.Columns {
+		field, ok := table.FieldsMap[rel.Columns[i].Name]
+		if !ok {
+			continue
+		}
+
+		var err error
+		switch col.Kind {
+		case NullColumn:
+			err = field.ScanValue(strct, types.NewBytesReader(nil), -1)
+		case TextColumn:
+			err = field.ScanValue(strct, types.NewBytesReader(col.Value), len(col.Value))
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
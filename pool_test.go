@@ -138,7 +138,7 @@ func (t *PoolTest) TestClosedStmt(c *C) {
 	c.Assert(err, IsNil)
 
 	c.Assert(t.db.Pool().Len(), Equals, 1)
-	c.Assert(t.db.Pool().IdleLen(), Equals, 0)
+	c.Assert(t.db.Pool().IdleLen(), Equals, 1)
 
 	c.Assert(stmt.Close(), IsNil)
 
@@ -0,0 +1,179 @@
+package pg
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-pg/pg/v9/internal/pool"
+	"github.com/go-pg/pg/v9/orm"
+)
+
+type replica struct {
+	addr string
+	opt  *Options
+	pool pool.Pooler
+
+	_healthy uint32
+	_downAt  int64
+}
+
+func (r *replica) healthy() bool {
+	return atomic.LoadUint32(&r._healthy) == 1
+}
+
+func (r *replica) setHealthy(ok bool) {
+	var n uint32
+	if ok {
+		n = 1
+	}
+	if !ok {
+		atomic.StoreInt64(&r._downAt, time.Now().UnixNano())
+	}
+	old := atomic.SwapUint32(&r._healthy, n)
+	if old != n && !ok {
+		r.opt.logger().Log(context.TODO(), LogWarn, "replica is down", "addr", r.addr)
+	}
+}
+
+// downFor returns how long ago the replica was marked down.
+func (r *replica) downFor() time.Duration {
+	return time.Since(time.Unix(0, atomic.LoadInt64(&r._downAt)))
+}
+
+// replicaRetryInterval is how long a replica that is down is skipped
+// when health checks are disabled.
+var replicaRetryInterval = 5 * time.Second
+
+// replicaSet is a set of read replicas shared by the DB and its copies.
+type replicaSet struct {
+	replicas []*replica
+	next     uint32
+	// retry is the interval after which a replica that is down is tried
+	// again. It is zero when health checks mark replicas up.
+	retry time.Duration
+
+	closeOnce sync.Once
+	exit      chan struct{}
+}
+
+func newReplicaSet(opt *Options) *replicaSet {
+	rs := &replicaSet{
+		replicas: make([]*replica, len(opt.Replicas)),
+		exit:     make(chan struct{}),
+	}
+	if opt.ReplicaCheckFrequency <= 0 {
+		rs.retry = replicaRetryInterval
+	}
+	for i, addr := range opt.Replicas {
+		ropt := *opt
+		ropt.Addr = addr
+		ropt.FallbackAddrs = nil
+		ropt.LoadBalanceHosts = false
+		ropt.TargetSessionAttrs = ""
+		rs.replicas[i] = &replica{
+			addr:     addr,
+			opt:      &ropt,
+			pool:     newConnPool(&ropt),
+			_healthy: 1,
+		}
+	}
+	return rs
+}
+
+// pick returns the next healthy replica using round-robin or nil
+// when all replicas are down. Without health checks replicas that were
+// down for the retry interval are considered healthy again.
+func (rs *replicaSet) pick() *replica {
+	n := uint32(len(rs.replicas))
+	start := atomic.AddUint32(&rs.next, 1)
+	for i := uint32(0); i < n; i++ {
+		r := rs.replicas[(start+i)%n]
+		if r.healthy() || rs.retry > 0 && r.downFor() >= rs.retry {
+			return r
+		}
+	}
+	return nil
+}
+
+// checkHealth periodically pings replicas so that replicas that are down
+// are skipped and replicas that are back up are used again.
+func (rs *replicaSet) checkHealth(db *baseDB, frequency time.Duration) {
+	ticker := time.NewTicker(frequency)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			for _, r := range rs.replicas {
+				r.setHealthy(db.pingReplica(r) == nil)
+			}
+		case <-rs.exit:
+			return
+		}
+	}
+}
+
+func (rs *replicaSet) Close() error {
+	var firstErr error
+	rs.closeOnce.Do(func() {
+		close(rs.exit)
+		for _, r := range rs.replicas {
+			if err := r.pool.Close(); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+	})
+	return firstErr
+}
+
+func (db *baseDB) pingReplica(r *replica) error {
+	c := context.Background()
+	if db.opt.ReadTimeout > 0 {
+		var cancel context.CancelFunc
+		c, cancel = context.WithTimeout(c, db.opt.ReadTimeout)
+		defer cancel()
+	}
+
+	rdb := db.withPool(r.pool)
+	return rdb.withConn(c, func(c context.Context, cn *pool.Conn) error {
+		_, err := rdb.simpleQuery(c, cn, "SELECT 1")
+		return err
+	})
+}
+
+// withQueryConn is like withConn, but runs read-only queries on a healthy
+// replica when replicas are configured.
+func (db *baseDB) withQueryConn(
+	c context.Context, readOnly bool, fn func(context.Context, *pool.Conn) error,
+) error {
+	if db.replicas == nil || !readOnly {
+		return db.withConn(c, fn)
+	}
+
+	r := db.replicas.pick()
+	if r == nil {
+		return db.withConn(c, fn)
+	}
+
+	err := db.withPool(r.pool).withConn(c, fn)
+	if isNetworkError(err) {
+		r.setHealthy(false)
+	} else if !r.healthy() {
+		r.setHealthy(true)
+	}
+	return err
+}
+
+// isReadOnlyQuery reports whether the query can be run on a replica.
+// Queries built with orm.Query are checked with orm.IsReadOnly and
+// other queries are considered read-only when raw is true.
+func isReadOnlyQuery(query interface{}, raw bool) bool {
+	switch query.(type) {
+	case *orm.Query, interface{ Query() *orm.Query }:
+		return orm.IsReadOnly(query)
+	default:
+		return raw
+	}
+}
@@ -0,0 +1,151 @@
+package pg_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-pg/pg/v9"
+)
+
+func TestReplicaRouting(t *testing.T) {
+	primary := newQueryServer(t)
+	defer primary.Close()
+	replica := newQueryServer(t)
+	defer replica.Close()
+
+	db := pg.Connect(&pg.Options{
+		Addr:                  primary.Addr(),
+		Replicas:              []string{replica.Addr()},
+		ReplicaCheckFrequency: -1,
+	})
+	defer db.Close()
+
+	type Book struct {
+		Id int
+	}
+
+	_, err := db.Model((*Book)(nil)).Exists()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = db.Query(pg.Discard, "SELECT 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = db.Exec("DELETE FROM books")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = db.Model(&Book{}).Returning("*").Insert()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = db.OnPrimary().Query(pg.Discard, "SELECT 2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var books []Book
+	err = db.Model(&books).For("UPDATE").Select()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wanted := []string{
+		`SELECT 1 FROM "books" AS "book" LIMIT 1`,
+		`SELECT 1`,
+	}
+	if got := replica.Queries(); !equalStrings(got, wanted) {
+		t.Fatalf("replica got %q, wanted %q", got, wanted)
+	}
+
+	wanted = []string{
+		`DELETE FROM books`,
+		`INSERT INTO "books" ("id") VALUES (DEFAULT) RETURNING *`,
+		`SELECT 2`,
+		`SELECT "book"."id" FROM "books" AS "book" FOR UPDATE`,
+	}
+	if got := primary.Queries(); !equalStrings(got, wanted) {
+		t.Fatalf("primary got %q, wanted %q", got, wanted)
+	}
+}
+
+func TestReplicaDown(t *testing.T) {
+	primary := newQueryServer(t)
+	defer primary.Close()
+	replica := newQueryServer(t)
+	replica.Close()
+
+	db := pg.Connect(&pg.Options{
+		Addr:                  primary.Addr(),
+		Replicas:              []string{replica.Addr()},
+		ReplicaCheckFrequency: -1,
+	})
+	defer db.Close()
+
+	_, err := db.Query(pg.Discard, "SELECT 1")
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+
+	_, err = db.Query(pg.Discard, "SELECT 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := primary.Queries(); !equalStrings(got, []string{"SELECT 1"}) {
+		t.Fatalf("primary got %q", got)
+	}
+}
+
+func TestReplicaRetry(t *testing.T) {
+	defer pg.SetReplicaRetryInterval(50 * time.Millisecond)()
+
+	primary := newQueryServer(t)
+	defer primary.Close()
+	replica := newQueryServer(t)
+	addr := replica.Addr()
+
+	db := pg.Connect(&pg.Options{
+		Addr:                  primary.Addr(),
+		Replicas:              []string{addr},
+		ReplicaCheckFrequency: -1,
+	})
+	defer db.Close()
+
+	_, err := db.Query(pg.Discard, "SELECT 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	replica.Close()
+	replica.CloseConns()
+	_, err = db.Query(pg.Discard, "SELECT 2")
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	_, err = db.Query(pg.Discard, "SELECT 3")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The replica is back and is tried again after the retry interval.
+	replica = newQueryServerAt(t, addr)
+	defer replica.Close()
+	time.Sleep(100 * time.Millisecond)
+
+	_, err = db.Query(pg.Discard, "SELECT 4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = db.Query(pg.Discard, "SELECT 5")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := primary.Queries(); !equalStrings(got, []string{"SELECT 3"}) {
+		t.Fatalf("primary got %q", got)
+	}
+	wanted := []string{"SELECT 4", "SELECT 5"}
+	if got := replica.Queries(); !equalStrings(got, wanted) {
+		t.Fatalf("replica got %q, wanted %q", got, wanted)
+	}
+}
@@ -0,0 +1,410 @@
+package pg
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/go-pg/pg/v9/internal"
+	"github.com/go-pg/pg/v9/internal/pool"
+)
+
+var errReplicationClosed = errors.New("pg: replication connection is closed")
+
+const (
+	xLogDataMsg         = 'w'
+	primaryKeepaliveMsg = 'k'
+	standbyStatusMsg    = 'r'
+)
+
+// LSN is a PostgreSQL Log Sequence Number, a position in the WAL.
+type LSN uint64
+
+// ParseLSN parses LSN in the X/X format.
+func ParseLSN(s string) (LSN, error) {
+	var hi, lo uint32
+	if _, err := fmt.Sscanf(s, "%X/%X", &hi, &lo); err != nil {
+		return 0, fmt.Errorf("pg: can't parse LSN=%q: %s", s, err)
+	}
+	return LSN(uint64(hi)<<32 | uint64(lo)), nil
+}
+
+// String returns LSN in the X/X format.
+func (lsn LSN) String() string {
+	return fmt.Sprintf("%X/%X", uint32(lsn>>32), uint32(lsn))
+}
+
+// IdentifySystem is the result of the IDENTIFY_SYSTEM replication command.
+type IdentifySystem struct {
+	SystemID string
+	Timeline int32
+	XLogPos  LSN
+	DBName   string
+}
+
+// XLogData is a chunk of WAL data sent by the server. For logical
+// replication WALData contains a message of the output plugin,
+// e.g. see pgoutput package.
+type XLogData struct {
+	WALStart     LSN
+	ServerWALEnd LSN
+	ServerTime   time.Time
+	WALData      []byte
+}
+
+// PrimaryKeepalive is a keepalive message sent by the server.
+// When ReplyRequested is true the client should send a standby
+// status update as soon as possible.
+type PrimaryKeepalive struct {
+	ServerWALEnd   LSN
+	ServerTime     time.Time
+	ReplyRequested bool
+}
+
+// StandbyStatus is a status update sent by the client to report
+// replication progress to the server.
+type StandbyStatus struct {
+	// The location of the last WAL byte + 1 received and written to disk.
+	WALWritePosition LSN
+	// The location of the last WAL byte + 1 flushed to disk.
+	// Default is WALWritePosition.
+	WALFlushPosition LSN
+	// The location of the last WAL byte + 1 applied.
+	// Default is WALWritePosition.
+	WALApplyPosition LSN
+	// Whether the server should reply to this message immediately.
+	ReplyRequested bool
+}
+
+// ReplicationConn is a connection opened in the logical replication mode.
+// It's NOT safe for concurrent use by multiple goroutines.
+type ReplicationConn struct {
+	db *DB
+	cn *pool.Conn
+
+	closed bool
+}
+
+// Replication opens a new connection in the logical replication mode.
+// The connection is not taken from the pool and must be closed with
+// ReplicationConn.Close.
+func (db *DB) Replication() (*ReplicationConn, error) {
+	c := db.Context()
+
+	cn, err := db.pool.NewConn(c)
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.initReplicationConn(c, cn)
+	if err != nil {
+		_ = db.pool.CloseConn(cn)
+		return nil, err
+	}
+
+	return &ReplicationConn{
+		db: db,
+		cn: cn,
+	}, nil
+}
+
+func (db *DB) initReplicationConn(c context.Context, cn *pool.Conn) error {
+	cn.Inited = true
+	db.setNoticeHandler(cn)
+
+	params := make(map[string]string, len(db.opt.RuntimeParams)+1)
+	for name, value := range db.opt.RuntimeParams {
+		params[name] = value
+	}
+	params["replication"] = "database"
+
+	return db.startupConn(c, cn, params)
+}
+
+func (r *ReplicationConn) String() string {
+	return fmt.Sprintf("ReplicationConn<%s>", r.db)
+}
+
+func (r *ReplicationConn) conn() (*pool.Conn, error) {
+	if r.closed {
+		return nil, errReplicationClosed
+	}
+	return r.cn, nil
+}
+
+// Close closes the replication connection.
+func (r *ReplicationConn) Close() error {
+	if r.closed {
+		return errReplicationClosed
+	}
+	r.closed = true
+	return r.db.pool.CloseConn(r.cn)
+}
+
+// IdentifySystem requests the server to identify itself.
+func (r *ReplicationConn) IdentifySystem() (*IdentifySystem, error) {
+	var sys IdentifySystem
+	var xlogPos string
+	err := r.queryOne(Scan(&sys.SystemID, &sys.Timeline, &xlogPos, &sys.DBName),
+		"IDENTIFY_SYSTEM")
+	if err != nil {
+		return nil, err
+	}
+
+	sys.XLogPos, err = ParseLSN(xlogPos)
+	if err != nil {
+		return nil, err
+	}
+	return &sys, nil
+}
+
+// CreateSlot creates a logical replication slot that uses the output plugin
+// and returns the LSN at which the slot became consistent.
+func (r *ReplicationConn) CreateSlot(slot, plugin string, temporary bool) (LSN, error) {
+	query := "CREATE_REPLICATION_SLOT ? LOGICAL ?"
+	if temporary {
+		query = "CREATE_REPLICATION_SLOT ? TEMPORARY LOGICAL ?"
+	}
+
+	var slotName, consistentPoint, snapshotName, outputPlugin string
+	err := r.queryOne(
+		Scan(&slotName, &consistentPoint, &snapshotName, &outputPlugin),
+		query, Ident(slot), Ident(plugin))
+	if err != nil {
+		return 0, err
+	}
+	return ParseLSN(consistentPoint)
+}
+
+// DropSlot drops the replication slot.
+func (r *ReplicationConn) DropSlot(slot string) error {
+	cn, err := r.conn()
+	if err != nil {
+		return err
+	}
+	_, err = r.db.simpleQuery(r.db.Context(), cn, "DROP_REPLICATION_SLOT ?", Ident(slot))
+	return err
+}
+
+func (r *ReplicationConn) queryOne(model, query interface{}, params ...interface{}) error {
+	cn, err := r.conn()
+	if err != nil {
+		return err
+	}
+
+	res, err := r.db.simpleQueryData(r.db.Context(), cn, model, query, params...)
+	if err != nil {
+		return err
+	}
+	return internal.AssertOneRow(res.RowsAffected())
+}
+
+// StartReplication starts streaming WAL from the slot beginning
+// at the startLSN. The pluginArgs are passed to the output plugin,
+// e.g. `proto_version '1'`. Use ReceiveMessage to read the stream.
+func (r *ReplicationConn) StartReplication(slot string, startLSN LSN, pluginArgs ...string) error {
+	cn, err := r.conn()
+	if err != nil {
+		return err
+	}
+
+	query := "START_REPLICATION SLOT ? LOGICAL ?"
+	if len(pluginArgs) > 0 {
+		query += " (" + strings.Join(pluginArgs, ", ") + ")"
+	}
+
+	c := r.db.Context()
+	err = cn.WithWriter(c, r.db.opt.WriteTimeout, func(wb *pool.WriteBuffer) error {
+		return writeQueryMsg(wb, r.db.fmter, query, Ident(slot), Safe(startLSN.String()))
+	})
+	if err != nil {
+		return err
+	}
+
+	return cn.WithReader(c, r.db.opt.ReadTimeout, readCopyBothResponse)
+}
+
+// ReceiveMessage waits for the next replication message. It returns
+// *XLogData or *PrimaryKeepalive. It returns io.EOF when the server
+// ends the replication stream.
+func (r *ReplicationConn) ReceiveMessage() (interface{}, error) {
+	return r.ReceiveMessageTimeout(0)
+}
+
+// ReceiveMessageTimeout acts like ReceiveMessage, but returns an error
+// if the message is not received in time.
+func (r *ReplicationConn) ReceiveMessageTimeout(timeout time.Duration) (interface{}, error) {
+	cn, err := r.conn()
+	if err != nil {
+		return nil, err
+	}
+
+	var msg interface{}
+	err = cn.WithReader(r.db.Context(), timeout, func(rd *internal.BufReader) error {
+		msg, err = readReplicationMessage(rd)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+// SendStandbyStatus reports the replication progress to the server.
+func (r *ReplicationConn) SendStandbyStatus(status *StandbyStatus) error {
+	cn, err := r.conn()
+	if err != nil {
+		return err
+	}
+
+	return cn.WithWriter(r.db.Context(), r.db.opt.WriteTimeout, func(wb *pool.WriteBuffer) error {
+		writeStandbyStatusMsg(wb, status, time.Now())
+		return nil
+	})
+}
+
+func writeStandbyStatusMsg(buf *pool.WriteBuffer, status *StandbyStatus, tm time.Time) {
+	flush := status.WALFlushPosition
+	if flush == 0 {
+		flush = status.WALWritePosition
+	}
+	apply := status.WALApplyPosition
+	if apply == 0 {
+		apply = status.WALWritePosition
+	}
+
+	buf.StartMessage(copyDataMsg)
+	_ = buf.WriteByte(standbyStatusMsg)
+	buf.WriteInt64(int64(status.WALWritePosition))
+	buf.WriteInt64(int64(flush))
+	buf.WriteInt64(int64(apply))
+	buf.WriteInt64(timeToPgMicros(tm))
+	if status.ReplyRequested {
+		_ = buf.WriteByte(1)
+	} else {
+		_ = buf.WriteByte(0)
+	}
+	buf.FinishMessage()
+}
+
+func readCopyBothResponse(rd *internal.BufReader) error {
+	var firstErr error
+	for {
+		c, msgLen, err := readMessageType(rd)
+		if err != nil {
+			return err
+		}
+
+		switch c {
+		case copyBothResponseMsg:
+			_, err := rd.ReadN(msgLen)
+			return err
+		case errorResponseMsg:
+			e, err := readError(rd)
+			if err != nil {
+				return err
+			}
+			if firstErr == nil {
+				firstErr = e
+			}
+		case readyForQueryMsg:
+			_, err := rd.ReadN(msgLen)
+			if err != nil {
+				return err
+			}
+			return firstErr
+		case noticeResponseMsg:
+			if err := handleNotice(rd, msgLen); err != nil {
+				return err
+			}
+		case parameterStatusMsg:
+			if err := handleParameterStatus(rd, msgLen); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("pg: readCopyBothResponse: unexpected message %q", c)
+		}
+	}
+}
+
+func readReplicationMessage(rd *internal.BufReader) (interface{}, error) {
+	for {
+		c, msgLen, err := readMessageType(rd)
+		if err != nil {
+			return nil, err
+		}
+
+		switch c {
+		case copyDataMsg:
+			b, err := rd.ReadN(msgLen)
+			if err != nil {
+				return nil, err
+			}
+			return parseReplicationMessage(b)
+		case copyDoneMsg:
+			_, err := rd.ReadN(msgLen)
+			if err != nil {
+				return nil, err
+			}
+			return nil, io.EOF
+		case errorResponseMsg:
+			e, err := readError(rd)
+			if err != nil {
+				return nil, err
+			}
+			return nil, e
+		case noticeResponseMsg:
+			if err := handleNotice(rd, msgLen); err != nil {
+				return nil, err
+			}
+		case parameterStatusMsg:
+			if err := handleParameterStatus(rd, msgLen); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, fmt.Errorf("pg: readReplicationMessage: unexpected message %q", c)
+		}
+	}
+}
+
+func parseReplicationMessage(b []byte) (interface{}, error) {
+	if len(b) == 0 {
+		return nil, errors.New("pg: empty replication message")
+	}
+
+	switch b[0] {
+	case xLogDataMsg:
+		if len(b) < 25 {
+			return nil, fmt.Errorf("pg: invalid XLogData length=%d", len(b))
+		}
+		return &XLogData{
+			WALStart:     LSN(binary.BigEndian.Uint64(b[1:])),
+			ServerWALEnd: LSN(binary.BigEndian.Uint64(b[9:])),
+			ServerTime:   pgMicrosToTime(int64(binary.BigEndian.Uint64(b[17:]))),
+			WALData:      append([]byte(nil), b[25:]...),
+		}, nil
+	case primaryKeepaliveMsg:
+		if len(b) < 18 {
+			return nil, fmt.Errorf("pg: invalid PrimaryKeepalive length=%d", len(b))
+		}
+		return &PrimaryKeepalive{
+			ServerWALEnd:   LSN(binary.BigEndian.Uint64(b[1:])),
+			ServerTime:     pgMicrosToTime(int64(binary.BigEndian.Uint64(b[9:]))),
+			ReplyRequested: b[17] == 1,
+		}, nil
+	default:
+		return nil, fmt.Errorf("pg: unknown replication message %q", b[0])
+	}
+}
+
+func pgMicrosToTime(usec int64) time.Time {
+	return internal.PGEpoch.Add(time.Duration(usec) * time.Microsecond)
+}
+
+func timeToPgMicros(tm time.Time) int64 {
+	return tm.Sub(internal.PGEpoch).Nanoseconds() / 1e3
+}
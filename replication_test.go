@@ -0,0 +1,233 @@
+package pg_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/go-pg/pg/v9"
+	"github.com/go-pg/pg/v9/pgoutput"
+)
+
+func TestParseLSN(t *testing.T) {
+	lsn, err := pg.ParseLSN("16/B374D848")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lsn != pg.LSN(0x16B374D848) {
+		t.Fatalf("got %x", uint64(lsn))
+	}
+	if s := lsn.String(); s != "16/B374D848" {
+		t.Fatalf("got %q", s)
+	}
+
+	if _, err := pg.ParseLSN("foo"); err == nil {
+		t.Fatal("expected an error")
+	}
+}
+
+// replicationFrames are CopyData payloads recorded from a pgoutput stream
+// with a single INSERT INTO books (id, title) VALUES (1, 'Hello').
+var replicationFrames = []string{
+	// Primary keepalive.
+	"6b" + "000000000169b2c8" + "00025e9e4c0f2c67" + "00",
+	// XLogData with Begin.
+	"77" + "000000000169b2c8" + "000000000169b2c8" + "00025e9e4c0f2c67" +
+		"42" + "000000000169b2f8" + "00025e9e4c0efc5a" + "0000023b",
+	// XLogData with Relation.
+	"77" + "000000000169b2c8" + "000000000169b2c8" + "00025e9e4c0f2c67" +
+		"52" + "00004001" + "7075626c696300" + "626f6f6b7300" + "64" + "0002" +
+		"01" + "696400" + "00000017" + "ffffffff" +
+		"00" + "7469746c6500" + "00000019" + "ffffffff",
+	// XLogData with Insert.
+	"77" + "000000000169b2c8" + "000000000169b2c8" + "00025e9e4c0f2c67" +
+		"49" + "00004001" + "4e" + "0002" +
+		"74" + "00000001" + "31" +
+		"74" + "00000005" + "48656c6c6f",
+	// XLogData with Commit.
+	"77" + "000000000169b2f8" + "000000000169b2f8" + "00025e9e4c0f2c67" +
+		"43" + "00" + "000000000169b2f8" + "000000000169b328" + "00025e9e4c0efc5a",
+}
+
+type fakeServer struct {
+	t  *testing.T
+	ln net.Listener
+
+	startup []byte
+	query   []byte
+	status  chan []byte
+}
+
+func newFakeServer(t *testing.T, frames [][]byte) *fakeServer {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := &fakeServer{
+		t:      t,
+		ln:     ln,
+		status: make(chan []byte, 1),
+	}
+	go srv.serve(frames)
+	return srv
+}
+
+func (srv *fakeServer) Close() error {
+	return srv.ln.Close()
+}
+
+func (srv *fakeServer) serve(frames [][]byte) {
+	cn, err := srv.ln.Accept()
+	if err != nil {
+		return
+	}
+	defer cn.Close()
+
+	// Startup message has no type byte.
+	var size uint32
+	if err := binary.Read(cn, binary.BigEndian, &size); err != nil {
+		return
+	}
+	srv.startup = make([]byte, size-4)
+	if _, err := io.ReadFull(cn, srv.startup); err != nil {
+		return
+	}
+
+	writeServerMsg(cn, 'R', []byte{0, 0, 0, 0}) // AuthenticationOk
+	writeServerMsg(cn, 'Z', []byte{'I'})        // ReadyForQuery
+
+	typ, query, err := readClientMsg(cn)
+	if err != nil || typ != 'Q' {
+		return
+	}
+	srv.query = query
+
+	writeServerMsg(cn, 'W', []byte{0, 0, 0}) // CopyBothResponse
+	for _, frame := range frames {
+		writeServerMsg(cn, 'd', frame)
+	}
+
+	typ, status, err := readClientMsg(cn)
+	if err != nil || typ != 'd' {
+		return
+	}
+	srv.status <- status
+
+	writeServerMsg(cn, 'c', nil) // CopyDone
+}
+
+func TestReplicationFakeServer(t *testing.T) {
+	frames := make([][]byte, len(replicationFrames))
+	for i, s := range replicationFrames {
+		b, err := hex.DecodeString(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		frames[i] = b
+	}
+
+	srv := newFakeServer(t, frames)
+	defer srv.Close()
+
+	db := pg.Connect(&pg.Options{
+		Addr:     srv.ln.Addr().String(),
+		User:     "postgres",
+		Database: "postgres",
+	})
+	defer db.Close()
+
+	rc, err := db.Replication()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rc.Close()
+
+	if !bytes.Contains(srv.startup, []byte("replication\x00database\x00")) {
+		t.Fatalf("startup message does not request replication: %q", srv.startup)
+	}
+
+	err = rc.StartReplication("test_slot", pg.LSN(0x169B2C8), pgoutput.PluginArgs("books")...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantedQuery := `START_REPLICATION SLOT "test_slot" LOGICAL 0/169B2C8 ` +
+		`(proto_version '1', publication_names '"books"')` + "\x00"
+	if string(srv.query) != wantedQuery {
+		t.Fatalf("got %q, wanted %q", srv.query, wantedQuery)
+	}
+
+	msg, err := rc.ReceiveMessage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	keepalive, ok := msg.(*pg.PrimaryKeepalive)
+	if !ok {
+		t.Fatalf("got %T, wanted *pg.PrimaryKeepalive", msg)
+	}
+	if keepalive.ServerWALEnd != pg.LSN(0x169B2C8) || keepalive.ReplyRequested {
+		t.Fatalf("got %#v", keepalive)
+	}
+
+	type Book struct {
+		Id    int
+		Title string
+	}
+
+	var dec pgoutput.Decoder
+	var types []byte
+	var books []Book
+	var lastLSN pg.LSN
+	for range frames[1:] {
+		msg, err := rc.ReceiveMessage()
+		if err != nil {
+			t.Fatal(err)
+		}
+		xld := msg.(*pg.XLogData)
+
+		m, err := dec.Decode(xld.WALData)
+		if err != nil {
+			t.Fatal(err)
+		}
+		types = append(types, m.MessageType())
+
+		switch m := m.(type) {
+		case *pgoutput.Insert:
+			var book Book
+			if err := m.New.Scan(dec.Relation(m.RelationID), &book); err != nil {
+				t.Fatal(err)
+			}
+			books = append(books, book)
+		case *pgoutput.Commit:
+			lastLSN = m.EndLSN
+		}
+	}
+
+	if string(types) != "BRIC" {
+		t.Fatalf("got %q, wanted BRIC", types)
+	}
+	if len(books) != 1 || books[0].Id != 1 || books[0].Title != "Hello" {
+		t.Fatalf("got %#v", books)
+	}
+
+	err = rc.SendStandbyStatus(&pg.StandbyStatus{WALWritePosition: lastLSN})
+	if err != nil {
+		t.Fatal(err)
+	}
+	status := <-srv.status
+	if status[0] != 'r' || len(status) != 34 {
+		t.Fatalf("got %x", status)
+	}
+	for _, pos := range [][]byte{status[1:9], status[9:17], status[17:25]} {
+		if pg.LSN(binary.BigEndian.Uint64(pos)) != lastLSN {
+			t.Fatalf("got %x, wanted %s", pos, lastLSN)
+		}
+	}
+
+	_, err = rc.ReceiveMessage()
+	if err != io.EOF {
+		t.Fatalf("got %v, wanted io.EOF", err)
+	}
+}
@@ -0,0 +1,357 @@
+package pg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/go-pg/pg/v9/internal"
+	"github.com/go-pg/pg/v9/internal/pool"
+	"github.com/go-pg/pg/v9/orm"
+)
+
+// Rows is an iterator over the rows returned by a query.
+type Rows = orm.Rows
+
+// rowsFetchSize is the maximum number of rows requested from the portal
+// at once. The server suspends the portal after sending that many rows
+// and resumes it when more rows are requested.
+const rowsFetchSize = 1000
+
+var (
+	errRowsClosed  = errors.New("pg: rows are closed")
+	errNoRow       = errors.New("pg: Scan called without calling Next")
+	errScanNoValue = errors.New("pg: Scan(no values)")
+)
+
+// rows reads the rows of the unnamed portal executed with a row limit.
+// Nothing else can be sent on the connection until Sync is sent by Close.
+type rows struct {
+	db  *baseDB
+	ctx context.Context
+	evt *QueryEvent
+	cn  *pool.Conn
+
+	columns [][]byte
+	res     result
+
+	pending bool // row body of rowLen bytes is not read yet
+	rowLen  int
+	done    bool // portal completed or failed
+	closed  bool
+	err     error // query error
+	netErr  error // connection is not usable
+}
+
+var _ Rows = (*rows)(nil)
+
+// QueryRows executes a query and returns an iterator over the returned
+// rows. Rows are fetched in batches using a portal with a row limit so
+// memory usage does not depend on the size of the result. Rows must be
+// closed to release the connection. The query always runs on the primary.
+// Other queries of Tx and Conn return an error while their rows are open.
+func (db *baseDB) QueryRows(query interface{}, params ...interface{}) (Rows, error) {
+	return db.QueryRowsContext(context.Background(), query, params...)
+}
+
+// QueryRowsContext acts like QueryRows but additionally receives a context.
+func (db *baseDB) QueryRowsContext(
+	c context.Context, query interface{}, params ...interface{},
+) (Rows, error) {
+	return db.queryRows(c, db.db, query, params...)
+}
+
+func (db *baseDB) queryRows(
+	c context.Context, ormDB orm.DB, query interface{}, params ...interface{},
+) (*rows, error) {
+	c, evt, err := db.beforeQuery(c, ormDB, nil, query, params)
+	if err != nil {
+		return nil, err
+	}
+
+	cn, err := db.getConn(c)
+	if err != nil {
+		_ = db.afterQuery(c, evt, nil, err)
+		return nil, err
+	}
+	if db.openRows != nil {
+		atomic.AddInt32(db.openRows, 1)
+	}
+
+	r := &rows{
+		db:  db,
+		ctx: c,
+		evt: evt,
+		cn:  cn,
+	}
+	if err := r.open(query, params...); err != nil {
+		_ = r.Close()
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *rows) open(query interface{}, params ...interface{}) error {
+	err := r.cn.WithWriter(r.ctx, r.db.opt.WriteTimeout, func(wb *pool.WriteBuffer) error {
+		if err := writeParseBindDescribeMsg(wb, r.db.fmter, query, params...); err != nil {
+			return err
+		}
+		writeExecuteMsg(wb, rowsFetchSize)
+		writeFlushMsg(wb)
+		return nil
+	})
+	if err != nil {
+		r.netErr = err
+		return err
+	}
+
+	err = r.cn.WithReader(r.ctx, r.db.opt.ReadTimeout, func(rd *internal.BufReader) error {
+		for {
+			c, msgLen, err := readMessageType(rd)
+			if err != nil {
+				return err
+			}
+
+			switch c {
+			case parseCompleteMsg, bindCompleteMsg, noDataMsg:
+				_, err = rd.ReadN(msgLen)
+				if err != nil {
+					return err
+				}
+				if c == noDataMsg {
+					return nil
+				}
+			case rowDescriptionMsg:
+				r.columns, err = readRowDescription(rd, nil)
+				return err
+			case errorResponseMsg:
+				e, err := readError(rd)
+				if err != nil {
+					return err
+				}
+				r.err = e
+				r.done = true
+				return nil
+			case noticeResponseMsg:
+				if err := handleNotice(rd, msgLen); err != nil {
+					return err
+				}
+			case parameterStatusMsg:
+				if err := handleParameterStatus(rd, msgLen); err != nil {
+					return err
+				}
+			default:
+				return fmt.Errorf("pg: rows.open: unexpected message %q", c)
+			}
+		}
+	})
+	if err != nil {
+		r.netErr = err
+		return err
+	}
+	return r.err
+}
+
+func (r *rows) Next() bool {
+	if r.closed || r.done || r.netErr != nil {
+		return false
+	}
+
+	err := r.cn.WithReader(r.ctx, r.db.opt.ReadTimeout, r.next)
+	if err != nil {
+		r.netErr = err
+		return false
+	}
+	return r.pending
+}
+
+func (r *rows) next(rd *internal.BufReader) error {
+	if err := r.skipRow(rd); err != nil {
+		return err
+	}
+
+	for {
+		c, msgLen, err := readMessageType(rd)
+		if err != nil {
+			return err
+		}
+
+		switch c {
+		case dataRowMsg:
+			// Leave the row in the reader until it is scanned or skipped.
+			r.pending = true
+			r.rowLen = msgLen
+			r.res.returned++
+			return nil
+		case portalSuspendedMsg:
+			_, err := rd.ReadN(msgLen)
+			if err != nil {
+				return err
+			}
+			err = r.cn.WithWriter(r.ctx, r.db.opt.WriteTimeout, func(wb *pool.WriteBuffer) error {
+				writeExecuteMsg(wb, rowsFetchSize)
+				writeFlushMsg(wb)
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		case commandCompleteMsg:
+			b, err := rd.ReadN(msgLen)
+			if err != nil {
+				return err
+			}
+			r.done = true
+			return r.res.parse(b)
+		case emptyQueryResponseMsg:
+			r.err = errEmptyQuery
+			r.done = true
+			return nil
+		case errorResponseMsg:
+			e, err := readError(rd)
+			if err != nil {
+				return err
+			}
+			r.err = e
+			r.done = true
+			return nil
+		case noticeResponseMsg:
+			if err := handleNotice(rd, msgLen); err != nil {
+				return err
+			}
+		case parameterStatusMsg:
+			if err := handleParameterStatus(rd, msgLen); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("pg: rows.Next: unexpected message %q", c)
+		}
+	}
+}
+
+// skipRow discards the current row when it was not scanned.
+func (r *rows) skipRow(rd *internal.BufReader) error {
+	if !r.pending {
+		return nil
+	}
+	r.pending = false
+	_, err := rd.Discard(r.rowLen)
+	return err
+}
+
+func (r *rows) Scan(values ...interface{}) error {
+	if r.closed {
+		return errRowsClosed
+	}
+	if !r.pending {
+		return errNoRow
+	}
+	if len(values) == 0 {
+		return errScanNoValue
+	}
+
+	model, err := orm.NewModel(values...)
+	if err != nil {
+		return err
+	}
+	if err := model.Init(); err != nil {
+		return err
+	}
+
+	scanner := model.NextColumnScanner()
+	err = r.cn.WithReader(r.ctx, r.db.opt.ReadTimeout, func(rd *internal.BufReader) error {
+		r.pending = false
+		return readDataRow(rd, scanner, r.columns, nil)
+	})
+	if err != nil {
+		if isBadConn(err, false) {
+			r.netErr = err
+		}
+		return err
+	}
+
+	if err := model.AddColumnScanner(scanner); err != nil {
+		return err
+	}
+	if m, ok := model.(orm.AfterScanHook); ok {
+		return m.AfterScan(r.ctx)
+	}
+	return nil
+}
+
+func (r *rows) Err() error {
+	if r.err != nil {
+		return r.err
+	}
+	return r.netErr
+}
+
+func (r *rows) Close() error {
+	if r.closed {
+		return nil
+	}
+	r.closed = true
+
+	if r.netErr == nil {
+		r.netErr = r.sync()
+	}
+	r.db.releaseConn(r.cn, r.netErr)
+	if r.db.openRows != nil {
+		atomic.AddInt32(r.db.openRows, -1)
+	}
+
+	_ = r.db.afterQuery(r.ctx, r.evt, &r.res, r.Err())
+	return r.netErr
+}
+
+// sync ends the implicit transaction of the portal and discards
+// the rows that were sent by the server but were not read.
+func (r *rows) sync() error {
+	err := r.cn.WithWriter(r.ctx, r.db.opt.WriteTimeout, func(wb *pool.WriteBuffer) error {
+		writeSyncMsg(wb)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return r.cn.WithReader(r.ctx, r.db.opt.ReadTimeout, func(rd *internal.BufReader) error {
+		if err := r.skipRow(rd); err != nil {
+			return err
+		}
+
+		for {
+			c, msgLen, err := readMessageType(rd)
+			if err != nil {
+				return err
+			}
+
+			switch c {
+			case readyForQueryMsg:
+				_, err := rd.ReadN(msgLen)
+				return err
+			case errorResponseMsg:
+				e, err := readError(rd)
+				if err != nil {
+					return err
+				}
+				if r.err == nil {
+					r.err = e
+				}
+			case noticeResponseMsg:
+				if err := handleNotice(rd, msgLen); err != nil {
+					return err
+				}
+			case parameterStatusMsg:
+				if err := handleParameterStatus(rd, msgLen); err != nil {
+					return err
+				}
+			default:
+				if _, err := rd.Discard(msgLen); err != nil {
+					return err
+				}
+			}
+		}
+	})
+}
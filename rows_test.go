@@ -0,0 +1,221 @@
+package pg_test
+
+import (
+	"testing"
+
+	"github.com/go-pg/pg/v9"
+	"github.com/go-pg/pg/v9/pgerrcode"
+)
+
+func TestQueryRows(t *testing.T) {
+	srv := newQueryServer(t)
+	defer srv.Close()
+
+	db := pg.Connect(&pg.Options{
+		Addr:     srv.Addr(),
+		PoolSize: 1,
+	})
+	defer db.Close()
+
+	// More rows than are fetched at once.
+	rows, err := db.QueryRows("SELECT n FROM generate_series(1, 2500)")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var count, last int
+	for rows.Next() {
+		count++
+		if count%2 == 0 {
+			// Rows that are not scanned are skipped.
+			continue
+		}
+		if err := rows.Scan(&last); err != nil {
+			t.Fatal(err)
+		}
+		if last != count {
+			t.Fatalf("got %d, wanted %d", last, count)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if err := rows.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if count != 2500 || last != 2499 {
+		t.Fatalf("got %d rows and last %d", count, last)
+	}
+
+	// Rows closed before they are read.
+	rows, err = db.QueryRows("SELECT n FROM generate_series(1, 2500)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 10 && rows.Next(); i++ {
+	}
+	if err := rows.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := rows.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if rows.Next() {
+		t.Fatal("Next returned true for closed rows")
+	}
+
+	// The connection is usable after rows are closed.
+	var n int
+	_, err = db.QueryOne(pg.Scan(&n), "SELECT n FROM generate_series(1, 1)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Fatalf("got %d, wanted 1", n)
+	}
+}
+
+func TestQueryRowsModel(t *testing.T) {
+	srv := newQueryServer(t)
+	defer srv.Close()
+
+	db := pg.Connect(&pg.Options{
+		Addr:     srv.Addr(),
+		PoolSize: 1,
+	})
+	defer db.Close()
+
+	type Num struct {
+		N int
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := tx.Model((*Num)(nil)).ColumnExpr("generate_series(1, 3) AS n").Rows()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var nums []int
+	for rows.Next() {
+		var num Num
+		if err := rows.Scan(&num); err != nil {
+			t.Fatal(err)
+		}
+		nums = append(nums, num.N)
+	}
+	if err := rows.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if len(nums) != 3 || nums[0] != 1 || nums[2] != 3 {
+		t.Fatalf("got %v", nums)
+	}
+
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tx.QueryRows("SELECT 1"); err == nil {
+		t.Fatal("QueryRows succeeded in a closed transaction")
+	}
+
+	_, err = db.NewBatch().Model((*Num)(nil)).Rows()
+	if err == nil || err.Error() != "pg: Rows is not supported by *pg.Batch" {
+		t.Fatalf("got %v, wanted Rows is not supported", err)
+	}
+}
+
+func TestQueryRowsBusyTx(t *testing.T) {
+	srv := newQueryServer(t)
+	defer srv.Close()
+
+	db := pg.Connect(&pg.Options{
+		Addr:     srv.Addr(),
+		PoolSize: 1,
+	})
+	defer db.Close()
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := tx.QueryRows("SELECT n FROM generate_series(1, 3)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !rows.Next() {
+		t.Fatal(rows.Err())
+	}
+
+	// Queries fail instead of waiting for the connection held by rows.
+	_, err = tx.Exec("SELECT 1")
+	if err == nil || err.Error() != "pg: Rows must be closed before running other queries" {
+		t.Fatalf("got %v, wanted Rows must be closed", err)
+	}
+	if _, err := tx.QueryRows("SELECT 2"); err == nil {
+		t.Fatal("QueryRows succeeded with open rows")
+	}
+
+	if err := rows.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tx.Exec("SELECT 3"); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	conn := db.Conn()
+	defer conn.Close()
+
+	rows, err = conn.QueryRows("SELECT n FROM generate_series(1, 3)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := conn.Exec("SELECT 4"); err == nil {
+		t.Fatal("Exec succeeded with open rows")
+	}
+	if err := rows.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestQueryRowsError(t *testing.T) {
+	srv := newQueryServer(t)
+	defer srv.Close()
+
+	db := pg.Connect(&pg.Options{
+		Addr:     srv.Addr(),
+		PoolSize: 1,
+	})
+	defer db.Close()
+
+	// Execution errors are reported by Err.
+	rows, err := db.QueryRows("SELECT 1 FROM dups")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rows.Next() {
+		t.Fatal("Next returned true")
+	}
+	if !pg.IsUniqueViolation(rows.Err()) {
+		t.Fatalf("got %v, wanted unique violation", rows.Err())
+	}
+	if err := rows.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	srv.FailQuery("SELECT 2", pgerrcode.SyntaxError, 1)
+	_, err = db.QueryRows("SELECT 2")
+	if pg.ErrorCode(err) != pgerrcode.SyntaxError {
+		t.Fatalf("got %v, wanted syntax error", err)
+	}
+
+	if _, err := db.Exec("SELECT 3"); err != nil {
+		t.Fatal(err)
+	}
+}
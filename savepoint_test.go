@@ -0,0 +1,112 @@
+package pg_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/go-pg/pg/v9"
+)
+
+func TestNestedTransactions(t *testing.T) {
+	srv := newQueryServer(t)
+	defer srv.Close()
+
+	db := pg.Connect(&pg.Options{
+		Addr: srv.Addr(),
+	})
+	defer db.Close()
+
+	errInner := errors.New("inner failed")
+	err := db.RunInTransaction(func(tx *pg.Tx) error {
+		if _, err := tx.Exec("SELECT 1"); err != nil {
+			return err
+		}
+
+		err := tx.RunInTransaction(func(tx *pg.Tx) error {
+			if _, err := tx.Exec("SELECT 2"); err != nil {
+				return err
+			}
+			return tx.RunInTransaction(func(tx *pg.Tx) error {
+				_, err := tx.Exec("SELECT 3")
+				return err
+			})
+		})
+		if err != nil {
+			return err
+		}
+
+		err = tx.RunInTransaction(func(tx *pg.Tx) error {
+			return errInner
+		})
+		if err != errInner {
+			t.Fatalf("got %v, wanted %v", err, errInner)
+		}
+
+		if err := tx.Savepoint("before_delete"); err != nil {
+			return err
+		}
+		if _, err := tx.Exec("DELETE FROM books"); err != nil {
+			return err
+		}
+		return tx.RollbackTo("before_delete")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wanted := []string{
+		`BEGIN`,
+		`SELECT 1`,
+		`SAVEPOINT "_go_pg_sp_1"`,
+		`SELECT 2`,
+		`SAVEPOINT "_go_pg_sp_2"`,
+		`SELECT 3`,
+		`RELEASE SAVEPOINT "_go_pg_sp_2"`,
+		`RELEASE SAVEPOINT "_go_pg_sp_1"`,
+		`SAVEPOINT "_go_pg_sp_1"`,
+		`ROLLBACK TO SAVEPOINT "_go_pg_sp_1"`,
+		`RELEASE SAVEPOINT "_go_pg_sp_1"`,
+		`SAVEPOINT "before_delete"`,
+		`DELETE FROM books`,
+		`ROLLBACK TO SAVEPOINT "before_delete"`,
+		`COMMIT`,
+	}
+	if got := srv.Queries(); !equalStrings(got, wanted) {
+		t.Fatalf("got %q, wanted %q", got, wanted)
+	}
+}
+
+func TestNestedTransactionDone(t *testing.T) {
+	srv := newQueryServer(t)
+	defer srv.Close()
+
+	db := pg.Connect(&pg.Options{
+		Addr: srv.Addr(),
+	})
+	defer db.Close()
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Close()
+
+	nested, err := tx.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := nested.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := nested.Exec("SELECT 1"); err == nil {
+		t.Fatal("expected an error")
+	}
+
+	// Outer transaction is still in progress.
+	if _, err := tx.Exec("SELECT 2"); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+}
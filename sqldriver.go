@@ -0,0 +1,615 @@
+package pg
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/go-pg/pg/v9/internal"
+	"github.com/go-pg/pg/v9/internal/pool"
+	"github.com/go-pg/pg/v9/types"
+)
+
+var (
+	errNamedArgs       = errors.New("pg: named arguments are not supported")
+	errNoLastInsertID  = errors.New("pg: LastInsertId is not supported, use RETURNING")
+	errUnsupportedIsol = errors.New("pg: unsupported transaction isolation level")
+)
+
+// Type OIDs of the columns that are converted to the driver values
+// other than []byte.
+const (
+	pgBool        = 16
+	pgBytea       = 17
+	pgName        = 19
+	pgInt8        = 20
+	pgInt2        = 21
+	pgInt4        = 23
+	pgText        = 25
+	pgFloat4      = 700
+	pgFloat8      = 701
+	pgBPChar      = 1042
+	pgVarchar     = 1043
+	pgDate        = 1082
+	pgTimestamp   = 1114
+	pgTimestamptz = 1184
+)
+
+// Connector returns a database/sql connector that opens connections
+// using the options of the database. Connections are established with
+// the same startup and authentication as pooled connections, but they
+// are pooled by database/sql instead of the DB. Connections are closed
+// when the DB is closed.
+//
+//    sqldb := sql.OpenDB(db.Connector())
+//
+// See the pgdriver package to register go-pg as a database/sql driver.
+func (db *DB) Connector() driver.Connector {
+	return &sqlConnector{db: db}
+}
+
+type sqlConnector struct {
+	db *DB
+}
+
+var _ driver.Connector = (*sqlConnector)(nil)
+
+func (c *sqlConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	db := c.db.baseDB
+	cn, err := db.pool.NewConn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := db.initConn(ctx, cn); err != nil {
+		_ = db.pool.CloseConn(cn)
+		return nil, err
+	}
+
+	return &sqlConn{db: db, cn: cn}, nil
+}
+
+// Driver returns a driver that opens connections using the connector
+// and ignores the data source name. Use the pgdriver package to open
+// connections by the data source name.
+func (c *sqlConnector) Driver() driver.Driver {
+	return c
+}
+
+func (c *sqlConnector) Open(string) (driver.Conn, error) {
+	return c.Connect(context.Background())
+}
+
+//------------------------------------------------------------------------------
+
+// sqlConn is a connection used by database/sql. Queries without
+// arguments are sent using the simple query protocol so they can contain
+// multiple statements. Other queries use the unnamed prepared statement.
+type sqlConn struct {
+	db  *baseDB
+	cn  *pool.Conn
+	bad bool
+}
+
+var _ driver.Conn = (*sqlConn)(nil)
+var _ driver.ConnPrepareContext = (*sqlConn)(nil)
+var _ driver.QueryerContext = (*sqlConn)(nil)
+var _ driver.ExecerContext = (*sqlConn)(nil)
+var _ driver.ConnBeginTx = (*sqlConn)(nil)
+var _ driver.Pinger = (*sqlConn)(nil)
+var _ driver.NamedValueChecker = (*sqlConn)(nil)
+var _ driver.SessionResetter = (*sqlConn)(nil)
+
+// check marks the connection bad after network errors so database/sql
+// does not return it to the pool.
+func (cn *sqlConn) check(err error) error {
+	if err != nil && isBadConn(err, false) {
+		cn.bad = true
+	}
+	return err
+}
+
+func (cn *sqlConn) Prepare(query string) (driver.Stmt, error) {
+	return cn.PrepareContext(context.Background(), query)
+}
+
+func (cn *sqlConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	if cn.bad {
+		return nil, driver.ErrBadConn
+	}
+	stmt, err := cn.db.prepareCached(ctx, cn.cn, query)
+	if err != nil {
+		return nil, cn.check(err)
+	}
+	return &sqlStmt{conn: cn, query: query, numInput: len(stmt.Desc.(*stmtDesc).paramTypes)}, nil
+}
+
+func (cn *sqlConn) Close() error {
+	return cn.db.pool.CloseConn(cn.cn)
+}
+
+func (cn *sqlConn) Begin() (driver.Tx, error) {
+	return cn.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (cn *sqlConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if cn.bad {
+		return nil, driver.ErrBadConn
+	}
+
+	txOpt := &TxOptions{ReadOnly: opts.ReadOnly}
+	// Values of database/sql IsolationLevel.
+	switch opts.Isolation {
+	case 0: // LevelDefault
+	case 1:
+		txOpt.IsolationLevel = ReadUncommitted
+	case 2:
+		txOpt.IsolationLevel = ReadCommitted
+	case 4:
+		txOpt.IsolationLevel = RepeatableRead
+	case 6:
+		txOpt.IsolationLevel = Serializable
+	default:
+		return nil, errUnsupportedIsol
+	}
+
+	if err := cn.simpleExec(ctx, txOpt.beginQuery()); err != nil {
+		return nil, err
+	}
+	return sqlTx{conn: cn}, nil
+}
+
+func (cn *sqlConn) Ping(ctx context.Context) error {
+	if cn.bad {
+		return driver.ErrBadConn
+	}
+	return cn.simpleExec(ctx, "SELECT 1")
+}
+
+func (cn *sqlConn) ResetSession(ctx context.Context) error {
+	if cn.bad {
+		return driver.ErrBadConn
+	}
+	return nil
+}
+
+// CheckNamedValue lets go-pg encode argument values, e.g. structs,
+// maps or pg.Array, instead of converting them to driver values.
+func (cn *sqlConn) CheckNamedValue(nv *driver.NamedValue) error {
+	if nv.Name != "" {
+		return errNamedArgs
+	}
+	if _, ok := nv.Value.(driver.Valuer); ok {
+		return driver.ErrSkip
+	}
+	return nil
+}
+
+func (cn *sqlConn) simpleExec(ctx context.Context, query string) error {
+	_, err := cn.db.simpleQuery(ctx, cn.cn, query)
+	return cn.check(err)
+}
+
+func (cn *sqlConn) ExecContext(
+	ctx context.Context, query string, args []driver.NamedValue,
+) (driver.Result, error) {
+	if cn.bad {
+		return nil, driver.ErrBadConn
+	}
+
+	if len(args) == 0 {
+		res, err := cn.db.simpleQuery(ctx, cn.cn, query)
+		if err != nil {
+			return nil, cn.check(err)
+		}
+		return sqlResult(res.affected), nil
+	}
+
+	desc := new(stmtDesc)
+	err := cn.cn.WithWriter(ctx, cn.db.opt.WriteTimeout, func(wb *pool.WriteBuffer) error {
+		writeParseDescribeMsg(wb, "", query)
+		return writeBindExecuteMsg(wb, "", desc, false, namedValues(args)...)
+	})
+	if err != nil {
+		return nil, cn.check(err)
+	}
+
+	var res *result
+	err = cn.cn.WithReader(ctx, cn.db.opt.ReadTimeout, func(rd *internal.BufReader) error {
+		res, err = readCachedQueryData(rd, nil, desc, nil)
+		return err
+	})
+	if err != nil {
+		return nil, cn.check(err)
+	}
+	return sqlResult(res.affected), nil
+}
+
+func (cn *sqlConn) QueryContext(
+	ctx context.Context, query string, args []driver.NamedValue,
+) (driver.Rows, error) {
+	if cn.bad {
+		return nil, driver.ErrBadConn
+	}
+
+	extended := len(args) > 0
+	err := cn.cn.WithWriter(ctx, cn.db.opt.WriteTimeout, func(wb *pool.WriteBuffer) error {
+		if !extended {
+			return writeQueryMsg(wb, cn.db.fmter, query)
+		}
+		writeParseDescribeMsg(wb, "", query)
+		return writeBindExecuteMsg(wb, "", new(stmtDesc), false, namedValues(args)...)
+	})
+	if err != nil {
+		return nil, cn.check(err)
+	}
+
+	rows := &sqlRows{conn: cn, ctx: ctx}
+	if err := rows.readColumns(extended); err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+func namedValues(args []driver.NamedValue) []interface{} {
+	values := make([]interface{}, len(args))
+	for i, arg := range args {
+		values[i] = arg.Value
+	}
+	return values
+}
+
+//------------------------------------------------------------------------------
+
+// sqlStmt is a statement prepared on the connection. It is kept in
+// the connection statement cache and is not closed on the server by Close.
+// The statement is prepared again if the cache evicts it.
+type sqlStmt struct {
+	conn     *sqlConn
+	query    string
+	numInput int
+}
+
+var _ driver.Stmt = (*sqlStmt)(nil)
+var _ driver.StmtExecContext = (*sqlStmt)(nil)
+var _ driver.StmtQueryContext = (*sqlStmt)(nil)
+var _ driver.NamedValueChecker = (*sqlStmt)(nil)
+
+func (s *sqlStmt) Close() error {
+	return nil
+}
+
+func (s *sqlStmt) NumInput() int {
+	return s.numInput
+}
+
+func (s *sqlStmt) CheckNamedValue(nv *driver.NamedValue) error {
+	return s.conn.CheckNamedValue(nv)
+}
+
+func (s *sqlStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return s.ExecContext(context.Background(), driverNamedValues(args))
+}
+
+func (s *sqlStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	cn := s.conn
+	if cn.bad {
+		return nil, driver.ErrBadConn
+	}
+
+	stmt, err := cn.db.prepareCached(ctx, cn.cn, s.query)
+	if err != nil {
+		return nil, cn.check(err)
+	}
+
+	err = cn.cn.WithWriter(ctx, cn.db.opt.WriteTimeout, func(wb *pool.WriteBuffer) error {
+		return writeBindExecuteMsg(wb, stmt.Name, stmt.Desc.(*stmtDesc), false, namedValues(args)...)
+	})
+	if err != nil {
+		return nil, cn.check(err)
+	}
+
+	var res *result
+	err = cn.cn.WithReader(ctx, cn.db.opt.ReadTimeout, func(rd *internal.BufReader) error {
+		res, err = readExtQuery(rd)
+		return err
+	})
+	if err != nil {
+		return nil, cn.check(err)
+	}
+	return sqlResult(res.affected), nil
+}
+
+func (s *sqlStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return s.QueryContext(context.Background(), driverNamedValues(args))
+}
+
+func (s *sqlStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	cn := s.conn
+	if cn.bad {
+		return nil, driver.ErrBadConn
+	}
+
+	stmt, err := cn.db.prepareCached(ctx, cn.cn, s.query)
+	if err != nil {
+		return nil, cn.check(err)
+	}
+
+	desc := stmt.Desc.(*stmtDesc)
+	err = cn.cn.WithWriter(ctx, cn.db.opt.WriteTimeout, func(wb *pool.WriteBuffer) error {
+		return writeBindExecuteMsg(wb, stmt.Name, desc, false, namedValues(args)...)
+	})
+	if err != nil {
+		return nil, cn.check(err)
+	}
+
+	rows := &sqlRows{
+		conn:        cn,
+		ctx:         ctx,
+		columns:     desc.columns,
+		columnTypes: desc.columnTypes,
+	}
+	if err := rows.readColumns(true); err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+func driverNamedValues(args []driver.Value) []driver.NamedValue {
+	values := make([]driver.NamedValue, len(args))
+	for i, arg := range args {
+		values[i] = driver.NamedValue{Ordinal: i + 1, Value: arg}
+	}
+	return values
+}
+
+//------------------------------------------------------------------------------
+
+type sqlTx struct {
+	conn *sqlConn
+}
+
+var _ driver.Tx = sqlTx{}
+
+func (tx sqlTx) Commit() error {
+	return tx.conn.simpleExec(context.Background(), "COMMIT")
+}
+
+func (tx sqlTx) Rollback() error {
+	return tx.conn.simpleExec(context.Background(), "ROLLBACK")
+}
+
+//------------------------------------------------------------------------------
+
+type sqlResult int
+
+var _ driver.Result = sqlResult(0)
+
+func (res sqlResult) LastInsertId() (int64, error) {
+	return 0, errNoLastInsertID
+}
+
+func (res sqlResult) RowsAffected() (int64, error) {
+	return int64(res), nil
+}
+
+//------------------------------------------------------------------------------
+
+// sqlRows reads rows from the connection until ReadyForQuery is received.
+type sqlRows struct {
+	conn *sqlConn
+	ctx  context.Context
+
+	columns     [][]byte
+	columnTypes []uint32
+
+	done bool
+	err  error
+}
+
+var _ driver.Rows = (*sqlRows)(nil)
+
+// readColumns reads messages up to the first row. When extended is true,
+// it stops after BindComplete; otherwise after RowDescription.
+func (r *sqlRows) readColumns(extended bool) error {
+	cn := r.conn
+	err := cn.cn.WithReader(r.ctx, cn.db.opt.ReadTimeout, func(rd *internal.BufReader) error {
+		for {
+			c, msgLen, err := readMessageType(rd)
+			if err != nil {
+				return err
+			}
+
+			switch c {
+			case bindCompleteMsg:
+				_, err := rd.ReadN(msgLen)
+				if err != nil {
+					return err
+				}
+				return nil
+			case rowDescriptionMsg:
+				r.columns, r.columnTypes, err = readRowDescriptionTypes(rd)
+				if err != nil {
+					return err
+				}
+				if !extended {
+					return nil
+				}
+			case readyForQueryMsg:
+				_, err := rd.ReadN(msgLen)
+				if err != nil {
+					return err
+				}
+				r.done = true
+				return nil
+			default:
+				if err := r.readMessage(rd, c, msgLen); err != nil {
+					return err
+				}
+			}
+		}
+	})
+	if err != nil {
+		return cn.check(err)
+	}
+	return r.err
+}
+
+// readMessage handles messages that don't change the state of the rows.
+func (r *sqlRows) readMessage(rd *internal.BufReader, c byte, msgLen int) error {
+	switch c {
+	case parseCompleteMsg, parameterDescriptionMsg, noDataMsg,
+		commandCompleteMsg, emptyQueryResponseMsg:
+		_, err := rd.ReadN(msgLen)
+		return err
+	case errorResponseMsg:
+		e, err := readError(rd)
+		if err != nil {
+			return err
+		}
+		if r.err == nil {
+			r.err = e
+		}
+		return nil
+	case noticeResponseMsg:
+		return handleNotice(rd, msgLen)
+	case parameterStatusMsg:
+		return handleParameterStatus(rd, msgLen)
+	default:
+		return fmt.Errorf("pg: sqlRows: unexpected message %q", c)
+	}
+}
+
+func (r *sqlRows) Columns() []string {
+	columns := make([]string, len(r.columns))
+	for i, col := range r.columns {
+		columns[i] = string(col)
+	}
+	return columns
+}
+
+func (r *sqlRows) Next(dest []driver.Value) error {
+	if r.done {
+		if r.err != nil {
+			return r.err
+		}
+		return io.EOF
+	}
+
+	cn := r.conn
+	var found bool
+	err := cn.cn.WithReader(r.ctx, cn.db.opt.ReadTimeout, func(rd *internal.BufReader) error {
+		for {
+			c, msgLen, err := readMessageType(rd)
+			if err != nil {
+				return err
+			}
+
+			switch c {
+			case dataRowMsg:
+				found = true
+				return r.readRow(rd, dest)
+			case rowDescriptionMsg:
+				r.columns, r.columnTypes, err = readRowDescriptionTypes(rd)
+				if err != nil {
+					return err
+				}
+			case readyForQueryMsg:
+				_, err := rd.ReadN(msgLen)
+				if err != nil {
+					return err
+				}
+				r.done = true
+				return nil
+			default:
+				if err := r.readMessage(rd, c, msgLen); err != nil {
+					return err
+				}
+			}
+		}
+	})
+	if err != nil {
+		r.done = true
+		return cn.check(err)
+	}
+	if found {
+		return nil
+	}
+	if r.err != nil {
+		return r.err
+	}
+	return io.EOF
+}
+
+func (r *sqlRows) readRow(rd *internal.BufReader, dest []driver.Value) error {
+	colNum, err := readInt16(rd)
+	if err != nil {
+		return err
+	}
+
+	var firstErr error
+	for i := 0; i < int(colNum); i++ {
+		n, err := readInt32(rd)
+		if err != nil {
+			return err
+		}
+		if n == -1 {
+			if i < len(dest) {
+				dest[i] = nil
+			}
+			continue
+		}
+
+		b, err := rd.ReadN(int(n))
+		if err != nil {
+			return err
+		}
+		if i >= len(dest) {
+			continue
+		}
+		dest[i], err = sqlColumnValue(r.columnTypes[i], b)
+		if err != nil && firstErr == nil {
+			firstErr = internal.WrapError(err)
+		}
+	}
+	return firstErr
+}
+
+// sqlColumnValue converts the column value in text format to a driver value.
+func sqlColumnValue(oid uint32, b []byte) (driver.Value, error) {
+	rd := internal.NewBytesReader(b)
+	n := len(b)
+	switch oid {
+	case pgBool:
+		return len(b) == 1 && b[0] == 't', nil
+	case pgInt2, pgInt4, pgInt8:
+		return types.ScanInt64(rd, n)
+	case pgFloat4, pgFloat8:
+		return types.ScanFloat64(rd, n)
+	case pgBytea:
+		return types.ScanBytes(rd, n)
+	case pgDate, pgTimestamp, pgTimestamptz:
+		return types.ScanTime(rd, n)
+	case pgText, pgVarchar, pgBPChar, pgName:
+		return string(b), nil
+	default:
+		return append([]byte(nil), b...), nil
+	}
+}
+
+// Close discards the remaining rows.
+func (r *sqlRows) Close() error {
+	if r.done {
+		return nil
+	}
+
+	dest := make([]driver.Value, len(r.columns))
+	for !r.done {
+		if err := r.Next(dest); err != nil && r.conn.bad {
+			return err
+		}
+	}
+	return nil
+}
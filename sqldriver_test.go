@@ -0,0 +1,152 @@
+package pg_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"testing"
+
+	"github.com/go-pg/pg/v9"
+	"github.com/go-pg/pg/v9/pgdriver"
+)
+
+func TestSQLDriver(t *testing.T) {
+	srv := newQueryServer(t)
+	defer srv.Close()
+
+	db := pg.Connect(&pg.Options{
+		Addr: srv.Addr(),
+	})
+	defer db.Close()
+
+	sqldb := sql.OpenDB(db.Connector())
+	defer sqldb.Close()
+	sqldb.SetMaxOpenConns(1)
+
+	if err := sqldb.Ping(); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := sqldb.Exec("INSERT INTO books VALUES (1); INSERT INTO books VALUES (2)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := res.RowsAffected(); n != 1 {
+		t.Fatalf("got %d rows affected, wanted 1", n)
+	}
+
+	res, err = sqldb.Exec("INSERT INTO books VALUES ($1, $2)", 3, "it's")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := res.RowsAffected(); n != 1 {
+		t.Fatalf("got %d rows affected, wanted 1", n)
+	}
+
+	rows, err := sqldb.Query("SELECT n FROM generate_series(1, 3) WHERE $1", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sum int
+	for rows.Next() {
+		var n int
+		if err := rows.Scan(&n); err != nil {
+			t.Fatal(err)
+		}
+		sum += n
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if sum != 6 {
+		t.Fatalf("got %d, wanted 6", sum)
+	}
+
+	var n interface{}
+	err = sqldb.QueryRow("SELECT n FROM generate_series(1, 2)").Scan(&n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != int64(1) {
+		t.Fatalf("got %#v, wanted 1", n)
+	}
+
+	tx, err := sqldb.BeginTx(context.Background(), &sql.TxOptions{
+		Isolation: sql.LevelSerializable,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stmt, err := tx.Prepare("UPDATE books SET title = $1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stmt.Exec(pg.Array([]string{"foo"})); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = sqldb.Exec("INSERT INTO dups VALUES ($1)", 1)
+	if !pg.IsUniqueViolation(err) {
+		t.Fatalf("got %v, wanted unique violation", err)
+	}
+
+	wanted := []string{
+		"SELECT 1",
+		"INSERT INTO books VALUES (1); INSERT INTO books VALUES (2)",
+		"INSERT INTO books VALUES ($1, $2)",
+		"SELECT n FROM generate_series(1, 3) WHERE $1",
+		"SELECT n FROM generate_series(1, 2)",
+		"BEGIN ISOLATION LEVEL SERIALIZABLE",
+		"UPDATE books SET title = $1",
+		"COMMIT",
+		"INSERT INTO dups VALUES ($1)",
+	}
+	if got := srv.Queries(); !equalStrings(got, wanted) {
+		t.Fatalf("got %q, wanted %q", got, wanted)
+	}
+
+	wanted = []string{
+		"parse ",
+		"bind  [3 it's]",
+		"parse ",
+		"bind  [TRUE]",
+		"parse 1",
+		`bind 1 [{"foo"}]`,
+		"parse ",
+		"bind  [1]",
+	}
+	if got := srv.ExtMessages(); !equalStrings(got, wanted) {
+		t.Fatalf("got %q, wanted %q", got, wanted)
+	}
+}
+
+func TestPgdriverOpen(t *testing.T) {
+	srv := newQueryServer(t)
+	defer srv.Close()
+
+	cn, err := pgdriver.Driver{}.Open("postgres://vasya@" + srv.Addr() + "/db?sslmode=disable")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	execer, ok := cn.(driver.ExecerContext)
+	if !ok {
+		t.Fatalf("%T does not implement driver.ExecerContext", cn)
+	}
+	_, err = execer.ExecContext(context.Background(), "INSERT INTO books VALUES (1)", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := cn.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	wanted := []string{"INSERT INTO books VALUES (1)"}
+	if got := srv.Queries(); !equalStrings(got, wanted) {
+		t.Fatalf("got %q, wanted %q", got, wanted)
+	}
+}
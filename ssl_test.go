@@ -0,0 +1,150 @@
+package pg_test
+
+import (
+	"crypto/tls"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/go-pg/pg/v9"
+)
+
+func TestSSLMode(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	rootCert := filepath.Join(dir, "root.crt")
+	cert := newTestCert(t, rootCert, "127.0.0.1")
+	tlsConf := &tls.Config{Certificates: []tls.Certificate{cert}}
+
+	type server struct {
+		tls     bool
+		sslOnly bool
+	}
+	noSSL := server{}
+	withSSL := server{tls: true}
+	sslOnly := server{tls: true, sslOnly: true}
+
+	tests := []struct {
+		mode     string
+		server   server
+		sessions []bool // nil when the connection must fail
+	}{
+		{"disable", noSSL, []bool{false}},
+		{"disable", withSSL, []bool{false}},
+		{"disable", sslOnly, nil},
+
+		{"allow", noSSL, []bool{false}},
+		{"allow", withSSL, []bool{false}},
+		{"allow", sslOnly, []bool{false, true}},
+
+		{"prefer", noSSL, []bool{false}},
+		{"prefer", withSSL, []bool{true}},
+		{"prefer", sslOnly, []bool{true}},
+
+		{"require", noSSL, nil},
+		{"require", withSSL, []bool{true}},
+		{"require", sslOnly, []bool{true}},
+
+		{"verify-ca", noSSL, nil},
+		{"verify-ca", withSSL, []bool{true}},
+		{"verify-ca", sslOnly, []bool{true}},
+
+		{"verify-full", noSSL, nil},
+		{"verify-full", withSSL, []bool{true}},
+		{"verify-full", sslOnly, []bool{true}},
+	}
+	for _, test := range tests {
+		name := fmt.Sprintf("%s tls=%v sslOnly=%v", test.mode, test.server.tls, test.server.sslOnly)
+		t.Run(name, func(t *testing.T) {
+			srv := newQueryServer(t)
+			defer srv.Close()
+			if test.server.tls {
+				srv.SetTLS(tlsConf, test.server.sslOnly)
+			}
+
+			url := fmt.Sprintf("postgres://vasya@%s/db?sslmode=%s", srv.Addr(), test.mode)
+			if test.mode == "verify-ca" || test.mode == "verify-full" {
+				url += "&sslrootcert=" + rootCert
+			}
+			opt, err := pg.ParseURL(url)
+			if err != nil {
+				t.Fatal(err)
+			}
+			opt.PoolSize = 1
+
+			db := pg.Connect(opt)
+			defer db.Close()
+
+			_, err = db.Exec("SELECT 1")
+			if test.sessions == nil {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := srv.Sessions(); !reflect.DeepEqual(got, test.sessions) {
+				t.Fatalf("got SSL sessions %v, wanted %v", got, test.sessions)
+			}
+		})
+	}
+}
+
+func TestSSLVerifyFullMultipleHosts(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	rootCert := filepath.Join(dir, "root.crt")
+	cert := newTestCert(t, rootCert, "localhost")
+
+	srv := newQueryServer(t)
+	defer srv.Close()
+	srv.SetTLS(&tls.Config{Certificates: []tls.Certificate{cert}}, true)
+
+	_, port, err := net.SplitHostPort(srv.Addr())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range []struct {
+		hosts string
+		ok    bool
+	}{
+		// The certificate of localhost must not be accepted for 127.0.0.1
+		// even though localhost is one of the hosts.
+		{"127.0.0.1,localhost", false},
+		{"localhost,127.0.0.1", true},
+	} {
+		opt, err := pg.ParseURL(fmt.Sprintf(
+			"host=%s port=%s,%s user=vasya dbname=db sslmode=verify-full sslrootcert=%s",
+			test.hosts, port, port, rootCert))
+		if err != nil {
+			t.Fatal(err)
+		}
+		opt.PoolSize = 1
+
+		db := pg.Connect(opt)
+		_, err = db.Exec("SELECT 1")
+		_ = db.Close()
+
+		if test.ok && err != nil {
+			t.Fatalf("%s: %s", test.hosts, err)
+		}
+		if !test.ok && err == nil {
+			t.Fatalf("%s: expected a certificate error", test.hosts)
+		}
+	}
+}
@@ -0,0 +1,206 @@
+package pg_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-pg/pg/v9"
+)
+
+func TestRuntimeParams(t *testing.T) {
+	srv := newQueryServer(t)
+	defer srv.Close()
+
+	db := pg.Connect(&pg.Options{
+		Addr:            srv.Addr(),
+		User:            "vasya",
+		Database:        "db",
+		ApplicationName: "myApp",
+		RuntimeParams: map[string]string{
+			"search_path":       "app,public",
+			"statement_timeout": "5s",
+			"user":              "ignored",
+		},
+	})
+	defer db.Close()
+
+	_, err := db.Exec("SELECT 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wanted := map[string]string{
+		"user":              "vasya",
+		"database":          "db",
+		"application_name":  "myApp",
+		"search_path":       "app,public",
+		"statement_timeout": "5s",
+	}
+	got := srv.Startup()
+	if len(got) != len(wanted) {
+		t.Fatalf("got %q, wanted %q", got, wanted)
+	}
+	for name, value := range wanted {
+		if got[name] != value {
+			t.Fatalf("got %q, wanted %q", got, wanted)
+		}
+	}
+}
+
+func TestServerParamsAndNotices(t *testing.T) {
+	srv := newQueryServer(t)
+	defer srv.Close()
+
+	var notices []pg.Notice
+	db := pg.Connect(&pg.Options{
+		Addr:     srv.Addr(),
+		PoolSize: 1,
+		OnNotice: func(n pg.Notice) {
+			notices = append(notices, n)
+		},
+	})
+	defer db.Close()
+
+	params, err := db.ServerParams()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if params["server_version"] != "12.1" || params["TimeZone"] != "UTC" {
+		t.Fatalf("got %q", params)
+	}
+
+	_, err = db.Exec("SET TimeZone = 'Europe/Moscow'")
+	if err != nil {
+		t.Fatal(err)
+	}
+	params, err = db.ServerParams()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if params["TimeZone"] != "Europe/Moscow" {
+		t.Fatalf("got %q", params)
+	}
+
+	_, err = db.Exec("DO $$ BEGIN RAISE NOTICE 'hello'; END $$")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(notices) != 1 {
+		t.Fatalf("got %d notices, wanted 1", len(notices))
+	}
+	if s := notices[0].String(); s != "NOTICE #00000 hello" {
+		t.Fatalf("got %q", s)
+	}
+	if notices[0].Field('M') != "hello" {
+		t.Fatalf("got %q", notices[0].Field('M'))
+	}
+}
+
+func TestPasswordFunc(t *testing.T) {
+	srv := newQueryServer(t)
+	defer srv.Close()
+
+	token := "token1"
+	srv.SetPassword(token)
+
+	var calls int
+	var addr string
+	var vaultErr error
+	db := pg.Connect(&pg.Options{
+		Addr:     srv.Addr(),
+		Password: "static",
+		PoolSize: 1,
+		PasswordFunc: func(c context.Context, a string) (string, error) {
+			calls++
+			addr = a
+			return token, vaultErr
+		},
+		HealthCheckIdleTime: time.Nanosecond,
+	})
+	defer db.Close()
+
+	if _, err := db.Exec("SELECT 1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec("SELECT 2"); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 1 {
+		t.Fatalf("got %d calls, wanted 1", calls)
+	}
+	if addr != srv.Addr() {
+		t.Fatalf("got addr %q, wanted %q", addr, srv.Addr())
+	}
+
+	// The token is rotated and the old connection is closed.
+	token = "token2"
+	srv.SetPassword(token)
+	srv.CloseConns()
+
+	if _, err := db.Exec("SELECT 3"); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 2 {
+		t.Fatalf("got %d calls, wanted 2", calls)
+	}
+
+	vaultErr = errors.New("vault is sealed")
+	srv.CloseConns()
+
+	if _, err := db.Exec("SELECT 4"); err != vaultErr {
+		t.Fatalf("got %v, wanted %v", err, vaultErr)
+	}
+}
+
+func TestPassfileMultipleHosts(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	srv := newQueryServer(t)
+	defer srv.Close()
+	srv.SetPassword("second")
+
+	// The first host refuses connections.
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, closedPort, _ := net.SplitHostPort(ln.Addr().String())
+	_ = ln.Close()
+	_, port, _ := net.SplitHostPort(srv.Addr())
+
+	passfile := filepath.Join(dir, "pgpass")
+	err = ioutil.WriteFile(passfile, []byte(
+		"127.0.0.1:"+closedPort+":*:*:first\n"+
+			"127.0.0.1:"+port+":*:*:second\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opt, err := pg.ParseURL(fmt.Sprintf(
+		"host=127.0.0.1,127.0.0.1 port=%s,%s user=vasya dbname=db sslmode=disable passfile=%s",
+		closedPort, port, passfile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opt.Password != "first" {
+		t.Fatalf("got password %q", opt.Password)
+	}
+
+	db := pg.Connect(opt)
+	defer db.Close()
+
+	if _, err := db.Exec("SELECT 1"); err != nil {
+		t.Fatal(err)
+	}
+}
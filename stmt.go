@@ -3,6 +3,7 @@ package pg
 import (
 	"context"
 	"errors"
+	"sync/atomic"
 
 	"github.com/go-pg/pg/v9/internal"
 	"github.com/go-pg/pg/v9/internal/pool"
@@ -11,15 +12,28 @@ import (
 
 var errStmtClosed = errors.New("pg: statement is closed")
 
+// defaultStatementCacheSize is the per-connection statement cache size
+// used by prepared statements when Options.StatementCacheSize is not set.
+const defaultStatementCacheSize = 100
+
 // Stmt is a prepared statement. Stmt is safe for concurrent use by
 // multiple goroutines.
+//
+// Stmt created by DB.Prepare is not bound to a single connection.
+// The statement is prepared lazily on every pooled connection that
+// executes it and is prepared again when the connection is re-established.
+// Server-side statements are kept in the per-connection statement cache
+// and are released when the cache evicts them or the pool closes
+// the connection.
 type Stmt struct {
 	db        *baseDB
 	stickyErr error
 
-	q       string
-	name    string
-	columns [][]byte
+	q string
+
+	binaryFormat bool
+
+	_closed *uint32 // atomic, shared with copies
 }
 
 func prepareStmt(db *baseDB, q string) (*Stmt, error) {
@@ -27,6 +41,10 @@ func prepareStmt(db *baseDB, q string) (*Stmt, error) {
 		db: db,
 
 		q: q,
+
+		binaryFormat: db.opt.BinaryFormat,
+
+		_closed: new(uint32),
 	}
 
 	err := stmt.prepare(context.TODO(), q)
@@ -37,6 +55,8 @@ func prepareStmt(db *baseDB, q string) (*Stmt, error) {
 	return stmt, nil
 }
 
+// prepare prepares the statement on a pooled connection to report
+// syntax errors early. Other connections prepare it on first use.
 func (stmt *Stmt) prepare(c context.Context, q string) error {
 	var lastErr error
 	for attempt := 0; attempt <= stmt.db.opt.MaxRetries; attempt++ {
@@ -44,16 +64,10 @@ func (stmt *Stmt) prepare(c context.Context, q string) error {
 			if err := internal.Sleep(c, stmt.db.retryBackoff(attempt-1)); err != nil {
 				return err
 			}
-
-			err := stmt.db.pool.(*pool.SingleConnPool).Reset()
-			if err != nil {
-				return err
-			}
 		}
 
 		lastErr = stmt.withConn(c, func(c context.Context, cn *pool.Conn) error {
-			var err error
-			stmt.name, stmt.columns, err = stmt.db.prepare(c, cn, q)
+			_, err := stmt.db.prepareCached(c, cn, q)
 			return err
 		})
 		if !stmt.db.shouldRetry(lastErr) {
@@ -63,10 +77,17 @@ func (stmt *Stmt) prepare(c context.Context, q string) error {
 	return lastErr
 }
 
+func (stmt *Stmt) closed() bool {
+	return atomic.LoadUint32(stmt._closed) == 1
+}
+
 func (stmt *Stmt) withConn(c context.Context, fn func(context.Context, *pool.Conn) error) error {
 	if stmt.stickyErr != nil {
 		return stmt.stickyErr
 	}
+	if stmt.closed() {
+		return errStmtClosed
+	}
 	err := stmt.db.withConn(c, fn)
 	if err == pool.ErrClosed {
 		return errStmtClosed
@@ -74,6 +95,34 @@ func (stmt *Stmt) withConn(c context.Context, fn func(context.Context, *pool.Con
 	return err
 }
 
+// withStmt runs fn with the statement prepared on the connection,
+// preparing it first when the connection has not seen the statement yet.
+func (stmt *Stmt) withStmt(
+	c context.Context, fn func(context.Context, *pool.Conn, *pool.CachedStmt) error,
+) error {
+	return stmt.withConn(c, func(c context.Context, cn *pool.Conn) error {
+		cs, err := stmt.db.prepareCached(c, cn, stmt.q)
+		if err != nil {
+			return err
+		}
+
+		err = fn(c, cn, cs)
+		if err != nil && isStaleStmtError(err) {
+			stmt.db.connStmts(cn).Remove(stmt.q)
+		}
+		return err
+	})
+}
+
+// WithBinaryFormat returns a copy of the statement that sends parameters
+// and receives result columns using binary format when the types support it.
+// The copy shares the prepared statement with the original statement.
+func (stmt *Stmt) WithBinaryFormat(on bool) *Stmt {
+	cp := *stmt
+	cp.binaryFormat = on
+	return &cp
+}
+
 // Exec executes a prepared statement with the given parameters.
 func (stmt *Stmt) Exec(params ...interface{}) (Result, error) {
 	return stmt.exec(context.TODO(), params...)
@@ -100,8 +149,8 @@ func (stmt *Stmt) exec(c context.Context, params ...interface{}) (Result, error)
 			}
 		}
 
-		lastErr = stmt.withConn(c, func(c context.Context, cn *pool.Conn) error {
-			res, err = stmt.extQuery(c, cn, stmt.name, params...)
+		lastErr = stmt.withStmt(c, func(c context.Context, cn *pool.Conn, cs *pool.CachedStmt) error {
+			res, err = stmt.extQuery(c, cn, cs.Name, cs.Desc.(*stmtDesc), params...)
 			return err
 		})
 		if !stmt.db.shouldRetry(lastErr) {
@@ -165,8 +214,8 @@ func (stmt *Stmt) query(c context.Context, model interface{}, params ...interfac
 			}
 		}
 
-		lastErr = stmt.withConn(c, func(c context.Context, cn *pool.Conn) error {
-			res, err = stmt.extQueryData(c, cn, stmt.name, model, stmt.columns, params...)
+		lastErr = stmt.withStmt(c, func(c context.Context, cn *pool.Conn, cs *pool.CachedStmt) error {
+			res, err = stmt.extQueryData(c, cn, cs.Name, model, cs.Desc.(*stmtDesc), params...)
 			return err
 		})
 		if !stmt.db.shouldRetry(lastErr) {
@@ -209,27 +258,24 @@ func (stmt *Stmt) queryOne(c context.Context, model interface{}, params ...inter
 	return res, nil
 }
 
-// Close closes the statement.
+// Close closes the statement. Statements prepared on pooled connections
+// are released when the connections are closed or the statement is evicted
+// from the connection statement cache.
 func (stmt *Stmt) Close() error {
-	var firstErr error
-
-	if stmt.name != "" {
-		firstErr = stmt.closeStmt()
+	if stmt.stickyErr != nil {
+		return stmt.stickyErr
 	}
-
-	err := stmt.db.Close()
-	if err != nil && firstErr == nil {
-		firstErr = err
+	if !atomic.CompareAndSwapUint32(stmt._closed, 0, 1) {
+		return errStmtClosed
 	}
-
-	return firstErr
+	return nil
 }
 
 func (stmt *Stmt) extQuery(
-	c context.Context, cn *pool.Conn, name string, params ...interface{},
+	c context.Context, cn *pool.Conn, name string, desc *stmtDesc, params ...interface{},
 ) (Result, error) {
 	err := cn.WithWriter(c, stmt.db.opt.WriteTimeout, func(wb *pool.WriteBuffer) error {
-		return writeBindExecuteMsg(wb, name, params...)
+		return writeBindExecuteMsg(wb, name, desc, stmt.binaryFormat, params...)
 	})
 	if err != nil {
 		return nil, err
@@ -252,11 +298,11 @@ func (stmt *Stmt) extQueryData(
 	cn *pool.Conn,
 	name string,
 	model interface{},
-	columns [][]byte,
+	desc *stmtDesc,
 	params ...interface{},
 ) (Result, error) {
 	err := cn.WithWriter(c, stmt.db.opt.WriteTimeout, func(wb *pool.WriteBuffer) error {
-		return writeBindExecuteMsg(wb, name, params...)
+		return writeBindExecuteMsg(wb, name, desc, stmt.binaryFormat, params...)
 	})
 	if err != nil {
 		return nil, err
@@ -264,7 +310,8 @@ func (stmt *Stmt) extQueryData(
 
 	var res *result
 	err = cn.WithReader(c, stmt.db.opt.ReadTimeout, func(rd *internal.BufReader) error {
-		res, err = readExtQueryData(rd, model, columns)
+		res, err = readExtQueryData(
+			rd, model, desc.columns, desc.binaryColumnTypes(stmt.binaryFormat))
 		return err
 	})
 	if err != nil {
@@ -282,9 +329,3 @@ func (stmt *Stmt) extQueryData(
 
 	return res, nil
 }
-
-func (stmt *Stmt) closeStmt() error {
-	return stmt.withConn(context.TODO(), func(c context.Context, cn *pool.Conn) error {
-		return stmt.db.closeStmt(c, cn, stmt.name)
-	})
-}
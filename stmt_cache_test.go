@@ -0,0 +1,245 @@
+package pg_test
+
+import (
+	"testing"
+
+	"github.com/go-pg/pg/v9"
+	"github.com/go-pg/pg/v9/orm"
+	"github.com/go-pg/pg/v9/pgerrcode"
+)
+
+func TestStatementCache(t *testing.T) {
+	srv := newQueryServer(t)
+	defer srv.Close()
+
+	db := pg.Connect(&pg.Options{
+		Addr:               srv.Addr(),
+		PoolSize:           1,
+		StatementCacheSize: 2,
+	})
+	defer db.Close()
+
+	type Book struct {
+		Id    int
+		Title string
+	}
+
+	for _, title := range []string{"foo", "it's"} {
+		_, err := db.Exec("UPDATE books SET title = ? WHERE id = ?", title, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var books []Book
+	err := db.Model(&books).Where("id = ?", 2).Where("title IN (?)", pg.In([]int{1, 2})).Select()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Evicts the UPDATE statement.
+	_, err = db.Exec("SELECT ?::int", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Statement that failed to prepare is not cached.
+	srv.FailQuery("SELECT $1::bigint", pgerrcode.SyntaxError, 1)
+	_, err = db.Exec("SELECT ?::bigint", 4)
+	if pg.ErrorCode(err) != pgerrcode.SyntaxError {
+		t.Fatalf("got %v, wanted syntax error", err)
+	}
+	_, err = db.Exec("SELECT ?::bigint", 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Queries without params and utility statements that don't accept
+	// params are sent using the simple query protocol.
+	for _, q := range []string{
+		"CREATE TABLE a (); CREATE TABLE b ()",
+		"SET statement_timeout = ?",
+		"/* comment */ CREATE TABLE c (n int DEFAULT ?)",
+	} {
+		_, err = db.Exec(q, 5000)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Named params of a query without params, e.g. a SQL script,
+	// are formatted on the client too.
+	_, err = db.WithParam("n", 6).Exec("SELECT ?n; SELECT ?n")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wanted := []string{
+		`UPDATE books SET title = $1 WHERE id = $2`,
+		`SELECT "book"."id", "book"."title" FROM "books" AS "book" ` +
+			`WHERE (id = $1) AND (title IN (1,2))`,
+		`SELECT $1::int`,
+		`SELECT $1::bigint`,
+		`SELECT $1::bigint`,
+		`CREATE TABLE a (); CREATE TABLE b ()`,
+		`SET statement_timeout = 5000`,
+		`/* comment */ CREATE TABLE c (n int DEFAULT 5000)`,
+		`SELECT 6; SELECT 6`,
+	}
+	if got := srv.Queries(); !equalStrings(got, wanted) {
+		t.Fatalf("got %q, wanted %q", got, wanted)
+	}
+
+	wanted = []string{
+		"parse 1",
+		"bind 1 [foo 1]",
+		"bind 1 [it's 1]",
+		"parse 2",
+		"bind 2 [2]",
+		"close 1",
+		"parse 3",
+		"bind 3 [3]",
+		"close 2",
+		"parse 4",
+		"bind 4 [4]",
+		"close 4",
+		"parse 5",
+		"bind 5 [4]",
+	}
+	if got := srv.ExtMessages(); !equalStrings(got, wanted) {
+		t.Fatalf("got %q, wanted %q", got, wanted)
+	}
+}
+
+func TestStatementCacheModelValues(t *testing.T) {
+	srv := newQueryServer(t)
+	defer srv.Close()
+
+	db := pg.Connect(&pg.Options{
+		Addr:               srv.Addr(),
+		PoolSize:           1,
+		StatementCacheSize: 2,
+	})
+	defer db.Close()
+
+	type Book struct {
+		Id    int
+		Title string
+	}
+
+	for i, title := range []string{"foo", "it's"} {
+		book := &Book{Id: i + 1, Title: title}
+		if _, err := db.Model(book).Insert(); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := db.Model(book).WherePK().Update(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	wanted := []string{
+		`INSERT INTO "books" ("id", "title") VALUES ($1, $2)`,
+		`UPDATE "books" AS "book" SET "title" = $1 WHERE "book"."id" = $2`,
+	}
+	if got := srv.Queries(); !equalStrings(got, wanted) {
+		t.Fatalf("got %q, wanted %q", got, wanted)
+	}
+
+	wanted = []string{
+		"parse 1",
+		"bind 1 [1 foo]",
+		"parse 2",
+		"bind 2 [foo 1]",
+		"bind 1 [2 it's]",
+		"bind 2 [it's 2]",
+	}
+	if got := srv.ExtMessages(); !equalStrings(got, wanted) {
+		t.Fatalf("got %q, wanted %q", got, wanted)
+	}
+}
+
+func TestStatementCachePartitionBounds(t *testing.T) {
+	srv := newQueryServer(t)
+	defer srv.Close()
+
+	db := pg.Connect(&pg.Options{
+		Addr:               srv.Addr(),
+		PoolSize:           1,
+		StatementCacheSize: 2,
+	})
+	defer db.Close()
+
+	type Event struct {
+		tableName struct{} `pg:"partitionBy:LIST (country)"`
+
+		Country string
+	}
+
+	err := orm.CreatePartition(db, (*Event)(nil), "events_de", orm.ListBound("de", "it's"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = orm.AttachPartition(db, (*Event)(nil), "events_fr", orm.ListBound("fr"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wanted := []string{
+		`CREATE TABLE events_de PARTITION OF "events" FOR VALUES IN ('de','it''s')`,
+		`ALTER TABLE "events" ATTACH PARTITION events_fr FOR VALUES IN ('fr')`,
+	}
+	if got := srv.Queries(); !equalStrings(got, wanted) {
+		t.Fatalf("got %q, wanted %q", got, wanted)
+	}
+	if got := srv.ExtMessages(); len(got) != 0 {
+		t.Fatalf("got %q, wanted no extended query messages", got)
+	}
+}
+
+func TestStmtPreparedPerConn(t *testing.T) {
+	srv := newQueryServer(t)
+	defer srv.Close()
+
+	db := pg.Connect(&pg.Options{
+		Addr:     srv.Addr(),
+		PoolSize: 2,
+	})
+	defer db.Close()
+
+	stmt, err := db.Prepare("SELECT $1::int")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Take the connection the statement was prepared on.
+	conn := db.Conn()
+	if _, err := conn.Exec("SELECT 1"); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 1; i <= 2; i++ {
+		if _, err := stmt.Exec(i); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := conn.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := stmt.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stmt.Exec(3); err == nil || err.Error() != "pg: statement is closed" {
+		t.Fatalf("got %v, wanted statement is closed", err)
+	}
+
+	wanted := []string{
+		"parse 1",
+		"parse 1",
+		"bind 1 [1]",
+		"bind 1 [2]",
+	}
+	if got := srv.ExtMessages(); !equalStrings(got, wanted) {
+		t.Fatalf("got %q, wanted %q", got, wanted)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"io"
+	"strconv"
 	"sync"
 	"sync/atomic"
 
@@ -24,10 +25,18 @@ var errTxDone = errors.New("pg: transaction has already been committed or rolled
 //
 // The statements prepared for a transaction by calling the transaction's
 // Prepare or Stmt methods are closed by the call to Commit or Rollback.
+//
+// Transactions started with Tx.Begin or Tx.RunInTransaction are nested
+// transactions that use savepoints: Commit releases the savepoint and
+// Rollback rolls back to the savepoint and releases it leaving the outer
+// transaction intact.
 type Tx struct {
 	db  *baseDB
 	ctx context.Context
 
+	depth     int
+	savepoint string
+
 	stmtsMu sync.Mutex
 	stmts   []*Stmt
 
@@ -41,14 +50,66 @@ func (tx *Tx) Context() context.Context {
 	return tx.ctx
 }
 
+// IsolationLevel is a transaction isolation level.
+type IsolationLevel string
+
+const (
+	ReadUncommitted IsolationLevel = "READ UNCOMMITTED"
+	ReadCommitted   IsolationLevel = "READ COMMITTED"
+	RepeatableRead  IsolationLevel = "REPEATABLE READ"
+	Serializable    IsolationLevel = "SERIALIZABLE"
+)
+
+// TxOptions are options for BeginWithOptions and RunInTransactionWithOptions.
+type TxOptions struct {
+	// Transaction isolation level.
+	// Default is the server default, usually read committed.
+	IsolationLevel IsolationLevel
+	// Starts a READ ONLY transaction.
+	ReadOnly bool
+	// Starts a DEFERRABLE transaction. It only has effect for
+	// serializable read only transactions.
+	Deferrable bool
+
+	// Maximum number of times RunInTransactionWithOptions re-runs the function
+	// after serialization failure or deadlock.
+	// Default is Options.MaxRetries; -1 disables retries.
+	MaxRetries int
+}
+
+func (opt *TxOptions) beginQuery() string {
+	if opt == nil {
+		return "BEGIN"
+	}
+
+	b := []byte("BEGIN")
+	if opt.IsolationLevel != "" {
+		b = append(b, " ISOLATION LEVEL "...)
+		b = append(b, opt.IsolationLevel...)
+	}
+	if opt.ReadOnly {
+		b = append(b, " READ ONLY"...)
+	}
+	if opt.Deferrable {
+		b = append(b, " DEFERRABLE"...)
+	}
+	return string(b)
+}
+
 // Begin starts a transaction. Most callers should use RunInTransaction instead.
 func (db *baseDB) Begin() (*Tx, error) {
+	return db.BeginWithOptions(nil)
+}
+
+// BeginWithOptions starts a transaction with the isolation level and
+// access mode specified in opt.
+func (db *baseDB) BeginWithOptions(opt *TxOptions) (*Tx, error) {
 	tx := &Tx{
-		db:  db.withPool(pool.NewSingleConnPool(db.pool)),
+		db:  db.withPool(pool.NewSingleConnPool(db.singleConnParent())),
 		ctx: db.db.Context(),
 	}
 
-	err := tx.begin(tx.ctx)
+	err := tx.begin(tx.ctx, opt.beginQuery())
 	if err != nil {
 		tx.close()
 		return nil, err
@@ -65,18 +126,104 @@ func (db *baseDB) RunInTransaction(fn func(*Tx) error) error {
 	if err != nil {
 		return err
 	}
-	return tx.RunInTransaction(fn)
+	return tx.run(fn)
+}
+
+// RunInTransactionWithOptions is like RunInTransaction, but starts
+// the transaction with the options specified in opt. When the transaction
+// fails with serialization failure (SQLSTATE 40001) or deadlock (40P01)
+// the transaction is rolled back and the whole function is run again
+// in a new transaction using the same backoff as queries.
+// The function must be safe to re-run.
+func (db *baseDB) RunInTransactionWithOptions(opt *TxOptions, fn func(*Tx) error) error {
+	maxRetries := db.opt.MaxRetries
+	if opt != nil && opt.MaxRetries != 0 {
+		maxRetries = opt.MaxRetries
+	}
+	if maxRetries < 0 {
+		maxRetries = 0
+	}
+
+	ctx := db.db.Context()
+	var lastErr error
+	for attempt := 0; attempt <= maxRetries; attempt++ {
+		if attempt > 0 {
+			if err := internal.Sleep(ctx, db.retryBackoff(attempt-1)); err != nil {
+				return err
+			}
+		}
+
+		tx, err := db.BeginWithOptions(opt)
+		if err != nil {
+			return err
+		}
+
+		lastErr = tx.run(fn)
+		if !shouldRetryTx(lastErr) {
+			break
+		}
+	}
+	return lastErr
+}
+
+func shouldRetryTx(err error) bool {
+	return IsSerializationFailure(err) || IsDeadlock(err)
 }
 
-// Begin returns current transaction. It does not start new transaction.
+// Begin starts a nested transaction using a savepoint.
+// Most callers should use RunInTransaction instead.
 func (tx *Tx) Begin() (*Tx, error) {
-	return tx, nil
+	depth := tx.depth + 1
+	nested := &Tx{
+		db:        tx.db,
+		ctx:       tx.ctx,
+		depth:     depth,
+		savepoint: "_go_pg_sp_" + strconv.Itoa(depth),
+	}
+
+	err := tx.Savepoint(nested.savepoint)
+	if err != nil {
+		return nil, err
+	}
+
+	return nested, nil
 }
 
-// RunInTransaction runs a function in the transaction. If function
-// returns an error transaction is rollbacked, otherwise transaction
-// is committed.
+// RunInTransaction runs a function in a nested transaction using a savepoint.
+// If function returns an error nested transaction is rolled back
+// to the savepoint. In both cases the savepoint is released and
+// the outer transaction stays in progress.
 func (tx *Tx) RunInTransaction(fn func(*Tx) error) error {
+	nested, err := tx.Begin()
+	if err != nil {
+		return err
+	}
+	return nested.run(fn)
+}
+
+// Savepoint establishes a new savepoint with the name
+// within the transaction.
+func (tx *Tx) Savepoint(name string) error {
+	_, err := tx.Exec("SAVEPOINT ?", Ident(name))
+	return err
+}
+
+// RollbackTo rolls back all commands that were executed after
+// the savepoint with the name was established. The savepoint
+// remains valid and can be rolled back to again later.
+func (tx *Tx) RollbackTo(name string) error {
+	_, err := tx.Exec("ROLLBACK TO SAVEPOINT ?", Ident(name))
+	return err
+}
+
+// ReleaseSavepoint destroys the savepoint with the name keeping
+// the effects of commands executed after it was established.
+func (tx *Tx) ReleaseSavepoint(name string) error {
+	_, err := tx.Exec("RELEASE SAVEPOINT ?", Ident(name))
+	return err
+}
+
+func (tx *Tx) run(fn func(*Tx) error) error {
 	defer func() {
 		if err := recover(); err != nil {
 			_ = tx.Rollback()
@@ -91,6 +238,9 @@ func (tx *Tx) RunInTransaction(fn func(*Tx) error) error {
 }
 
 func (tx *Tx) withConn(c context.Context, fn func(context.Context, *pool.Conn) error) error {
+	if tx.closed() {
+		return errTxDone
+	}
 	err := tx.db.withConn(c, fn)
 	if err == pool.ErrClosed {
 		return errTxDone
@@ -101,11 +251,12 @@ func (tx *Tx) withConn(c context.Context, fn func(context.Context, *pool.Conn) e
 // Stmt returns a transaction-specific prepared statement
 // from an existing statement.
 func (tx *Tx) Stmt(stmt *Stmt) *Stmt {
-	stmt, err := tx.Prepare(stmt.q)
+	txStmt, err := tx.Prepare(stmt.q)
 	if err != nil {
 		return &Stmt{stickyErr: err}
 	}
-	return stmt
+	txStmt.binaryFormat = stmt.binaryFormat
+	return txStmt
 }
 
 // Prepare creates a prepared statement for use within a transaction.
@@ -145,7 +296,7 @@ func (tx *Tx) exec(c context.Context, query interface{}, params ...interface{})
 
 	var res Result
 	lastErr := tx.withConn(c, func(c context.Context, cn *pool.Conn) error {
-		res, err = tx.db.simpleQuery(c, cn, query, params...)
+		res, err = tx.db.runQuery(c, cn, query, params...)
 		return err
 	})
 
@@ -205,7 +356,7 @@ func (tx *Tx) query(
 
 	var res *result
 	lastErr := tx.withConn(c, func(c context.Context, cn *pool.Conn) error {
-		res, err = tx.db.simpleQueryData(c, cn, model, query, params...)
+		res, err = tx.db.runQueryData(c, cn, model, query, params...)
 		return err
 	})
 
@@ -215,6 +366,22 @@ func (tx *Tx) query(
 	return res, lastErr
 }
 
+// QueryRows is an alias for DB.QueryRows. Other queries of the transaction
+// return an error until the rows are closed.
+func (tx *Tx) QueryRows(query interface{}, params ...interface{}) (Rows, error) {
+	return tx.QueryRowsContext(tx.ctx, query, params...)
+}
+
+// QueryRowsContext acts like QueryRows but additionally receives a context.
+func (tx *Tx) QueryRowsContext(
+	c context.Context, query interface{}, params ...interface{},
+) (Rows, error) {
+	if tx.closed() {
+		return nil, errTxDone
+	}
+	return tx.db.queryRows(c, tx, query, params...)
+}
+
 // QueryOne is an alias for DB.QueryOne.
 func (tx *Tx) QueryOne(model interface{}, query interface{}, params ...interface{}) (Result, error) {
 	return tx.queryOne(tx.ctx, model, query, params...)
@@ -292,6 +459,11 @@ func (tx *Tx) CreateTable(model interface{}, opt *orm.CreateTableOptions) error
 	return orm.CreateTable(tx, model, opt)
 }
 
+// CreateIndexes is an alias for DB.CreateIndexes.
+func (tx *Tx) CreateIndexes(model interface{}, opt *orm.CreateIndexOptions) error {
+	return orm.CreateIndexes(tx, model, opt)
+}
+
 // DropTable is an alias for DB.DropTable.
 func (tx *Tx) DropTable(model interface{}, opt *orm.DropTableOptions) error {
 	return orm.DropTable(tx, model, opt)
@@ -320,7 +492,7 @@ func (tx *Tx) Formatter() orm.QueryFormatter {
 	return tx.db.Formatter()
 }
 
-func (tx *Tx) begin(ctx context.Context) error {
+func (tx *Tx) begin(ctx context.Context, query string) error {
 	var lastErr error
 	for attempt := 0; attempt <= tx.db.opt.MaxRetries; attempt++ {
 		if attempt > 0 {
@@ -334,7 +506,7 @@ func (tx *Tx) begin(ctx context.Context) error {
 			}
 		}
 
-		_, lastErr = tx.ExecContext(ctx, "BEGIN")
+		_, lastErr = tx.ExecContext(ctx, query)
 		if !tx.db.shouldRetry(lastErr) {
 			break
 		}
@@ -342,16 +514,32 @@ func (tx *Tx) begin(ctx context.Context) error {
 	return lastErr
 }
 
-// Commit commits the transaction.
+// Commit commits the transaction. Nested transaction releases its savepoint.
 func (tx *Tx) Commit() error {
-	_, err := tx.Exec("COMMIT")
+	var err error
+	if tx.savepoint != "" {
+		err = tx.ReleaseSavepoint(tx.savepoint)
+	} else {
+		_, err = tx.Exec("COMMIT")
+	}
 	tx.close()
 	return err
 }
 
-// Rollback aborts the transaction.
+// Rollback aborts the transaction. Nested transaction is rolled back
+// to its savepoint and releases it.
 func (tx *Tx) Rollback() error {
-	_, err := tx.Exec("ROLLBACK")
+	var err error
+	if tx.savepoint != "" {
+		err = tx.RollbackTo(tx.savepoint)
+		if err == nil {
+			// Release the savepoint, so they don't pile up when nested
+			// transactions are rolled back over and over again.
+			err = tx.ReleaseSavepoint(tx.savepoint)
+		}
+	} else {
+		_, err = tx.Exec("ROLLBACK")
+	}
 	tx.close()
 	return err
 }
@@ -376,7 +564,11 @@ func (tx *Tx) close() {
 		_ = stmt.Close()
 	}
 	tx.stmts = nil
-	_ = tx.db.Close()
+
+	// Nested transactions share the connection with the outer transaction.
+	if tx.savepoint == "" {
+		_ = tx.db.Close()
+	}
 }
 
 func (tx *Tx) closed() bool {
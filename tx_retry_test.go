@@ -0,0 +1,89 @@
+package pg_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/go-pg/pg/v9"
+	"github.com/go-pg/pg/v9/pgerrcode"
+)
+
+func TestRunInTransactionWithOptions(t *testing.T) {
+	srv := newQueryServer(t)
+	defer srv.Close()
+
+	const update = "UPDATE accounts SET balance = balance - 10"
+	srv.FailQuery(update, pgerrcode.SerializationFailure, 1)
+	srv.FailQuery("COMMIT", pgerrcode.DeadlockDetected, 1)
+
+	db := pg.Connect(&pg.Options{
+		Addr:            srv.Addr(),
+		MaxRetries:      2,
+		MinRetryBackoff: -1,
+		MaxRetryBackoff: -1,
+	})
+	defer db.Close()
+
+	opt := &pg.TxOptions{
+		IsolationLevel: pg.Serializable,
+		ReadOnly:       true,
+		Deferrable:     true,
+	}
+	var attempts int
+	err := db.RunInTransactionWithOptions(opt, func(tx *pg.Tx) error {
+		attempts++
+		_, err := tx.Exec(update)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if attempts != 3 {
+		t.Fatalf("got %d attempts, wanted 3", attempts)
+	}
+
+	begin := "BEGIN ISOLATION LEVEL SERIALIZABLE READ ONLY DEFERRABLE"
+	wanted := []string{
+		begin, update, "ROLLBACK",
+		begin, update, "COMMIT",
+		begin, update, "COMMIT",
+	}
+	if got := srv.Queries(); !equalStrings(got, wanted) {
+		t.Fatalf("got %q, wanted %q", got, wanted)
+	}
+}
+
+func TestRunInTransactionWithOptionsNoRetry(t *testing.T) {
+	srv := newQueryServer(t)
+	defer srv.Close()
+
+	db := pg.Connect(&pg.Options{
+		Addr:       srv.Addr(),
+		MaxRetries: 2,
+	})
+	defer db.Close()
+
+	srv.FailQuery("SELECT 1", pgerrcode.SerializationFailure, 1)
+	var attempts int
+	err := db.RunInTransactionWithOptions(&pg.TxOptions{MaxRetries: -1}, func(tx *pg.Tx) error {
+		attempts++
+		_, err := tx.Exec("SELECT 1")
+		return err
+	})
+	if !pg.IsSerializationFailure(err) {
+		t.Fatalf("got %v, wanted serialization failure", err)
+	}
+	if attempts != 1 {
+		t.Fatalf("got %d attempts, wanted 1", attempts)
+	}
+
+	errFn := errors.New("fn failed")
+	attempts = 0
+	err = db.RunInTransactionWithOptions(nil, func(tx *pg.Tx) error {
+		attempts++
+		return errFn
+	})
+	if err != errFn || attempts != 1 {
+		t.Fatalf("got %v after %d attempts", err, attempts)
+	}
+}
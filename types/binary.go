@@ -0,0 +1,387 @@
+package types
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/go-pg/pg/v9/internal"
+)
+
+// Format codes used by the extended query protocol.
+const (
+	TextFormat   int16 = 0
+	BinaryFormat int16 = 1
+)
+
+// PostgreSQL type OIDs that support binary format.
+const (
+	boolOID             = 16
+	byteaOID            = 17
+	int8OID             = 20
+	int2OID             = 21
+	int4OID             = 23
+	float4OID           = 700
+	float8OID           = 701
+	boolArrayOID        = 1000
+	byteaArrayOID       = 1001
+	int2ArrayOID        = 1005
+	int4ArrayOID        = 1007
+	int8ArrayOID        = 1016
+	float4ArrayOID      = 1021
+	float8ArrayOID      = 1022
+	timestampOID        = 1114
+	timestampArrayOID   = 1115
+	timestamptzOID      = 1184
+	timestamptzArrayOID = 1185
+	numericArrayOID     = 1231
+	numericOID          = 1700
+	uuidOID             = 2950
+	uuidArrayOID        = 2951
+)
+
+const (
+	numericPos = 0x0000
+	numericNeg = 0x4000
+	numericNaN = 0xC000
+)
+
+// HasBinaryFormat reports whether values of the type with the oid
+// can be sent and received using binary format.
+func HasBinaryFormat(oid uint32) bool {
+	switch oid {
+	case boolOID, byteaOID, int8OID, int2OID, int4OID, float4OID, float8OID,
+		timestampOID, timestamptzOID, numericOID, uuidOID:
+		return true
+	}
+	return arrayElemOID(oid) != 0
+}
+
+func arrayElemOID(oid uint32) uint32 {
+	switch oid {
+	case boolArrayOID:
+		return boolOID
+	case byteaArrayOID:
+		return byteaOID
+	case int2ArrayOID:
+		return int2OID
+	case int4ArrayOID:
+		return int4OID
+	case int8ArrayOID:
+		return int8OID
+	case float4ArrayOID:
+		return float4OID
+	case float8ArrayOID:
+		return float8OID
+	case timestampArrayOID:
+		return timestampOID
+	case timestamptzArrayOID:
+		return timestamptzOID
+	case numericArrayOID:
+		return numericOID
+	case uuidArrayOID:
+		return uuidOID
+	}
+	return 0
+}
+
+// AppendBinaryText converts value b received in binary format
+// to the text format and appends it to dst.
+func AppendBinaryText(dst []byte, oid uint32, b []byte) ([]byte, error) {
+	switch oid {
+	case boolOID:
+		if len(b) != 1 {
+			return nil, binaryLenError(oid, b)
+		}
+		if b[0] != 0 {
+			return append(dst, 't'), nil
+		}
+		return append(dst, 'f'), nil
+	case byteaOID:
+		dst = append(dst, `\x`...)
+		return appendHex(dst, b), nil
+	case int2OID, int4OID, int8OID:
+		n, err := decodeBinaryInt(oid, b)
+		if err != nil {
+			return nil, err
+		}
+		return strconv.AppendInt(dst, n, 10), nil
+	case float4OID, float8OID:
+		f, err := decodeBinaryFloat(oid, b)
+		if err != nil {
+			return nil, err
+		}
+		bitSize := 64
+		if oid == float4OID {
+			bitSize = 32
+		}
+		return appendFloatText(dst, f, bitSize), nil
+	case timestampOID, timestamptzOID:
+		tm, err := decodeBinaryTime(oid, b)
+		if err != nil {
+			return nil, err
+		}
+		if oid == timestampOID {
+			return tm.AppendFormat(dst, timestampFormat), nil
+		}
+		return tm.AppendFormat(dst, timestamptzFormat), nil
+	case numericOID:
+		return appendBinaryNumericText(dst, b)
+	case uuidOID:
+		if len(b) != 16 {
+			return nil, binaryLenError(oid, b)
+		}
+		return appendUUID(dst, b), nil
+	}
+
+	if elemOID := arrayElemOID(oid); elemOID != 0 {
+		return appendBinaryArrayText(dst, elemOID, b)
+	}
+	return nil, fmt.Errorf("pg: binary format is not supported for oid=%d", oid)
+}
+
+func appendFloatText(dst []byte, f float64, bitSize int) []byte {
+	switch {
+	case math.IsNaN(f):
+		return append(dst, "NaN"...)
+	case math.IsInf(f, 1):
+		return append(dst, "Infinity"...)
+	case math.IsInf(f, -1):
+		return append(dst, "-Infinity"...)
+	default:
+		return strconv.AppendFloat(dst, f, 'f', -1, bitSize)
+	}
+}
+
+func binaryLenError(oid uint32, b []byte) error {
+	return fmt.Errorf("pg: invalid binary value length=%d for oid=%d", len(b), oid)
+}
+
+func appendHex(dst, b []byte) []byte {
+	n := len(dst)
+	dst = append(dst, make([]byte, hex.EncodedLen(len(b)))...)
+	hex.Encode(dst[n:], b)
+	return dst
+}
+
+func appendUUID(dst, b []byte) []byte {
+	dst = appendHex(dst, b[:4])
+	dst = append(dst, '-')
+	dst = appendHex(dst, b[4:6])
+	dst = append(dst, '-')
+	dst = appendHex(dst, b[6:8])
+	dst = append(dst, '-')
+	dst = appendHex(dst, b[8:10])
+	dst = append(dst, '-')
+	dst = appendHex(dst, b[10:16])
+	return dst
+}
+
+func decodeBinaryInt(oid uint32, b []byte) (int64, error) {
+	switch {
+	case oid == int2OID && len(b) == 2:
+		return int64(int16(binary.BigEndian.Uint16(b))), nil
+	case oid == int4OID && len(b) == 4:
+		return int64(int32(binary.BigEndian.Uint32(b))), nil
+	case oid == int8OID && len(b) == 8:
+		return int64(binary.BigEndian.Uint64(b)), nil
+	}
+	return 0, binaryLenError(oid, b)
+}
+
+func decodeBinaryFloat(oid uint32, b []byte) (float64, error) {
+	switch {
+	case oid == float4OID && len(b) == 4:
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(b))), nil
+	case oid == float8OID && len(b) == 8:
+		return math.Float64frombits(binary.BigEndian.Uint64(b)), nil
+	}
+	return 0, binaryLenError(oid, b)
+}
+
+func decodeBinaryTime(oid uint32, b []byte) (time.Time, error) {
+	if len(b) != 8 {
+		return time.Time{}, binaryLenError(oid, b)
+	}
+	usec := int64(binary.BigEndian.Uint64(b))
+	sec := usec / 1e6
+	nsec := (usec % 1e6) * 1e3
+	return time.Unix(internal.PGEpoch.Unix()+sec, nsec).UTC(), nil
+}
+
+// appendBinaryNumericText converts numeric in binary format to its
+// decimal representation.
+func appendBinaryNumericText(dst, b []byte) ([]byte, error) {
+	if len(b) < 8 {
+		return nil, binaryLenError(numericOID, b)
+	}
+
+	ndigits := int(int16(binary.BigEndian.Uint16(b)))
+	weight := int(int16(binary.BigEndian.Uint16(b[2:])))
+	sign := binary.BigEndian.Uint16(b[4:])
+	dscale := int(int16(binary.BigEndian.Uint16(b[6:])))
+	if len(b) != 8+2*ndigits {
+		return nil, binaryLenError(numericOID, b)
+	}
+
+	if sign == numericNaN {
+		return append(dst, "NaN"...), nil
+	}
+
+	digit := func(i int) int {
+		if i < 0 || i >= ndigits {
+			return 0
+		}
+		return int(binary.BigEndian.Uint16(b[8+2*i:]))
+	}
+
+	if sign == numericNeg {
+		dst = append(dst, '-')
+	}
+
+	if weight < 0 {
+		dst = append(dst, '0')
+	} else {
+		for i := 0; i <= weight; i++ {
+			d := digit(i)
+			if i == 0 {
+				dst = strconv.AppendInt(dst, int64(d), 10)
+			} else {
+				dst = appendNumericDigit(dst, d)
+			}
+		}
+	}
+
+	if dscale > 0 {
+		dst = append(dst, '.')
+		end := len(dst) + dscale
+		for i := weight + 1; len(dst) < end; i++ {
+			dst = appendNumericDigit(dst, digit(i))
+		}
+		dst = dst[:end]
+	}
+
+	return dst, nil
+}
+
+func appendNumericDigit(dst []byte, d int) []byte {
+	return append(dst,
+		byte('0'+d/1000),
+		byte('0'+d/100%10),
+		byte('0'+d/10%10),
+		byte('0'+d%10))
+}
+
+type binaryArrayHeader struct {
+	elemOID uint32
+	dims    []int
+}
+
+func (h *binaryArrayHeader) len() int {
+	if len(h.dims) == 0 {
+		return 0
+	}
+	n := 1
+	for _, dim := range h.dims {
+		n *= dim
+	}
+	return n
+}
+
+func readBinaryArrayHeader(b []byte) (*binaryArrayHeader, []byte, error) {
+	if len(b) < 12 {
+		return nil, nil, fmt.Errorf("pg: invalid binary array length=%d", len(b))
+	}
+
+	ndim := int(binary.BigEndian.Uint32(b))
+	h := &binaryArrayHeader{
+		elemOID: binary.BigEndian.Uint32(b[8:]),
+	}
+	b = b[12:]
+
+	if len(b) < 8*ndim {
+		return nil, nil, fmt.Errorf("pg: invalid binary array with ndim=%d", ndim)
+	}
+	for i := 0; i < ndim; i++ {
+		h.dims = append(h.dims, int(binary.BigEndian.Uint32(b)))
+		b = b[8:]
+	}
+
+	return h, b, nil
+}
+
+// nextBinaryArrayElem returns next array element and the rest of the array.
+// Element is nil for NULL values.
+func nextBinaryArrayElem(b []byte) ([]byte, []byte, error) {
+	if len(b) < 4 {
+		return nil, nil, fmt.Errorf("pg: invalid binary array element")
+	}
+	n := int(int32(binary.BigEndian.Uint32(b)))
+	b = b[4:]
+	if n == -1 {
+		return nil, b, nil
+	}
+	if n < 0 || len(b) < n {
+		return nil, nil, fmt.Errorf("pg: invalid binary array element length=%d", n)
+	}
+	return b[:n], b[n:], nil
+}
+
+func appendBinaryArrayText(dst []byte, elemOID uint32, b []byte) ([]byte, error) {
+	h, b, err := readBinaryArrayHeader(b)
+	if err != nil {
+		return nil, err
+	}
+	if len(h.dims) == 0 {
+		return append(dst, "{}"...), nil
+	}
+	return appendBinaryArrayDim(dst, elemOID, h.dims, &b)
+}
+
+func appendBinaryArrayDim(dst []byte, elemOID uint32, dims []int, b *[]byte) ([]byte, error) {
+	dst = append(dst, '{')
+	for i := 0; i < dims[0]; i++ {
+		if i > 0 {
+			dst = append(dst, ',')
+		}
+
+		if len(dims) > 1 {
+			var err error
+			dst, err = appendBinaryArrayDim(dst, elemOID, dims[1:], b)
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		elem, rest, err := nextBinaryArrayElem(*b)
+		if err != nil {
+			return nil, err
+		}
+		*b = rest
+
+		if elem == nil {
+			dst = append(dst, "NULL"...)
+			continue
+		}
+
+		text, err := AppendBinaryText(nil, elemOID, elem)
+		if err != nil {
+			return nil, err
+		}
+
+		dst = append(dst, '"')
+		for _, c := range text {
+			if c == '"' || c == '\\' {
+				dst = append(dst, '\\')
+			}
+			dst = append(dst, c)
+		}
+		dst = append(dst, '"')
+	}
+	dst = append(dst, '}')
+	return dst, nil
+}
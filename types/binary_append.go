@@ -0,0 +1,306 @@
+package types
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-pg/pg/v9/internal"
+)
+
+// AppendBinary appends v encoded using binary format of the type with the oid.
+// It returns false when v can't be encoded using binary format and
+// text format should be used instead. NULL values are not handled.
+func AppendBinary(b []byte, v interface{}, oid uint32) ([]byte, bool) {
+	switch v := v.(type) {
+	case bool:
+		return appendBinaryBool(b, v, oid)
+	case int:
+		return appendBinaryInt(b, int64(v), oid)
+	case int32:
+		return appendBinaryInt(b, int64(v), oid)
+	case int64:
+		return appendBinaryInt(b, v, oid)
+	case float64:
+		return appendBinaryFloat(b, v, oid)
+	case []byte:
+		return appendBinaryBytes(b, v, oid)
+	case time.Time:
+		return appendBinaryTime(b, v, oid)
+	case *Array:
+		return appendBinaryValue(b, v.v, oid)
+	}
+	return appendBinaryValue(b, reflect.ValueOf(v), oid)
+}
+
+func appendBinaryValue(b []byte, v reflect.Value, oid uint32) ([]byte, bool) {
+	if !v.IsValid() {
+		return b, false
+	}
+
+	typ := v.Type()
+	if typ.Implements(appenderType) || typ.Implements(driverValuerType) {
+		return b, false
+	}
+
+	switch typ {
+	case timeType:
+		return appendBinaryTime(b, v.Interface().(time.Time), oid)
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return b, false
+		}
+		return appendBinaryValue(b, v.Elem(), oid)
+	case reflect.Bool:
+		return appendBinaryBool(b, v.Bool(), oid)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return appendBinaryInt(b, v.Int(), oid)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n := v.Uint()
+		if n > math.MaxInt64 {
+			return b, false
+		}
+		return appendBinaryInt(b, int64(n), oid)
+	case reflect.Float32, reflect.Float64:
+		return appendBinaryFloat(b, v.Float(), oid)
+	case reflect.String:
+		return appendBinaryString(b, v.String(), oid)
+	case reflect.Slice:
+		if typ.Elem().Kind() == reflect.Uint8 {
+			return appendBinaryBytes(b, v.Bytes(), oid)
+		}
+		return appendBinaryArray(b, v, oid)
+	case reflect.Array:
+		if typ.Elem().Kind() == reflect.Uint8 && v.Len() == 16 && oid == uuidOID {
+			for i := 0; i < 16; i++ {
+				b = append(b, byte(v.Index(i).Uint()))
+			}
+			return b, true
+		}
+		return appendBinaryArray(b, v, oid)
+	}
+	return b, false
+}
+
+func appendBinaryBool(b []byte, v bool, oid uint32) ([]byte, bool) {
+	if oid != boolOID {
+		return b, false
+	}
+	if v {
+		return append(b, 1), true
+	}
+	return append(b, 0), true
+}
+
+func appendBinaryInt(b []byte, n int64, oid uint32) ([]byte, bool) {
+	switch oid {
+	case int2OID:
+		if n < math.MinInt16 || n > math.MaxInt16 {
+			return b, false
+		}
+		return appendUint16(b, uint16(n)), true
+	case int4OID:
+		if n < math.MinInt32 || n > math.MaxInt32 {
+			return b, false
+		}
+		return appendUint32(b, uint32(n)), true
+	case int8OID:
+		return appendUint64(b, uint64(n)), true
+	case float4OID, float8OID:
+		return appendBinaryFloat(b, float64(n), oid)
+	case numericOID:
+		return appendBinaryNumeric(b, strconv.FormatInt(n, 10))
+	}
+	return b, false
+}
+
+func appendBinaryFloat(b []byte, f float64, oid uint32) ([]byte, bool) {
+	switch oid {
+	case float4OID:
+		return appendUint32(b, math.Float32bits(float32(f))), true
+	case float8OID:
+		return appendUint64(b, math.Float64bits(f)), true
+	case numericOID:
+		if math.IsNaN(f) {
+			b = appendUint16(b, 0)
+			b = appendUint16(b, 0)
+			b = appendUint16(b, numericNaN)
+			b = appendUint16(b, 0)
+			return b, true
+		}
+		if math.IsInf(f, 0) {
+			return b, false
+		}
+		return appendBinaryNumeric(b, strconv.FormatFloat(f, 'f', -1, 64))
+	}
+	return b, false
+}
+
+func appendBinaryString(b []byte, s string, oid uint32) ([]byte, bool) {
+	switch oid {
+	case uuidOID:
+		s = strings.Replace(s, "-", "", -1)
+		if len(s) != 32 {
+			return b, false
+		}
+		n := len(b)
+		b = append(b, make([]byte, 16)...)
+		if _, err := hex.Decode(b[n:], []byte(s)); err != nil {
+			return b[:n], false
+		}
+		return b, true
+	case numericOID:
+		return appendBinaryNumeric(b, s)
+	}
+	return b, false
+}
+
+func appendBinaryBytes(b []byte, bs []byte, oid uint32) ([]byte, bool) {
+	switch oid {
+	case byteaOID:
+		return append(b, bs...), true
+	case uuidOID:
+		if len(bs) != 16 {
+			return b, false
+		}
+		return append(b, bs...), true
+	}
+	return b, false
+}
+
+func appendBinaryTime(b []byte, tm time.Time, oid uint32) ([]byte, bool) {
+	switch oid {
+	case timestampOID, timestamptzOID:
+		usec := tm.Unix()*1e6 + int64(tm.Nanosecond())/1e3 - internal.PGEpoch.Unix()*1e6
+		return appendUint64(b, uint64(usec)), true
+	}
+	return b, false
+}
+
+// appendBinaryNumeric encodes decimal number s using base 10000 digits.
+func appendBinaryNumeric(b []byte, s string) ([]byte, bool) {
+	var sign uint16 = numericPos
+	switch {
+	case strings.HasPrefix(s, "-"):
+		sign = numericNeg
+		s = s[1:]
+	case strings.HasPrefix(s, "+"):
+		s = s[1:]
+	}
+
+	intPart, fracPart := s, ""
+	if ind := strings.IndexByte(s, '.'); ind >= 0 {
+		intPart, fracPart = s[:ind], s[ind+1:]
+	}
+	if intPart == "" && fracPart == "" {
+		return b, false
+	}
+	for _, part := range [...]string{intPart, fracPart} {
+		for i := 0; i < len(part); i++ {
+			if part[i] < '0' || part[i] > '9' {
+				return b, false
+			}
+		}
+	}
+	dscale := len(fracPart)
+
+	intPart = strings.TrimLeft(intPart, "0")
+	if pad := len(intPart) % 4; pad != 0 {
+		intPart = strings.Repeat("0", 4-pad) + intPart
+	}
+	if pad := len(fracPart) % 4; pad != 0 {
+		fracPart += strings.Repeat("0", 4-pad)
+	}
+
+	digits := make([]uint16, 0, (len(intPart)+len(fracPart))/4)
+	for _, part := range [...]string{intPart, fracPart} {
+		for i := 0; i < len(part); i += 4 {
+			d, _ := strconv.Atoi(part[i : i+4])
+			digits = append(digits, uint16(d))
+		}
+	}
+	weight := len(intPart)/4 - 1
+
+	for len(digits) > 0 && digits[0] == 0 {
+		digits = digits[1:]
+		weight--
+	}
+	for len(digits) > 0 && digits[len(digits)-1] == 0 {
+		digits = digits[:len(digits)-1]
+	}
+	if len(digits) == 0 {
+		weight = 0
+		sign = numericPos
+	}
+
+	b = appendUint16(b, uint16(len(digits)))
+	b = appendUint16(b, uint16(int16(weight)))
+	b = appendUint16(b, sign)
+	b = appendUint16(b, uint16(dscale))
+	for _, d := range digits {
+		b = appendUint16(b, d)
+	}
+	return b, true
+}
+
+func appendBinaryArray(b []byte, v reflect.Value, oid uint32) ([]byte, bool) {
+	elemOID := arrayElemOID(oid)
+	if elemOID == 0 {
+		return b, false
+	}
+
+	if v.Kind() == reflect.Slice && v.IsNil() {
+		return b, false
+	}
+
+	start := len(b)
+	n := v.Len()
+
+	b = appendUint32(b, 1) // ndim
+	b = appendUint32(b, 0) // has nulls
+	b = appendUint32(b, elemOID)
+	b = appendUint32(b, uint32(n))
+	b = appendUint32(b, 1) // lower bound
+
+	for i := 0; i < n; i++ {
+		elem := v.Index(i)
+		if elem.Kind() == reflect.Ptr && elem.IsNil() {
+			b = appendUint32(b, math.MaxUint32)
+			binary.BigEndian.PutUint32(b[start+4:], 1)
+			continue
+		}
+
+		elemStart := len(b)
+		b = appendUint32(b, 0)
+
+		var ok bool
+		b, ok = appendBinaryValue(b, elem, elemOID)
+		if !ok {
+			return b[:start], false
+		}
+		binary.BigEndian.PutUint32(b[elemStart:], uint32(len(b)-elemStart-4))
+	}
+
+	return b, true
+}
+
+func appendUint16(b []byte, n uint16) []byte {
+	return append(b, byte(n>>8), byte(n))
+}
+
+func appendUint32(b []byte, n uint32) []byte {
+	return append(b, byte(n>>24), byte(n>>16), byte(n>>8), byte(n))
+}
+
+func appendUint64(b []byte, n uint64) []byte {
+	return append(b,
+		byte(n>>56), byte(n>>48), byte(n>>40), byte(n>>32),
+		byte(n>>24), byte(n>>16), byte(n>>8), byte(n))
+}
@@ -0,0 +1,356 @@
+package types
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"sync"
+)
+
+// BinaryScannerFunc scans a value received in binary format
+// of the type with the oid.
+type BinaryScannerFunc func(v reflect.Value, oid uint32, rd Reader, n int) error
+
+// BinaryScanner returns BinaryScannerFunc for the type. Values that can't be
+// decoded directly are converted to the text format and scanned with fn.
+func BinaryScanner(typ reflect.Type, fn ScannerFunc) BinaryScannerFunc {
+	if fn == nil {
+		return nil
+	}
+	if hasCustomScanner(typ) {
+		return textBinaryScanner(fn)
+	}
+
+	switch typ {
+	case timeType:
+		return func(v reflect.Value, oid uint32, rd Reader, n int) error {
+			if n == -1 || (oid != timestampOID && oid != timestamptzOID) {
+				return scanBinaryAsText(fn, v, oid, rd, n)
+			}
+			if !v.CanSet() {
+				return fmt.Errorf("pg: Scan(nonsettable %s)", v.Type())
+			}
+			b, err := rd.ReadFullTemp()
+			if err != nil {
+				return err
+			}
+			tm, err := decodeBinaryTime(oid, b)
+			if err != nil {
+				return err
+			}
+			v.Set(reflect.ValueOf(tm))
+			return nil
+		}
+	}
+
+	switch typ.Kind() {
+	case reflect.Ptr:
+		return binaryPtrScanner(typ, fn)
+	case reflect.Bool:
+		return binaryKindScanner(fn, scanBinaryBool)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return binaryKindScanner(fn, scanBinaryInt)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return binaryKindScanner(fn, scanBinaryUint)
+	case reflect.Float32, reflect.Float64:
+		return binaryKindScanner(fn, scanBinaryFloat)
+	case reflect.Slice:
+		if typ.Elem().Kind() == reflect.Uint8 {
+			return binaryKindScanner(fn, scanBinaryBytes)
+		}
+		return binarySliceScanner(typ, fn)
+	case reflect.Array:
+		if typ.Elem().Kind() == reflect.Uint8 && typ.Len() == 16 {
+			return binaryKindScanner(fn, scanBinaryUUIDArray)
+		}
+	}
+
+	return textBinaryScanner(fn)
+}
+
+func hasCustomScanner(typ reflect.Type) bool {
+	return typ.Implements(valueScannerType) ||
+		reflect.PtrTo(typ).Implements(valueScannerType) ||
+		typ.Implements(sqlScannerType) ||
+		reflect.PtrTo(typ).Implements(sqlScannerType)
+}
+
+func textBinaryScanner(fn ScannerFunc) BinaryScannerFunc {
+	return func(v reflect.Value, oid uint32, rd Reader, n int) error {
+		return scanBinaryAsText(fn, v, oid, rd, n)
+	}
+}
+
+func scanBinaryAsText(fn ScannerFunc, v reflect.Value, oid uint32, rd Reader, n int) error {
+	if n == -1 {
+		return fn(v, rd, n)
+	}
+
+	b, err := rd.ReadFullTemp()
+	if err != nil {
+		return err
+	}
+
+	text, err := AppendBinaryText(nil, oid, b)
+	if err != nil {
+		return err
+	}
+	return fn(v, NewBytesReader(text), len(text))
+}
+
+// binaryKindScanner decodes values directly into v with the decode func.
+// The decode func returns false when the oid is not supported.
+func binaryKindScanner(
+	fn ScannerFunc, decode func(v reflect.Value, oid uint32, b []byte) (bool, error),
+) BinaryScannerFunc {
+	return func(v reflect.Value, oid uint32, rd Reader, n int) error {
+		if n == -1 {
+			return fn(v, rd, n)
+		}
+		if !v.CanSet() {
+			return fmt.Errorf("pg: Scan(nonsettable %s)", v.Type())
+		}
+
+		b, err := rd.ReadFullTemp()
+		if err != nil {
+			return err
+		}
+
+		ok, err := decode(v, oid, b)
+		if err != nil {
+			return err
+		}
+		if ok {
+			return nil
+		}
+
+		text, err := AppendBinaryText(nil, oid, b)
+		if err != nil {
+			return err
+		}
+		return fn(v, NewBytesReader(text), len(text))
+	}
+}
+
+func binaryPtrScanner(typ reflect.Type, fn ScannerFunc) BinaryScannerFunc {
+	scanner := BinaryScanner(typ.Elem(), Scanner(typ.Elem()))
+	return func(v reflect.Value, oid uint32, rd Reader, n int) error {
+		if n == -1 || scanner == nil {
+			return fn(v, rd, n)
+		}
+
+		if v.IsNil() {
+			if !v.CanSet() {
+				return fmt.Errorf("pg: Scan(nonsettable %s)", v.Type())
+			}
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+
+		return scanner(v.Elem(), oid, rd, n)
+	}
+}
+
+func scanBinaryBool(v reflect.Value, oid uint32, b []byte) (bool, error) {
+	if oid != boolOID {
+		return false, nil
+	}
+	if len(b) != 1 {
+		return false, binaryLenError(oid, b)
+	}
+	v.SetBool(b[0] != 0)
+	return true, nil
+}
+
+func scanBinaryInt(v reflect.Value, oid uint32, b []byte) (bool, error) {
+	switch oid {
+	case int2OID, int4OID, int8OID:
+	default:
+		return false, nil
+	}
+
+	n, err := decodeBinaryInt(oid, b)
+	if err != nil {
+		return false, err
+	}
+	if v.OverflowInt(n) {
+		return false, fmt.Errorf("pg: %d overflows %s", n, v.Type())
+	}
+	v.SetInt(n)
+	return true, nil
+}
+
+func scanBinaryUint(v reflect.Value, oid uint32, b []byte) (bool, error) {
+	switch oid {
+	case int2OID, int4OID, int8OID:
+	default:
+		return false, nil
+	}
+
+	n, err := decodeBinaryInt(oid, b)
+	if err != nil {
+		return false, err
+	}
+	if n < 0 || v.OverflowUint(uint64(n)) {
+		return false, fmt.Errorf("pg: %d overflows %s", n, v.Type())
+	}
+	v.SetUint(uint64(n))
+	return true, nil
+}
+
+func scanBinaryFloat(v reflect.Value, oid uint32, b []byte) (bool, error) {
+	switch oid {
+	case float4OID, float8OID:
+		f, err := decodeBinaryFloat(oid, b)
+		if err != nil {
+			return false, err
+		}
+		v.SetFloat(f)
+		return true, nil
+	case int2OID, int4OID, int8OID:
+		n, err := decodeBinaryInt(oid, b)
+		if err != nil {
+			return false, err
+		}
+		v.SetFloat(float64(n))
+		return true, nil
+	case numericOID:
+		text, err := appendBinaryNumericText(nil, b)
+		if err != nil {
+			return false, err
+		}
+		f, err := strconv.ParseFloat(string(text), v.Type().Bits())
+		if err != nil {
+			return false, err
+		}
+		v.SetFloat(f)
+		return true, nil
+	}
+	return false, nil
+}
+
+func scanBinaryBytes(v reflect.Value, oid uint32, b []byte) (bool, error) {
+	switch oid {
+	case byteaOID:
+		bs := make([]byte, len(b))
+		copy(bs, b)
+		v.SetBytes(bs)
+		return true, nil
+	case uuidOID:
+		// Like in text format, []byte gets the text form of uuid.
+		// Only [16]byte gets the raw bytes.
+		bs, err := AppendBinaryText(nil, oid, b)
+		if err != nil {
+			return false, err
+		}
+		v.SetBytes(bs)
+		return true, nil
+	}
+	return false, nil
+}
+
+func scanBinaryUUIDArray(v reflect.Value, oid uint32, b []byte) (bool, error) {
+	if oid != uuidOID {
+		return false, nil
+	}
+	if len(b) != 16 {
+		return false, binaryLenError(oid, b)
+	}
+	reflect.Copy(v, reflect.ValueOf(b))
+	return true, nil
+}
+
+func binarySliceScanner(typ reflect.Type, fn ScannerFunc) BinaryScannerFunc {
+	elemType := typ.Elem()
+	elemScanner := BinaryScanner(elemType, Scanner(elemType))
+	if elemScanner == nil {
+		return textBinaryScanner(fn)
+	}
+
+	return func(v reflect.Value, oid uint32, rd Reader, n int) error {
+		if n == -1 || arrayElemOID(oid) == 0 {
+			return scanBinaryAsText(fn, v, oid, rd, n)
+		}
+		if !v.CanSet() {
+			return fmt.Errorf("pg: Scan(nonsettable %s)", v.Type())
+		}
+
+		b, err := rd.ReadFullTemp()
+		if err != nil {
+			return err
+		}
+
+		h, elems, err := readBinaryArrayHeader(b)
+		if err != nil {
+			return err
+		}
+		if len(h.dims) > 1 {
+			text, err := AppendBinaryText(nil, oid, b)
+			if err != nil {
+				return err
+			}
+			return fn(v, NewBytesReader(text), len(text))
+		}
+
+		size := h.len()
+		if v.IsNil() || v.Cap() < size {
+			v.Set(reflect.MakeSlice(typ, size, size))
+		} else {
+			v.SetLen(size)
+		}
+
+		elemRd := NewBytesReader(nil)
+		for i := 0; i < size; i++ {
+			var elem []byte
+			elem, elems, err = nextBinaryArrayElem(elems)
+			if err != nil {
+				return err
+			}
+
+			elemLen := -1
+			if elem != nil {
+				elemLen = len(elem)
+			}
+			elemRd.Reset(elem)
+
+			err = elemScanner(v.Index(i), h.elemOID, elemRd, elemLen)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+}
+
+// ScanBinary scans value received in binary format into v.
+func ScanBinary(v interface{}, oid uint32, rd Reader, n int) error {
+	vv := reflect.ValueOf(v)
+	if !vv.IsValid() || vv.Kind() != reflect.Ptr {
+		return fmt.Errorf("pg: Scan(nonsettable %T)", v)
+	}
+	return ScanBinaryValue(vv.Elem(), oid, rd, n)
+}
+
+// ScanBinaryValue scans value received in binary format into v.
+func ScanBinaryValue(v reflect.Value, oid uint32, rd Reader, n int) error {
+	if !v.IsValid() {
+		return fmt.Errorf("pg: Scan(nil)")
+	}
+
+	scanner := binaryScanner(v.Type())
+	if scanner == nil {
+		return fmt.Errorf("pg: Scan(unsupported %s)", v.Type())
+	}
+	return scanner(v, oid, rd, n)
+}
+
+var binaryScannersMap sync.Map
+
+func binaryScanner(typ reflect.Type) BinaryScannerFunc {
+	if v, ok := binaryScannersMap.Load(typ); ok {
+		return v.(BinaryScannerFunc)
+	}
+	fn := BinaryScanner(typ, Scanner(typ))
+	_, _ = binaryScannersMap.LoadOrStore(typ, fn)
+	return fn
+}
@@ -0,0 +1,159 @@
+package types
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+)
+
+var binaryTextTests = []struct {
+	v    interface{}
+	oid  uint32
+	text string
+}{
+	{true, boolOID, "t"},
+	{false, boolOID, "f"},
+	{int64(-12345), int2OID, "-12345"},
+	{int64(123456789), int4OID, "123456789"},
+	{int64(-1234567890123), int8OID, "-1234567890123"},
+	{1.5, float4OID, "1.5"},
+	{-0.125, float8OID, "-0.125"},
+	{[]byte("hello"), byteaOID, `\x68656c6c6f`},
+	{"a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11", uuidOID, "a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11"},
+	{"0", numericOID, "0"},
+	{"12345.6789", numericOID, "12345.6789"},
+	{"-0.00012", numericOID, "-0.00012"},
+	{"100000000", numericOID, "100000000"},
+	{"1.10", numericOID, "1.10"},
+	{
+		time.Date(2019, time.March, 4, 5, 6, 7, 891000000, time.UTC),
+		timestamptzOID,
+		"2019-03-04 05:06:07.891+00:00:00",
+	},
+	{[]int{1, 2, 3}, int4ArrayOID, `{"1","2","3"}`},
+	{[]*int64{nil}, int8ArrayOID, `{NULL}`},
+}
+
+func TestBinaryText(t *testing.T) {
+	for _, test := range binaryTextTests {
+		b, ok := AppendBinary(nil, test.v, test.oid)
+		if !ok {
+			t.Fatalf("AppendBinary(%#v, %d) failed", test.v, test.oid)
+		}
+
+		text, err := AppendBinaryText(nil, test.oid, b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(text) != test.text {
+			t.Fatalf("got %q, wanted %q", text, test.text)
+		}
+	}
+}
+
+func TestAppendBinaryUnsupported(t *testing.T) {
+	tests := []struct {
+		v   interface{}
+		oid uint32
+	}{
+		{"hello", int4OID},
+		{int64(1 << 40), int4OID},
+		{"1e10", numericOID},
+		{[]string{"a"}, int4ArrayOID},
+		{struct{}{}, boolOID},
+	}
+	for _, test := range tests {
+		if _, ok := AppendBinary(nil, test.v, test.oid); ok {
+			t.Fatalf("AppendBinary(%#v, %d) succeeded", test.v, test.oid)
+		}
+	}
+}
+
+func TestScanBinary(t *testing.T) {
+	tm := time.Date(2019, time.March, 4, 5, 6, 7, 891000000, time.UTC)
+
+	tests := []struct {
+		v    interface{}
+		oid  uint32
+		dst  interface{}
+		want interface{}
+	}{
+		{true, boolOID, new(bool), true},
+		{int64(-42), int2OID, new(int), -42},
+		{int64(42), int8OID, new(uint32), uint32(42)},
+		{2.5, float8OID, new(float32), float32(2.5)},
+		{"12.25", numericOID, new(float64), 12.25},
+		{"12.25", numericOID, new(string), "12.25"},
+		{[]byte{1, 2, 3}, byteaOID, new([]byte), []byte{1, 2, 3}},
+		{tm, timestamptzOID, new(time.Time), tm},
+		{tm, timestamptzOID, new(*time.Time), &tm},
+		{[]int64{1, 2}, int8ArrayOID, new([]int), []int{1, 2}},
+		{[]float64{1.5}, float8ArrayOID, new([]float64), []float64{1.5}},
+	}
+	for _, test := range tests {
+		b, ok := AppendBinary(nil, test.v, test.oid)
+		if !ok {
+			t.Fatalf("AppendBinary(%#v, %d) failed", test.v, test.oid)
+		}
+
+		err := ScanBinary(test.dst, test.oid, NewBytesReader(b), len(b))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got := reflect.ValueOf(test.dst).Elem().Interface()
+		if !reflect.DeepEqual(got, test.want) {
+			t.Fatalf("got %#v, wanted %#v", got, test.want)
+		}
+	}
+}
+
+func TestScanBinaryNull(t *testing.T) {
+	n := 42
+	dst := &n
+	err := ScanBinary(&dst, int4OID, NewBytesReader(nil), -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dst != nil {
+		t.Fatalf("got %v, wanted nil", *dst)
+	}
+}
+
+func TestScanBinaryUUID(t *testing.T) {
+	const s = "a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11"
+	b, ok := AppendBinary(nil, s, uuidOID)
+	if !ok {
+		t.Fatal("AppendBinary failed")
+	}
+
+	var arr [16]byte
+	if err := ScanBinary(&arr, uuidOID, NewBytesReader(b), len(b)); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(arr[:], b) {
+		t.Fatalf("got %x, wanted %x", arr, b)
+	}
+
+	var str string
+	if err := ScanBinary(&str, uuidOID, NewBytesReader(b), len(b)); err != nil {
+		t.Fatal(err)
+	}
+	if str != s {
+		t.Fatalf("got %q, wanted %q", str, s)
+	}
+
+	// []byte gets the same text form that the server sends in text format.
+	var text string
+	if err := Scan(&text, NewBytesReader([]byte(s)), len(s)); err != nil {
+		t.Fatal(err)
+	}
+	var bin []byte
+	if err := ScanBinary(&bin, uuidOID, NewBytesReader(b), len(b)); err != nil {
+		t.Fatal(err)
+	}
+	if string(bin) != text {
+		t.Fatalf("got %q in binary format, wanted %q like in text format", bin, text)
+	}
+}
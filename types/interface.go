@@ -35,7 +35,7 @@ var qWarn sync.Once
 
 func (q Q) AppendValue(b []byte, flags int) ([]byte, error) {
 	qWarn.Do(func() {
-		internal.Logger.Printf("DEPRECATED: types.Q is replaced with pg.Safe")
+		internal.Warn("DEPRECATED: types.Q is replaced with pg.Safe")
 	})
 	return append(b, q...), nil
 }
@@ -61,7 +61,7 @@ var fWarn sync.Once
 
 func (f F) AppendValue(b []byte, flags int) ([]byte, error) {
 	fWarn.Do(func() {
-		internal.Logger.Printf("DEPRECATED: types.F is replaced with pg.Ident")
+		internal.Warn("DEPRECATED: types.F is replaced with pg.Ident")
 	})
 	return AppendIdent(b, string(f), flags), nil
 }